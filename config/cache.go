@@ -0,0 +1,26 @@
+package config
+
+import (
+	"codebase-service/util/cache"
+	"log/slog"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// NewCacheClient returns the cache client on rdb, a nil rdb caches nothing.
+func NewCacheClient(cfg *Config, rdb *redis.Client, logger *slog.Logger) (*cache.Client, error) {
+	codec, err := cache.NewCodec(cfg.CacheCodec)
+	if err != nil {
+		return nil, err
+	}
+
+	return cache.NewClient(rdb, cache.Config{
+		TTL:         cfg.CacheTTL,
+		TTLs:        cfg.CacheTTLs,
+		NegativeTTL: cfg.CacheNegativeTTL,
+		L1Size:      cfg.CacheL1Size,
+		L1TTL:       cfg.CacheL1TTL,
+		Codec:       codec,
+		Logger:      logger,
+	}), nil
+}
@@ -11,6 +11,7 @@ type Config struct {
 	AppPort      string
 	LogLevel     string
 	LogAddSource bool
+	LogFormat    string
 	DBHost       string
 	DBPort       int
 	DBUser       string
@@ -22,11 +23,50 @@ type Config struct {
 	ClientKey    string
 	ServerKey    string
 	MerchantID   string
+	PaymentURL   string
 
-	RedisHost string
-	RedisPort string
-	RedisPass string
-	RedisDB   int
+	RedisHost    string
+	RedisPort    string
+	RedisPass    string
+	RedisDB      int
+	RedisTimeout time.Duration
+	CacheEnabled bool
+
+	CacheTTL         time.Duration
+	CacheTTLs        map[string]time.Duration
+	CacheNegativeTTL time.Duration
+	CacheL1Size      int
+	CacheL1TTL       time.Duration
+	CacheCodec       string
+
+	CacheWarmUpEnabled  bool
+	CacheWarmUpProducts int
+	CacheWarmUpPages    int
+
+	ReservationTTL           time.Duration
+	ReservationSweepInterval time.Duration
+	PublishSchedulerInterval time.Duration
+
+	ProductRestoreWindow time.Duration
+	ProductPurgeAfter    time.Duration
+	ProductPurgeInterval time.Duration
+
+	ModerationEnabled     bool
+	ModerationBannedWords []string
+	NotificationURL       string
+
+	UploadMaxSize    int64
+	StorageDriver    string
+	StorageLocalDir  string
+	StoragePublicURL string
+	S3Endpoint       string
+	S3Region         string
+	S3Bucket         string
+	S3AccessKey      string
+	S3SecretKey      string
+	S3UseSSL         bool
+
+	ImportMaxSize int64
 }
 
 func LoadConfig() (*Config, error) {
@@ -36,6 +76,9 @@ func LoadConfig() (*Config, error) {
 	viper.AddConfigPath(".")
 	viper.AutomaticEnv()
 	viper.SetConfigType("yaml")
+	viper.SetDefault("CACHE_ENABLED", true)
+	viper.SetDefault("CACHE_L1_SIZE", 10000)
+	viper.SetDefault("CACHE_WARMUP_ENABLED", true)
 
 	if err := viper.ReadInConfig(); err != nil {
 		return nil, fmt.Errorf("cannot read config file: %w", err)
@@ -54,11 +97,107 @@ func LoadConfig() (*Config, error) {
 		ClientKey:   viper.GetString("CLIENT_KEY"),
 		ServerKey:   viper.GetString("SERVER_KEY"),
 		MerchantID:  viper.GetString("MERCHANT_ID"),
+		PaymentURL:  viper.GetString("PAYMENT_URL"),
+
+		LogLevel:     viper.GetString("LOG_LEVEL"),
+		LogAddSource: viper.GetBool("LOG_ADD_SOURCE"),
+		LogFormat:    viper.GetString("LOG_FORMAT"),
+
+		RedisHost:    viper.GetString("REDIS_HOST"),
+		RedisPort:    viper.GetString("REDIS_PORT"),
+		RedisPass:    viper.GetString("REDIS_PASS"),
+		RedisDB:      viper.GetInt("REDIS_DB"),
+		RedisTimeout: viper.GetDuration("REDIS_TIMEOUT"),
+		CacheEnabled: viper.GetBool("CACHE_ENABLED"),
+
+		CacheTTL:         viper.GetDuration("CACHE_TTL"),
+		CacheNegativeTTL: viper.GetDuration("CACHE_NEGATIVE_TTL"),
+		CacheL1Size:      viper.GetInt("CACHE_L1_SIZE"),
+		CacheL1TTL:       viper.GetDuration("CACHE_L1_TTL"),
+		CacheCodec:       viper.GetString("CACHE_CODEC"),
+
+		CacheWarmUpEnabled:  viper.GetBool("CACHE_WARMUP_ENABLED"),
+		CacheWarmUpProducts: viper.GetInt("CACHE_WARMUP_PRODUCTS"),
+		CacheWarmUpPages:    viper.GetInt("CACHE_WARMUP_PAGES"),
+
+		ReservationTTL:           viper.GetDuration("RESERVATION_TTL"),
+		ReservationSweepInterval: viper.GetDuration("RESERVATION_SWEEP_INTERVAL"),
+		PublishSchedulerInterval: viper.GetDuration("PUBLISH_SCHEDULER_INTERVAL"),
+
+		ProductRestoreWindow: viper.GetDuration("PRODUCT_RESTORE_WINDOW"),
+		ProductPurgeAfter:    viper.GetDuration("PRODUCT_PURGE_AFTER"),
+		ProductPurgeInterval: viper.GetDuration("PRODUCT_PURGE_INTERVAL"),
+
+		ModerationEnabled:     viper.GetBool("MODERATION_ENABLED"),
+		ModerationBannedWords: viper.GetStringSlice("MODERATION_BANNED_WORDS"),
+		NotificationURL:       viper.GetString("NOTIFICATION_URL"),
+
+		UploadMaxSize:    viper.GetInt64("UPLOAD_MAX_SIZE"),
+		StorageDriver:    viper.GetString("STORAGE_DRIVER"),
+		StorageLocalDir:  viper.GetString("STORAGE_LOCAL_DIR"),
+		StoragePublicURL: viper.GetString("STORAGE_PUBLIC_URL"),
+		S3Endpoint:       viper.GetString("S3_ENDPOINT"),
+		S3Region:         viper.GetString("S3_REGION"),
+		S3Bucket:         viper.GetString("S3_BUCKET"),
+		S3AccessKey:      viper.GetString("S3_ACCESS_KEY"),
+		S3SecretKey:      viper.GetString("S3_SECRET_KEY"),
+		S3UseSSL:         viper.GetBool("S3_USE_SSL"),
+
+		ImportMaxSize: viper.GetInt64("IMPORT_MAX_SIZE"),
+	}
+
+	if config.RedisTimeout <= 0 {
+		config.RedisTimeout = time.Second
+	}
+
+	config.CacheTTLs = make(map[string]time.Duration)
+	for namespace, value := range viper.GetStringMapString("CACHE_TTLS") {
+		ttl, err := time.ParseDuration(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CACHE_TTLS value for %s: %w", namespace, err)
+		}
+		config.CacheTTLs[namespace] = ttl
+	}
+
+	if config.ReservationTTL <= 0 {
+		config.ReservationTTL = 15 * time.Minute
+	}
+
+	if config.ReservationSweepInterval <= 0 {
+		config.ReservationSweepInterval = time.Minute
+	}
+
+	if config.PublishSchedulerInterval <= 0 {
+		config.PublishSchedulerInterval = time.Minute
+	}
+
+	if config.ProductRestoreWindow <= 0 {
+		config.ProductRestoreWindow = 30 * 24 * time.Hour
+	}
+
+	// a product must stay restorable for the whole window
+	if config.ProductPurgeAfter < config.ProductRestoreWindow {
+		config.ProductPurgeAfter = config.ProductRestoreWindow
+	}
+
+	if config.ProductPurgeInterval <= 0 {
+		config.ProductPurgeInterval = time.Hour
+	}
+
+	if config.UploadMaxSize <= 0 {
+		config.UploadMaxSize = 5 << 20
+	}
+
+	if config.ImportMaxSize <= 0 {
+		config.ImportMaxSize = 20 << 20
+	}
+
+	if config.StorageLocalDir == "" {
+		config.StorageLocalDir = "./uploads"
+	}
 
-		RedisHost: viper.GetString("REDIS_HOST"),
-		RedisPort: viper.GetString("REDIS_PORT"),
-		RedisPass: viper.GetString("REDIS_PASS"),
-		RedisDB:   viper.GetInt("REDIS_DB"),
+	if config.StoragePublicURL == "" && config.StorageDriver != "s3" {
+		config.StoragePublicURL = fmt.Sprintf("http://localhost:%s%s/uploads/files", config.AppPort, config.BaseURLPath)
 	}
 
 	return config, nil
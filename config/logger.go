@@ -0,0 +1,16 @@
+package config
+
+import (
+	"codebase-service/util/logging"
+	"log/slog"
+	"os"
+)
+
+// NewLogger returns the logger every layer writes to, on stdout.
+func NewLogger(cfg *Config) (*slog.Logger, error) {
+	return logging.New(os.Stdout, logging.Options{
+		Level:     cfg.LogLevel,
+		Format:    cfg.LogFormat,
+		AddSource: cfg.LogAddSource,
+	})
+}
@@ -0,0 +1,16 @@
+package config
+
+import (
+	"codebase-service/helper"
+	"codebase-service/util/payment"
+)
+
+// NewPaymentProvider calls the gateway at PaymentURL with the server key.
+// Without a PaymentURL refunds fail with payment.ErrNotConfigured.
+func NewPaymentProvider(cfg *Config) payment.Provider {
+	return payment.NewGateway(payment.Connection{
+		URL:       cfg.PaymentURL,
+		ServerKey: cfg.ServerKey,
+		Client:    helper.DefaultNetClient,
+	})
+}
@@ -8,25 +8,33 @@ import (
 	"github.com/redis/go-redis/v9"
 )
 
+// RedisConnection.Timeout bounds every redis command, it keeps requests fast
+// while redis is slow or gone and the cache breaker has not opened yet.
 type RedisConnection struct {
-	Host string
-	Port string
-	Pass string
-	DB   int
+	Host    string
+	Port    string
+	Pass    string
+	DB      int
+	Timeout time.Duration
 }
 
+// ConnectToRedis returns the client even when redis does not answer the
+// ping, the client reconnects on its own once redis is reachable.
 func ConnectToRedis(conn RedisConnection) (*redis.Client, error) {
 	client := redis.NewClient(&redis.Options{
-		Addr:     fmt.Sprintf("%s:%s", conn.Host, conn.Port),
-		Password: conn.Pass,
-		DB:       conn.DB,
+		Addr:         fmt.Sprintf("%s:%s", conn.Host, conn.Port),
+		Password:     conn.Pass,
+		DB:           conn.DB,
+		DialTimeout:  conn.Timeout,
+		ReadTimeout:  conn.Timeout,
+		WriteTimeout: conn.Timeout,
 	})
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	err := client.Ping(ctx).Err()
 	if err != nil {
-		return nil, fmt.Errorf("cannot connect to redis: %w", err)
+		return client, fmt.Errorf("cannot connect to redis: %w", err)
 	}
 
 	return client, nil
@@ -0,0 +1,25 @@
+package config
+
+import (
+	"codebase-service/util/storage"
+	"fmt"
+)
+
+func NewBlobStore(cfg *Config) (storage.BlobStore, error) {
+	switch cfg.StorageDriver {
+	case "", "local":
+		return storage.NewLocalStore(cfg.StorageLocalDir, cfg.StoragePublicURL)
+	case "s3":
+		return storage.NewS3Store(storage.S3Connection{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			UseSSL:    cfg.S3UseSSL,
+			PublicURL: cfg.StoragePublicURL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.StorageDriver)
+	}
+}
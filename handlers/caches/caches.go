@@ -0,0 +1,120 @@
+package caches
+
+import (
+	"codebase-service/helper"
+	model "codebase-service/models"
+	"codebase-service/usecases/caches"
+	"codebase-service/util/middleware"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-playground/validator"
+)
+
+type Handler struct {
+	Svc    caches.CacheSvc
+	v      *validator.Validate
+	logger *slog.Logger
+}
+
+func NewHandler(Svc caches.CacheSvc, v *validator.Validate, logger *slog.Logger) *Handler {
+	return &Handler{
+		Svc:    Svc,
+		v:      v,
+		logger: logger.With("component", "handler.caches"),
+	}
+}
+
+func (h *Handler) GetNamespaces(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.GetCacheNamespacesReq)
+
+	req.Role = middleware.GetRole(r.Context())
+
+	bRes, err := h.Svc.GetNamespaces(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusOK, bRes)
+}
+
+func (h *Handler) InspectCache(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.InspectCacheReq)
+
+	req.Namespace = r.PathValue("namespace")
+	req.Key = r.PathValue("key")
+	req.Role = middleware.GetRole(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "InspectCache", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.InspectCache(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusOK, bRes)
+}
+
+func (h *Handler) PurgeProductCache(w http.ResponseWriter, r *http.Request) {
+	h.purgeCache(w, r, &model.PurgeCacheReq{ProductId: r.PathValue("id")})
+}
+
+func (h *Handler) PurgeShopCache(w http.ResponseWriter, r *http.Request) {
+	h.purgeCache(w, r, &model.PurgeCacheReq{ShopId: r.PathValue("id")})
+}
+
+func (h *Handler) PurgeNamespace(w http.ResponseWriter, r *http.Request) {
+	h.purgeCache(w, r, &model.PurgeCacheReq{Namespace: r.PathValue("namespace")})
+}
+
+func (h *Handler) purgeCache(w http.ResponseWriter, r *http.Request, req *model.PurgeCacheReq) {
+	req.Role = middleware.GetRole(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "purgeCache", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.PurgeCache(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusOK, bRes)
+}
+
+func (h *Handler) WarmCache(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.WarmCacheReq)
+	// the body is optional, the defaults warm the usual set
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		helper.HandleError(w, r, helper.ErrInvalidBody)
+		return
+	}
+
+	req.Role = middleware.GetRole(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "WarmCache", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.WarmCache(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusOK, bRes)
+}
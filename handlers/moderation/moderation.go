@@ -0,0 +1,90 @@
+package moderation
+
+import (
+	"codebase-service/helper"
+	model "codebase-service/models"
+	"codebase-service/usecases/moderation"
+	"codebase-service/util/middleware"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-playground/validator"
+)
+
+type Handler struct {
+	Svc    moderation.ModerationSvc
+	v      *validator.Validate
+	logger *slog.Logger
+}
+
+func NewHandler(Svc moderation.ModerationSvc, v *validator.Validate, logger *slog.Logger) *Handler {
+	return &Handler{
+		Svc:    Svc,
+		v:      v,
+		logger: logger.With("component", "handler.moderation"),
+	}
+}
+
+func (h *Handler) GetModerationQueue(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.GetModerationQueueReq)
+	var (
+		page, _    = strconv.Atoi(r.URL.Query().Get("page"))
+		limit, _   = strconv.Atoi(r.URL.Query().Get("limit"))
+		flagged, _ = strconv.ParseBool(r.URL.Query().Get("flagged"))
+	)
+
+	req.Role = middleware.GetRole(r.Context())
+	req.Page = page
+	req.Limit = limit
+	req.Flagged = flagged
+
+	req.SetDefault()
+
+	bRes, err := h.Svc.GetModerationQueue(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusOK, bRes)
+}
+
+func (h *Handler) ApproveProduct(w http.ResponseWriter, r *http.Request) {
+	h.decideReview(w, r, model.ReviewStatusApproved)
+}
+
+func (h *Handler) RejectProduct(w http.ResponseWriter, r *http.Request) {
+	h.decideReview(w, r, model.ReviewStatusRejected)
+}
+
+func (h *Handler) decideReview(w http.ResponseWriter, r *http.Request, status string) {
+	var req = new(model.ReviewDecisionReq)
+	// the body is optional when approving
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil && !errors.Is(err, io.EOF) {
+		helper.HandleError(w, r, helper.ErrInvalidBody)
+		return
+	}
+
+	req.Id = r.PathValue("id")
+	req.Status = status
+	req.UserId = middleware.GetUserID(r.Context())
+	req.Role = middleware.GetRole(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "decideReview", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.DecideReview(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusOK, bRes)
+}
@@ -5,23 +5,46 @@ import (
 	model "codebase-service/models"
 	"codebase-service/usecases/products"
 	"codebase-service/util/middleware"
+	"codebase-service/util/xlsx"
+	"context"
 	"encoding/json"
-	"log"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
 	"net/http"
+	"path/filepath"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-playground/validator"
 )
 
+const (
+	// multipartOverhead leaves room for the multipart boundaries and headers
+	// around an import file.
+	multipartOverhead = 1 << 20
+
+	// exportWriteTimeout is how long a client may take to read one chunk of
+	// an export, exportMaxDuration caps the whole download.
+	exportWriteTimeout = 30 * time.Second
+	exportMaxDuration  = 30 * time.Minute
+)
+
 type Handler struct {
-	Svc products.ProductSvc
-	v   *validator.Validate
+	Svc           products.ProductSvc
+	v             *validator.Validate
+	importMaxSize int64
+	logger        *slog.Logger
 }
 
-func NewHandler(Svc products.ProductSvc, v *validator.Validate) *Handler {
+func NewHandler(Svc products.ProductSvc, v *validator.Validate, importMaxSize int64, logger *slog.Logger) *Handler {
 	return &Handler{
-		Svc: Svc,
-		v:   v,
+		Svc:           Svc,
+		v:             v,
+		importMaxSize: importMaxSize,
+		logger:        logger.With("component", "handler.products"),
 	}
 }
 
@@ -30,51 +53,67 @@ func (h *Handler) GetProduct(w http.ResponseWriter, r *http.Request) {
 	req.Id = r.PathValue("id")
 
 	if err := h.v.Struct(req); err != nil {
-		log.Printf("handler::GetProduct - failed to validate request, err: %v", err)
-		helper.HandleResponse(w, http.StatusBadRequest, err.Error(), nil)
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "GetProduct", "err", err)
+		helper.HandleError(w, r, err)
 		return
 	}
 
-	bRes, err := h.Svc.GetProduct(req)
+	bRes, err := h.Svc.GetProduct(r.Context(), req)
 	if err != nil {
-		if err.Error() == "no product found" {
-			helper.HandleResponse(w, http.StatusNotFound, err.Error(), nil)
-			return
-		}
-		helper.HandleResponse(w, http.StatusInternalServerError, err.Error(), nil)
+		helper.HandleError(w, r, err)
 		return
 	}
 
-	helper.HandleResponse(w, http.StatusOK, helper.SUCCESS_MESSSAGE, bRes)
+	helper.HandleSuccess(w, r, http.StatusOK, bRes)
 }
 
 func (h *Handler) CreateProduct(w http.ResponseWriter, r *http.Request) {
 	var req = new(model.CreateProductReq)
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		helper.HandleResponse(w, http.StatusBadRequest, err.Error(), nil)
+		helper.HandleError(w, r, helper.ErrInvalidBody)
 		return
 	}
 
 	req.UserId = middleware.GetUserID(r.Context())
 
 	if err := h.v.Struct(req); err != nil {
-		log.Printf("handler::CreateProduct - failed to validate request, err: %v", err)
-		helper.HandleResponse(w, http.StatusBadRequest, err.Error(), nil)
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "CreateProduct", "err", err)
+		helper.HandleError(w, r, err)
 		return
 	}
 
-	bRes, err := h.Svc.CreateProduct(req)
+	bRes, err := h.Svc.CreateProduct(r.Context(), req)
 	if err != nil {
-		if err.Error() == "user is not shop owner" {
-			helper.HandleResponse(w, http.StatusForbidden, err.Error(), nil)
-			return
-		}
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusCreated, bRes)
+}
+
+func (h *Handler) UpdateProduct(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.UpdateProductReq)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.HandleError(w, r, helper.ErrInvalidBody)
+		return
+	}
+
+	req.Id = r.PathValue("id")
+	req.UserId = middleware.GetUserID(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "UpdateProduct", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
 
-		helper.HandleResponse(w, http.StatusInternalServerError, err.Error(), nil)
+	bRes, err := h.Svc.UpdateProduct(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
 		return
 	}
 
-	helper.HandleResponse(w, http.StatusCreated, helper.SUCCESS_MESSSAGE, bRes)
+	helper.HandleSuccess(w, r, http.StatusOK, bRes)
 }
 
 func (h *Handler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
@@ -83,18 +122,38 @@ func (h *Handler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	req.UserId = middleware.GetUserID(r.Context())
 
 	if err := h.v.Struct(req); err != nil {
-		log.Printf("handler::DeleteProduct - failed to validate request, err: %v", err)
-		helper.HandleResponse(w, http.StatusBadRequest, err.Error(), nil)
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "DeleteProduct", "err", err)
+		helper.HandleError(w, r, err)
 		return
 	}
 
-	err := h.Svc.DeleteProduct(req)
+	err := h.Svc.DeleteProduct(r.Context(), req)
 	if err != nil {
-		helper.HandleResponse(w, http.StatusInternalServerError, err.Error(), nil)
+		helper.HandleError(w, r, err)
 		return
 	}
 
-	helper.HandleResponse(w, http.StatusOK, helper.SUCCESS_MESSSAGE, nil)
+	helper.HandleSuccess(w, r, http.StatusOK, nil)
+}
+
+func (h *Handler) RestoreProduct(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.RestoreProductReq)
+	req.Id = r.PathValue("id")
+	req.UserId = middleware.GetUserID(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "RestoreProduct", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.RestoreProduct(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusOK, bRes)
 }
 
 func (h *Handler) GetProducts(w http.ResponseWriter, r *http.Request) {
@@ -104,16 +163,541 @@ func (h *Handler) GetProducts(w http.ResponseWriter, r *http.Request) {
 		limit, _ = strconv.Atoi(r.URL.Query().Get("limit"))
 	)
 
+	req.Page = page
+	req.Limit = limit
+	req.Attributes = attributeFilters(r)
+
+	req.SetDefault()
+
+	bRes, err := h.Svc.GetProducts(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusOK, bRes)
+}
+
+func (h *Handler) SetProductStatus(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.SetProductStatusReq)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.HandleError(w, r, helper.ErrInvalidBody)
+		return
+	}
+
+	req.Id = r.PathValue("id")
+	req.UserId = middleware.GetUserID(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "SetProductStatus", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.SetProductStatus(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusOK, bRes)
+}
+
+func (h *Handler) GetShopProducts(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.GetShopProductsReq)
+	var (
+		page, _  = strconv.Atoi(r.URL.Query().Get("page"))
+		limit, _ = strconv.Atoi(r.URL.Query().Get("limit"))
+	)
+
+	req.ShopId = r.PathValue("id")
+	req.UserId = middleware.GetUserID(r.Context())
+	req.Status = r.URL.Query().Get("status")
 	req.Page = page
 	req.Limit = limit
 
 	req.SetDefault()
 
-	bRes, err := h.Svc.GetProducts(req)
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "GetShopProducts", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.GetShopProducts(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusOK, bRes)
+}
+
+func (h *Handler) CreatePromotion(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.CreatePromotionReq)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.HandleError(w, r, helper.ErrInvalidBody)
+		return
+	}
+
+	req.ProductId = r.PathValue("id")
+	req.UserId = middleware.GetUserID(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "CreatePromotion", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.CreatePromotion(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusCreated, bRes)
+}
+
+func (h *Handler) ClaimFlashSale(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.ClaimFlashSaleReq)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.HandleError(w, r, helper.ErrInvalidBody)
+		return
+	}
+
+	req.PromotionId = r.PathValue("id")
+	req.UserId = middleware.GetUserID(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "ClaimFlashSale", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.ClaimFlashSale(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusOK, bRes)
+}
+
+func (h *Handler) CreateWarehouse(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.CreateWarehouseReq)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.HandleError(w, r, helper.ErrInvalidBody)
+		return
+	}
+
+	req.ShopId = r.PathValue("id")
+	req.UserId = middleware.GetUserID(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "CreateWarehouse", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.CreateWarehouse(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusCreated, bRes)
+}
+
+func (h *Handler) GetWarehouses(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.GetWarehousesReq)
+	req.ShopId = r.PathValue("id")
+	req.UserId = middleware.GetUserID(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "GetWarehouses", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.GetWarehouses(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusOK, bRes)
+}
+
+func (h *Handler) CreateStockAdjustment(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.CreateStockAdjustmentReq)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.HandleError(w, r, helper.ErrInvalidBody)
+		return
+	}
+
+	req.ProductId = r.PathValue("id")
+	req.UserId = middleware.GetUserID(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "CreateStockAdjustment", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.CreateStockAdjustment(r.Context(), req)
 	if err != nil {
-		helper.HandleResponse(w, http.StatusInternalServerError, err.Error(), nil)
+		helper.HandleError(w, r, err)
 		return
 	}
 
-	helper.HandleResponse(w, http.StatusOK, helper.SUCCESS_MESSSAGE, bRes)
+	helper.HandleSuccess(w, r, http.StatusCreated, bRes)
+}
+
+func (h *Handler) SetProductOptions(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.SetProductOptionsReq)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.HandleError(w, r, helper.ErrInvalidBody)
+		return
+	}
+
+	req.ProductId = r.PathValue("id")
+	req.UserId = middleware.GetUserID(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "SetProductOptions", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.SetProductOptions(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusOK, bRes)
+}
+
+func (h *Handler) CreateVariant(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.CreateVariantReq)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.HandleError(w, r, helper.ErrInvalidBody)
+		return
+	}
+
+	req.ProductId = r.PathValue("id")
+	req.UserId = middleware.GetUserID(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "CreateVariant", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.CreateVariant(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusCreated, bRes)
+}
+
+func (h *Handler) AddProductImage(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.AddProductImageReq)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.HandleError(w, r, helper.ErrInvalidBody)
+		return
+	}
+
+	req.ProductId = r.PathValue("id")
+	req.UserId = middleware.GetUserID(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "AddProductImage", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.AddProductImage(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusCreated, bRes)
+}
+
+func (h *Handler) ReorderProductImages(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.ReorderProductImagesReq)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.HandleError(w, r, helper.ErrInvalidBody)
+		return
+	}
+
+	req.ProductId = r.PathValue("id")
+	req.UserId = middleware.GetUserID(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "ReorderProductImages", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.ReorderProductImages(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusOK, bRes)
+}
+
+func (h *Handler) DeleteProductImage(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.DeleteProductImageReq)
+	req.ProductId = r.PathValue("id")
+	req.Id = r.PathValue("imageId")
+	req.UserId = middleware.GetUserID(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "DeleteProductImage", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	err := h.Svc.DeleteProductImage(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusOK, nil)
+}
+
+func (h *Handler) GetCategoryAttributes(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.GetCategoryAttributesReq)
+	req.CategoryId = r.PathValue("id")
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "GetCategoryAttributes", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.GetCategoryAttributes(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusOK, bRes)
+}
+
+func (h *Handler) SetCategoryAttributes(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.SetCategoryAttributesReq)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.HandleError(w, r, helper.ErrInvalidBody)
+		return
+	}
+
+	req.CategoryId = r.PathValue("id")
+	req.Role = middleware.GetRole(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "SetCategoryAttributes", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.SetCategoryAttributes(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusOK, bRes)
+}
+
+// CreateImport takes a multipart file field. The format comes from the format
+// field or else from the file extension.
+func (h *Handler) CreateImport(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.CreateImportReq)
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.importMaxSize+multipartOverhead)
+	if err := r.ParseMultipartForm(h.importMaxSize); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			helper.HandleError(w, r, helper.ErrFileTooLarge)
+			return
+		}
+		helper.HandleError(w, r, helper.ErrInvalidBody)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		helper.HandleError(w, r, helper.ErrFileRequired)
+		return
+	}
+	defer file.Close()
+
+	req.Data, err = io.ReadAll(io.LimitReader(file, h.importMaxSize+1))
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to read file", "method", "CreateImport", "err", err)
+		helper.HandleError(w, r, helper.ErrInvalidBody)
+		return
+	}
+
+	if int64(len(req.Data)) > h.importMaxSize {
+		helper.HandleError(w, r, helper.ErrFileTooLarge)
+		return
+	}
+
+	req.Format = strings.ToLower(r.FormValue("format"))
+	if req.Format == "" {
+		switch strings.ToLower(filepath.Ext(header.Filename)) {
+		case ".csv":
+			req.Format = model.ImportFormatCSV
+		case ".ndjson", ".jsonl":
+			req.Format = model.ImportFormatNDJSON
+		}
+	}
+
+	req.ShopId = r.PathValue("id")
+	req.UserId = middleware.GetUserID(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "CreateImport", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.CreateImport(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusAccepted, bRes)
+}
+
+func (h *Handler) GetImport(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.GetImportReq)
+
+	req.Id = r.PathValue("id")
+	req.UserId = middleware.GetUserID(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "GetImport", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.GetImport(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusOK, bRes)
+}
+
+// ExportProducts streams the file as products are read, so errors after
+// the first bytes went out can only abort the response.
+func (h *Handler) ExportProducts(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.ExportProductsReq)
+
+	req.ShopId = r.PathValue("id")
+	req.UserId = middleware.GetUserID(r.Context())
+	req.Role = middleware.GetRole(r.Context())
+	req.Format = strings.ToLower(r.URL.Query().Get("format"))
+	req.Status = r.URL.Query().Get("status")
+	req.Attributes = attributeFilters(r)
+
+	if req.Format == "" {
+		req.Format = model.ExportFormatCSV
+	}
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "ExportProducts", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	// large catalogs take longer than the server write timeout, each chunk
+	// gets a deadline of its own instead
+	ctx, cancel := context.WithTimeout(r.Context(), exportMaxDuration)
+	defer cancel()
+
+	res := &exportResponse{
+		ResponseWriter: w,
+		rc:             http.NewResponseController(w),
+		contentType:    exportContentTypes[req.Format],
+		filename:       fmt.Sprintf("products-%s-%s.%s", req.ShopId, time.Now().Format("20060102"), req.Format),
+	}
+
+	err := h.Svc.ExportProducts(ctx, req, res)
+	if err != nil {
+		if !res.started {
+			helper.HandleError(w, r, err)
+			return
+		}
+
+		h.logger.ErrorContext(r.Context(), "export failed after streaming started", "method", "ExportProducts", "err", err)
+		panic(http.ErrAbortHandler)
+	}
+
+	if !res.started {
+		res.Write(nil)
+	}
+}
+
+var exportContentTypes = map[string]string{
+	model.ExportFormatCSV:    "text/csv; charset=utf-8",
+	model.ExportFormatNDJSON: "application/x-ndjson",
+	model.ExportFormatXLSX:   xlsx.ContentType,
+}
+
+// exportResponse sends the download headers with the first bytes of the
+// file, until then an error can still get a JSON response. Every write moves
+// the write deadline, so only a client that stops reading times out.
+type exportResponse struct {
+	http.ResponseWriter
+	rc          *http.ResponseController
+	contentType string
+	filename    string
+	started     bool
+}
+
+func (e *exportResponse) Write(p []byte) (int, error) {
+	if err := e.rc.SetWriteDeadline(time.Now().Add(exportWriteTimeout)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return 0, err
+	}
+
+	if !e.started {
+		e.started = true
+		e.Header().Set("Content-Type", e.contentType)
+		e.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, e.filename))
+		e.WriteHeader(http.StatusOK)
+	}
+
+	return e.ResponseWriter.Write(p)
+}
+
+// attributeFilters reads the attribute filters, given as attr.<name>=<value>.
+func attributeFilters(r *http.Request) map[string]string {
+	var filters map[string]string
+	for key, values := range r.URL.Query() {
+		name, ok := strings.CutPrefix(key, "attr.")
+		if !ok || name == "" || len(values) == 0 {
+			continue
+		}
+		if filters == nil {
+			filters = make(map[string]string)
+		}
+		filters[name] = values[0]
+	}
+
+	return filters
 }
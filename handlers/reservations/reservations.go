@@ -0,0 +1,118 @@
+package reservations
+
+import (
+	"codebase-service/helper"
+	model "codebase-service/models"
+	"codebase-service/usecases/reservations"
+	"codebase-service/util/middleware"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-playground/validator"
+)
+
+type Handler struct {
+	Svc    reservations.ReservationSvc
+	v      *validator.Validate
+	logger *slog.Logger
+}
+
+func NewHandler(Svc reservations.ReservationSvc, v *validator.Validate, logger *slog.Logger) *Handler {
+	return &Handler{
+		Svc:    Svc,
+		v:      v,
+		logger: logger.With("component", "handler.reservations"),
+	}
+}
+
+func (h *Handler) CreateReservation(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.CreateReservationReq)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.HandleError(w, r, helper.ErrInvalidBody)
+		return
+	}
+
+	req.UserId = middleware.GetUserID(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "CreateReservation", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.CreateReservation(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusCreated, bRes)
+}
+
+func (h *Handler) ConfirmReservation(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.ReservationReq)
+	req.Id = r.PathValue("id")
+	req.UserId = middleware.GetUserID(r.Context())
+	req.Role = middleware.GetRole(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "ConfirmReservation", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.ConfirmReservation(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusOK, bRes)
+}
+
+func (h *Handler) ReleaseReservation(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.ReservationReq)
+	req.Id = r.PathValue("id")
+	req.UserId = middleware.GetUserID(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "ReleaseReservation", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.ReleaseReservation(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusOK, bRes)
+}
+
+func (h *Handler) RefundReservation(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.CreateRefundReq)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.HandleError(w, r, helper.ErrInvalidBody)
+		return
+	}
+
+	req.ReservationId = r.PathValue("id")
+	req.UserId = middleware.GetUserID(r.Context())
+	req.Role = middleware.GetRole(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "RefundReservation", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.RefundReservation(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusCreated, bRes)
+}
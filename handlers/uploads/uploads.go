@@ -0,0 +1,91 @@
+package uploads
+
+import (
+	"codebase-service/helper"
+	model "codebase-service/models"
+	"codebase-service/usecases/uploads"
+	"codebase-service/util/middleware"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-playground/validator"
+)
+
+// multipartOverhead leaves room for the multipart boundaries and headers
+// around the file itself.
+const multipartOverhead = 1 << 20
+
+type Handler struct {
+	Svc     uploads.UploadSvc
+	v       *validator.Validate
+	maxSize int64
+	logger  *slog.Logger
+}
+
+func NewHandler(Svc uploads.UploadSvc, v *validator.Validate, maxSize int64, logger *slog.Logger) *Handler {
+	return &Handler{
+		Svc:     Svc,
+		v:       v,
+		maxSize: maxSize,
+		logger:  logger.With("component", "handler.uploads"),
+	}
+}
+
+func (h *Handler) UploadImage(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.UploadImageReq)
+
+	r.Body = http.MaxBytesReader(w, r.Body, h.maxSize+multipartOverhead)
+	if err := r.ParseMultipartForm(h.maxSize); err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			helper.HandleError(w, r, helper.ErrFileTooLarge)
+			return
+		}
+		helper.HandleError(w, r, helper.ErrInvalidBody)
+		return
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	file, header, err := r.FormFile("file")
+	if err != nil {
+		helper.HandleError(w, r, helper.ErrFileRequired)
+		return
+	}
+	defer file.Close()
+
+	if header.Size > h.maxSize {
+		helper.HandleError(w, r, helper.ErrFileTooLarge)
+		return
+	}
+
+	req.Data, err = io.ReadAll(io.LimitReader(file, h.maxSize+1))
+	if err != nil {
+		h.logger.ErrorContext(r.Context(), "failed to read file", "method", "UploadImage", "err", err)
+		helper.HandleError(w, r, helper.ErrInvalidBody)
+		return
+	}
+
+	if int64(len(req.Data)) > h.maxSize {
+		helper.HandleError(w, r, helper.ErrFileTooLarge)
+		return
+	}
+
+	req.Filename = header.Filename
+	req.UserId = middleware.GetUserID(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "UploadImage", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.UploadImage(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusCreated, bRes)
+}
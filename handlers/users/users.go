@@ -5,6 +5,7 @@ import (
 	model "codebase-service/models"
 	"codebase-service/usecases/users"
 	"encoding/json"
+	"log/slog"
 	"net/http"
 
 	"github.com/go-playground/validator"
@@ -13,19 +14,21 @@ import (
 type Handler struct {
 	userSvc   users.UserSvc
 	validator *validator.Validate
+	logger    *slog.Logger
 }
 
-func NewHandler(userSvc users.UserSvc, validator *validator.Validate) *Handler {
+func NewHandler(userSvc users.UserSvc, validator *validator.Validate, logger *slog.Logger) *Handler {
 	return &Handler{
 		userSvc:   userSvc,
 		validator: validator,
+		logger:    logger.With("component", "handler.users"),
 	}
 }
 
 func (h *Handler) SignUpByEmail(w http.ResponseWriter, r *http.Request) {
 	var bReq model.Users
 	if err := json.NewDecoder(r.Body).Decode(&bReq); err != nil {
-		helper.HandleResponse(w, http.StatusBadRequest, err.Error(), nil)
+		helper.HandleError(w, r, helper.ErrInvalidBody)
 		return
 	}
 
@@ -34,36 +37,36 @@ func (h *Handler) SignUpByEmail(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := h.validator.Struct(bReq); err != nil {
-		helper.HandleResponse(w, http.StatusBadRequest, err.Error(), nil)
+		helper.HandleError(w, r, err)
 		return
 	}
 
-	userID, err := h.userSvc.UserRegister(bReq)
+	userID, err := h.userSvc.UserRegister(r.Context(), bReq)
 	if err != nil {
-		helper.HandleResponse(w, http.StatusInternalServerError, err.Error(), nil)
+		helper.HandleError(w, r, err)
 		return
 	}
 
-	helper.HandleResponse(w, http.StatusCreated, helper.SUCCESS_MESSSAGE, userID)
+	helper.HandleSuccess(w, r, http.StatusCreated, userID)
 }
 
 func (h *Handler) SignInByEmail(w http.ResponseWriter, r *http.Request) {
 	var bReq model.UserLoginRequest
 	if err := json.NewDecoder(r.Body).Decode(&bReq); err != nil {
-		helper.HandleResponse(w, http.StatusBadRequest, err.Error(), nil)
+		helper.HandleError(w, r, helper.ErrInvalidBody)
 		return
 	}
 
 	if err := h.validator.Struct(bReq); err != nil {
-		helper.HandleResponse(w, http.StatusBadRequest, err.Error(), nil)
+		helper.HandleError(w, r, err)
 		return
 	}
 
-	bRes, err := h.userSvc.UserLogin(bReq)
+	bRes, err := h.userSvc.UserLogin(r.Context(), bReq)
 	if err != nil {
-		helper.HandleResponse(w, http.StatusInternalServerError, err.Error(), nil)
+		helper.HandleError(w, r, err)
 		return
 	}
 
-	helper.HandleResponse(w, http.StatusOK, helper.SUCCESS_MESSSAGE, bRes)
+	helper.HandleSuccess(w, r, http.StatusOK, bRes)
 }
@@ -0,0 +1,100 @@
+package vouchers
+
+import (
+	"codebase-service/helper"
+	model "codebase-service/models"
+	"codebase-service/usecases/vouchers"
+	"codebase-service/util/middleware"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+
+	"github.com/go-playground/validator"
+)
+
+type Handler struct {
+	Svc    vouchers.VoucherSvc
+	v      *validator.Validate
+	logger *slog.Logger
+}
+
+func NewHandler(Svc vouchers.VoucherSvc, v *validator.Validate, logger *slog.Logger) *Handler {
+	return &Handler{
+		Svc:    Svc,
+		v:      v,
+		logger: logger.With("component", "handler.vouchers"),
+	}
+}
+
+func (h *Handler) CreateVoucher(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.CreateVoucherReq)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.HandleError(w, r, helper.ErrInvalidBody)
+		return
+	}
+
+	req.UserId = middleware.GetUserID(r.Context())
+	req.Role = middleware.GetRole(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "CreateVoucher", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.CreateVoucher(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusCreated, bRes)
+}
+
+func (h *Handler) ApplyVoucher(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.ApplyVoucherReq)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.HandleError(w, r, helper.ErrInvalidBody)
+		return
+	}
+
+	req.UserId = middleware.GetUserID(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "ApplyVoucher", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.ApplyVoucher(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusOK, bRes)
+}
+
+func (h *Handler) RedeemVoucher(w http.ResponseWriter, r *http.Request) {
+	var req = new(model.RedeemVoucherReq)
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		helper.HandleError(w, r, helper.ErrInvalidBody)
+		return
+	}
+
+	req.UserId = middleware.GetUserID(r.Context())
+
+	if err := h.v.Struct(req); err != nil {
+		h.logger.InfoContext(r.Context(), "failed to validate request", "method", "RedeemVoucher", "err", err)
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	bRes, err := h.Svc.RedeemVoucher(r.Context(), req)
+	if err != nil {
+		helper.HandleError(w, r, err)
+		return
+	}
+
+	helper.HandleSuccess(w, r, http.StatusOK, bRes)
+}
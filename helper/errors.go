@@ -0,0 +1,130 @@
+package helper
+
+import (
+	model "codebase-service/models"
+	"codebase-service/util/apperror"
+	"codebase-service/util/i18n"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"mime"
+	"net/http"
+	"strings"
+
+	"github.com/go-playground/validator"
+)
+
+const (
+	INTERNAL_ERROR_MESSAGE   string = "internal server error"
+	VALIDATION_ERROR_MESSAGE string = "request validation failed"
+
+	PROBLEM_CONTENT_TYPE string = "application/problem+json"
+)
+
+var (
+	errInternal   = apperror.New(apperror.Internal, "internal_error", INTERNAL_ERROR_MESSAGE)
+	errValidation = apperror.New(apperror.Validation, "validation_failed", VALIDATION_ERROR_MESSAGE)
+)
+
+var errorStatuses = map[apperror.Kind]int{
+	apperror.NotFound:      http.StatusNotFound,
+	apperror.Forbidden:     http.StatusForbidden,
+	apperror.Conflict:      http.StatusConflict,
+	apperror.Validation:    http.StatusBadRequest,
+	apperror.Unauthorized:  http.StatusUnauthorized,
+	apperror.Unprocessable: http.StatusUnprocessableEntity,
+	apperror.Gone:          http.StatusGone,
+	apperror.Unsupported:   http.StatusUnsupportedMediaType,
+	apperror.Unavailable:   http.StatusServiceUnavailable,
+	apperror.TooLarge:      http.StatusRequestEntityTooLarge,
+}
+
+// Errors of reading a request, reported before a usecase is involved. The
+// decoder's own message names Go types, so it is not sent.
+var (
+	ErrInvalidBody  = apperror.New(apperror.Validation, "invalid_body", "invalid request body")
+	ErrFileRequired = apperror.New(apperror.Validation, "file_required", "file is required")
+	ErrFileTooLarge = apperror.New(apperror.TooLarge, "file_too_large", "file is too large")
+)
+
+// ErrorStatus maps err to a status code and an English message that is
+// safe to send to the client. Errors that are not an apperror.Error are
+// internal, their message may hold queries or driver details so it is
+// replaced.
+func ErrorStatus(err error) (int, string) {
+	status, message := describe(err)
+	return status, message.Message
+}
+
+// describe is ErrorStatus keeping the message code and arguments for
+// translation.
+func describe(err error) (int, *apperror.Error) {
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		return http.StatusBadRequest, errValidation
+	}
+
+	var appErr *apperror.Error
+	if !errors.As(err, &appErr) {
+		return http.StatusInternalServerError, errInternal
+	}
+
+	status, ok := errorStatuses[appErr.Kind]
+	if !ok {
+		return http.StatusInternalServerError, errInternal
+	}
+	return status, appErr
+}
+
+// HandleError writes err as a response in the client's language, as a
+// problem document when the client accepts application/problem+json and as
+// the usual message body otherwise. Validation errors list the failed
+// fields in both formats. Internal errors are logged here since the client
+// only gets the generic message.
+func HandleError(w http.ResponseWriter, r *http.Request, err error) {
+	status, appErr := describe(err)
+	if status == http.StatusInternalServerError {
+		slog.ErrorContext(r.Context(), "internal error", "err", err)
+	}
+
+	locale := Locale(r)
+	message := i18n.Message(locale, appErr.Code, appErr.Message, appErr.Args...)
+	w.Header().Set("Content-Language", locale)
+
+	var fields []*model.FieldError
+	var validationErrs validator.ValidationErrors
+	if errors.As(err, &validationErrs) {
+		fields = fieldErrors(locale, validationErrs)
+	}
+
+	if !acceptsProblem(r) {
+		var data interface{}
+		if fields != nil {
+			data = fields
+		}
+		HandleResponse(w, status, message, data)
+		return
+	}
+
+	w.Header().Set("Content-Type", PROBLEM_CONTENT_TYPE)
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(model.Problem{
+		Type:      "about:blank",
+		Title:     http.StatusText(status),
+		Status:    status,
+		Detail:    message,
+		Instance:  r.URL.Path,
+		RequestId: GetRequestID(r.Context()),
+		Errors:    fields,
+	})
+}
+
+func acceptsProblem(r *http.Request) bool {
+	for _, accept := range strings.Split(r.Header.Get("Accept"), ",") {
+		mediaType, _, err := mime.ParseMediaType(strings.TrimSpace(accept))
+		if err == nil && mediaType == PROBLEM_CONTENT_TYPE {
+			return true
+		}
+	}
+	return false
+}
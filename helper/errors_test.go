@@ -0,0 +1,184 @@
+package helper
+
+import (
+	model "codebase-service/models"
+	"codebase-service/util/apperror"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestErrors(t *testing.T) {
+	suite.Run(t, new(ErrorsTestSuite))
+}
+
+type ErrorsTestSuite struct {
+	suite.Suite
+}
+
+func (s *ErrorsTestSuite) TestErrorStatus_Kinds() {
+	cases := map[apperror.Kind]int{
+		apperror.NotFound:      http.StatusNotFound,
+		apperror.Forbidden:     http.StatusForbidden,
+		apperror.Conflict:      http.StatusConflict,
+		apperror.Validation:    http.StatusBadRequest,
+		apperror.Unauthorized:  http.StatusUnauthorized,
+		apperror.Unprocessable: http.StatusUnprocessableEntity,
+		apperror.Gone:          http.StatusGone,
+		apperror.Unsupported:   http.StatusUnsupportedMediaType,
+		apperror.Unavailable:   http.StatusServiceUnavailable,
+		apperror.TooLarge:      http.StatusRequestEntityTooLarge,
+	}
+
+	// a kind added without a status would be reported as internal
+	s.Len(cases, len(errorStatuses))
+
+	for kind, want := range cases {
+		status, message := ErrorStatus(apperror.New(kind, "something_wrong", "something went wrong"))
+		s.Equal(want, status, kind.String())
+		s.Equal("something went wrong", message)
+	}
+}
+
+func (s *ErrorsTestSuite) TestErrorStatus_InternalKind() {
+	status, message := ErrorStatus(apperror.New(apperror.Internal, "something_wrong", "something went wrong"))
+
+	s.Equal(http.StatusInternalServerError, status)
+	s.Equal(INTERNAL_ERROR_MESSAGE, message)
+}
+
+func (s *ErrorsTestSuite) TestHandleError_InvalidCredentials() {
+	w := s.serve("application/json", apperror.New(apperror.Unauthorized, "invalid_credentials", "invalid username or password"))
+
+	var resp model.Response
+	s.NoError(json.NewDecoder(w.Body).Decode(&resp))
+	s.Equal(http.StatusUnauthorized, w.Code)
+	s.Equal("invalid username or password", resp.Message)
+}
+
+func (s *ErrorsTestSuite) TestErrorStatus_Wrapped() {
+	notFound := apperror.New(apperror.NotFound, "product_not_found", "no product found")
+
+	status, message := ErrorStatus(fmt.Errorf("failed to get product: %w", notFound))
+
+	s.Equal(http.StatusNotFound, status)
+	s.Equal("no product found", message)
+}
+
+func (s *ErrorsTestSuite) TestErrorStatus_HidesInternalErrors() {
+	status, message := ErrorStatus(errors.New(`pq: relation "products" does not exist`))
+
+	s.Equal(http.StatusInternalServerError, status)
+	s.Equal(INTERNAL_ERROR_MESSAGE, message)
+}
+
+type orderReq struct {
+	Email    string       `json:"email" validate:"required,email"`
+	Username string       `json:"username" validate:"required,min=3"`
+	Items    []*orderItem `json:"items" validate:"dive"`
+}
+
+type orderItem struct {
+	Qty int `json:"qty" validate:"gt=0"`
+}
+
+func (s *ErrorsTestSuite) serve(accept string, err error) *httptest.ResponseRecorder {
+	return s.serveIn(accept, "", err)
+}
+
+func (s *ErrorsTestSuite) serveIn(accept, language string, err error) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/orders", nil)
+	r.Header.Set("Accept", accept)
+	r.Header.Set("Accept-Language", language)
+	r = r.WithContext(SetRequestID(r.Context(), "req-1"))
+	w := httptest.NewRecorder()
+
+	HandleError(w, r, err)
+	return w
+}
+
+func (s *ErrorsTestSuite) TestHandleError_Problem() {
+	w := s.serve("application/problem+json", apperror.New(apperror.Conflict, "sku_exists", "sku already exists"))
+
+	var problem model.Problem
+	s.NoError(json.NewDecoder(w.Body).Decode(&problem))
+	s.Equal(http.StatusConflict, w.Code)
+	s.Equal(PROBLEM_CONTENT_TYPE, w.Header().Get("Content-Type"))
+	s.Equal(model.Problem{
+		Type:      "about:blank",
+		Title:     "Conflict",
+		Status:    http.StatusConflict,
+		Detail:    "sku already exists",
+		Instance:  "/orders",
+		RequestId: "req-1",
+	}, problem)
+}
+
+func (s *ErrorsTestSuite) TestHandleError_DefaultsToMessageBody() {
+	w := s.serve("application/json", apperror.New(apperror.Conflict, "sku_exists", "sku already exists"))
+
+	var resp model.Response
+	s.NoError(json.NewDecoder(w.Body).Decode(&resp))
+	s.Equal(http.StatusConflict, w.Code)
+	s.Equal("sku already exists", resp.Message)
+}
+
+func (s *ErrorsTestSuite) TestHandleError_InvalidBody() {
+	w := s.serveIn("application/json", "id", ErrInvalidBody)
+
+	var resp model.Response
+	s.NoError(json.NewDecoder(w.Body).Decode(&resp))
+	s.Equal(http.StatusBadRequest, w.Code)
+	s.Equal("isi permintaan tidak valid", resp.Message)
+}
+
+func (s *ErrorsTestSuite) validate(req orderReq) error {
+	v, err := NewValidator()
+	s.Require().NoError(err)
+	return v.Struct(req)
+}
+
+func (s *ErrorsTestSuite) TestHandleError_ValidationFields() {
+	err := s.validate(orderReq{Email: "not-an-email", Items: []*orderItem{{Qty: 0}}})
+
+	w := s.serve("application/problem+json", err)
+
+	var problem model.Problem
+	s.NoError(json.NewDecoder(w.Body).Decode(&problem))
+	s.Equal(http.StatusBadRequest, problem.Status)
+	s.Equal(VALIDATION_ERROR_MESSAGE, problem.Detail)
+	s.Equal([]*model.FieldError{
+		{Field: "email", Rule: "email", Message: "email must be a valid email address"},
+		{Field: "username", Rule: "required", Message: "username is required"},
+		{Field: "items[0].qty", Rule: "gt", Message: "qty must be greater than 0"},
+	}, problem.Errors)
+}
+
+func (s *ErrorsTestSuite) TestHandleError_Indonesian() {
+	w := s.serveIn("application/json", "id-ID,id;q=0.9,en;q=0.8",
+		apperror.New(apperror.NotFound, "product_not_found", "no product found"))
+
+	var resp model.Response
+	s.NoError(json.NewDecoder(w.Body).Decode(&resp))
+	s.Equal(http.StatusNotFound, w.Code)
+	s.Equal("id", w.Header().Get("Content-Language"))
+	s.Equal("produk tidak ditemukan", resp.Message)
+}
+
+func (s *ErrorsTestSuite) TestHandleError_IndonesianValidationFields() {
+	err := s.validate(orderReq{Email: "a@b.co", Username: "ab"})
+
+	w := s.serveIn("application/problem+json", "id", err)
+
+	var problem model.Problem
+	s.NoError(json.NewDecoder(w.Body).Decode(&problem))
+	s.Equal("validasi permintaan gagal", problem.Detail)
+	s.Equal([]*model.FieldError{
+		{Field: "username", Rule: "min", Message: "username minimal 3 karakter"},
+	}, problem.Errors)
+}
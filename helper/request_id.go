@@ -0,0 +1,18 @@
+package helper
+
+import "context"
+
+const REQUEST_ID_HEADER string = "X-Request-ID"
+
+type requestIDKey struct{}
+
+// SetRequestID lives here rather than in middleware so error responses can
+// carry the request ID, middleware already depends on helper.
+func SetRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+func GetRequestID(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
@@ -2,6 +2,7 @@ package helper
 
 import (
 	model "codebase-service/models"
+	"codebase-service/util/i18n"
 	"encoding/json"
 	"net/http"
 )
@@ -18,3 +19,16 @@ func HandleResponse(w http.ResponseWriter, statusCode int, message string, data
 		Data:    data,
 	})
 }
+
+// HandleSuccess writes data with the success message in the client's
+// language.
+func HandleSuccess(w http.ResponseWriter, r *http.Request, statusCode int, data interface{}) {
+	locale := Locale(r)
+	w.Header().Set("Content-Language", locale)
+	HandleResponse(w, statusCode, i18n.Message(locale, "success", SUCCESS_MESSSAGE), data)
+}
+
+// Locale is the response language negotiated from Accept-Language.
+func Locale(r *http.Request) string {
+	return i18n.Negotiate(r.Header.Get("Accept-Language"))
+}
@@ -0,0 +1,47 @@
+package helper
+
+import (
+	model "codebase-service/models"
+	"codebase-service/util/i18n"
+	"reflect"
+	"strings"
+
+	"github.com/go-playground/validator"
+)
+
+// NewValidator reports fields by their json names, the names clients send,
+// and knows the rule messages of every supported locale.
+func NewValidator() (*validator.Validate, error) {
+	v := validator.New()
+	v.RegisterTagNameFunc(func(field reflect.StructField) string {
+		name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+		if name == "-" || name == "" {
+			return field.Name
+		}
+		return name
+	})
+
+	if err := i18n.RegisterValidator(v); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// fieldErrors lists one entry per failed rule. Fields are given as their
+// path below the request struct, like variants[0].sku.
+func fieldErrors(locale string, errs validator.ValidationErrors) []*model.FieldError {
+	fields := make([]*model.FieldError, 0, len(errs))
+	for _, fe := range errs {
+		field := fe.Namespace()
+		if i := strings.IndexByte(field, '.'); i >= 0 {
+			field = field[i+1:]
+		}
+
+		fields = append(fields, &model.FieldError{
+			Field:   field,
+			Rule:    fe.Tag(),
+			Message: i18n.ValidationMessage(locale, fe),
+		})
+	}
+	return fields
+}
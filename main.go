@@ -2,16 +2,34 @@ package main
 
 import (
 	"codebase-service/config"
+	cacheHandler "codebase-service/handlers/caches"
+	moderationHandler "codebase-service/handlers/moderation"
 	productHandler "codebase-service/handlers/products"
+	reservationHandler "codebase-service/handlers/reservations"
+	uploadHandler "codebase-service/handlers/uploads"
 	userHandler "codebase-service/handlers/users"
+	voucherHandler "codebase-service/handlers/vouchers"
+	"codebase-service/helper"
+	model "codebase-service/models"
 	"codebase-service/repository/products"
 	"codebase-service/repository/users"
+	"codebase-service/repository/vouchers"
 	"codebase-service/routes"
+	cacheSvc "codebase-service/usecases/caches"
+	moderationSvc "codebase-service/usecases/moderation"
 	productSvc "codebase-service/usecases/products"
+	reservationSvc "codebase-service/usecases/reservations"
+	uploadSvc "codebase-service/usecases/uploads"
 	userSvc "codebase-service/usecases/users"
+	voucherSvc "codebase-service/usecases/vouchers"
+	"codebase-service/util/cache"
+	"codebase-service/util/storage"
 	"context"
 	"database/sql"
 	"log"
+	"log/slog"
+	"net/http"
+	"os"
 
 	"github.com/go-playground/validator"
 	"github.com/redis/go-redis/v9"
@@ -23,6 +41,15 @@ func main() {
 		return
 	}
 
+	logger, err := config.NewLogger(cfg)
+	if err != nil {
+		log.Fatalf("cannot set up logger: %v", err)
+		return
+	}
+	// code without an injected logger, the log package included, writes
+	// through the same handler
+	slog.SetDefault(logger)
+
 	dbConn, err := config.ConnectToDatabase(config.Connection{
 		Host:     cfg.DBHost,
 		Port:     cfg.DBPort,
@@ -35,47 +62,111 @@ func main() {
 	}
 	defer dbConn.Close()
 
-	redisConn, err := config.ConnectToRedis(config.RedisConnection{
-		Host: cfg.RedisHost,
-		Port: cfg.RedisPort,
-		Pass: cfg.RedisPass,
-		DB:   cfg.RedisDB,
-	})
+	// redis only caches, the service serves from the db when it is disabled
+	// or unreachable
+	var redisConn *redis.Client
+	if cfg.CacheEnabled {
+		redisConn, err = config.ConnectToRedis(config.RedisConnection{
+			Host:    cfg.RedisHost,
+			Port:    cfg.RedisPort,
+			Pass:    cfg.RedisPass,
+			DB:      cfg.RedisDB,
+			Timeout: cfg.RedisTimeout,
+		})
+		if err != nil {
+			logger.Warn("redis unavailable, starting without cache until it is reachable", "err", err)
+		} else {
+			logger.Info("connected to redis")
+		}
+		defer redisConn.Close()
+	} else {
+		logger.Info("cache disabled, starting without redis")
+	}
+
+	blobStore, err := config.NewBlobStore(cfg)
 	if err != nil {
-		log.Fatalf("cannot connect to redis: %v", err)
-		return
+		logger.Error("cannot set up blob store", "err", err)
+		os.Exit(1)
 	}
 
-	// checj if redis is connected
-	err = redisConn.Ping(context.Background()).Err()
+	cacheClient, err := config.NewCacheClient(cfg, redisConn, logger)
 	if err != nil {
-		log.Fatalf("cannot connect to redis: %v", err)
-		return
-	} else {
-		log.Println("connected to redis")
+		logger.Error("cannot set up cache", "err", err)
+		os.Exit(1)
 	}
+	go cacheClient.Listen(context.Background())
 
-	validator := validator.New()
+	validator, err := helper.NewValidator()
+	if err != nil {
+		logger.Error("cannot set up validator", "err", err)
+		os.Exit(1)
+	}
 
-	routes := setupRoutes(dbConn, redisConn, validator)
+	routes := setupRoutes(cfg, dbConn, cacheClient, blobStore, validator, logger)
 	routes.Run(cfg.AppPort)
 }
 
 func setupRoutes(
+	cfg *config.Config,
 	db *sql.DB,
-	rdb *redis.Client,
+	cacheClient *cache.Client,
+	blobStore storage.BlobStore,
 	validator *validator.Validate,
+	logger *slog.Logger,
 ) *routes.Routes {
-	userStore := users.NewStore(db)
-	userSvc := userSvc.NewUserSvc(userStore)
-	userHandler := userHandler.NewHandler(userSvc, validator)
+	userStore := users.NewStore(db, logger)
+	userSvc := userSvc.NewUserSvc(userStore, logger)
+	userHandler := userHandler.NewHandler(userSvc, validator, logger)
+
+	productStore := products.NewStore(db, cacheClient, logger)
+	productSvc := productSvc.NewProductSvc(productStore, validator, productSvc.Moderation{
+		Enabled:     cfg.ModerationEnabled,
+		BannedWords: cfg.ModerationBannedWords,
+	}, productSvc.Retention{
+		RestoreWindow: cfg.ProductRestoreWindow,
+		PurgeAfter:    cfg.ProductPurgeAfter,
+	}, cfg.ReservationTTL, logger)
+	productHandler := productHandler.NewHandler(productSvc, validator, cfg.ImportMaxSize, logger)
+	go productSvc.RunScheduler(context.Background(), cfg.PublishSchedulerInterval)
+	go productSvc.RunPurger(context.Background(), cfg.ProductPurgeInterval)
+
+	reservationSvc := reservationSvc.NewReservationSvc(productStore, config.NewPaymentProvider(cfg), cfg.ReservationTTL, logger)
+	reservationHandler := reservationHandler.NewHandler(reservationSvc, validator, logger)
+	go reservationSvc.RunSweeper(context.Background(), cfg.ReservationSweepInterval)
+
+	moderationSvc := moderationSvc.NewModerationSvc(productStore, cfg.NotificationURL, logger)
+	moderationHandler := moderationHandler.NewHandler(moderationSvc, validator, logger)
 
-	productStore := products.NewStore(db, rdb)
-	productSvc := productSvc.NewProductSvc(productStore)
-	productHandler := productHandler.NewHandler(productSvc, validator)
+	cacheSvc := cacheSvc.NewCacheSvc(productStore, cacheClient, logger)
+	cacheHandler := cacheHandler.NewHandler(cacheSvc, validator, logger)
+	if cfg.CacheWarmUpEnabled {
+		go cacheSvc.RunWarmUp(context.Background(), &model.WarmCacheReq{
+			Products: cfg.CacheWarmUpProducts,
+			Pages:    cfg.CacheWarmUpPages,
+		})
+	}
+
+	voucherStore := vouchers.NewStore(db, logger)
+	voucherSvc := voucherSvc.NewVoucherSvc(voucherStore, logger)
+	voucherHandler := voucherHandler.NewHandler(voucherSvc, validator, logger)
+
+	uploadSvc := uploadSvc.NewUploadSvc(blobStore, logger)
+	uploadHandler := uploadHandler.NewHandler(uploadSvc, validator, cfg.UploadMaxSize, logger)
+
+	var uploadFiles http.Handler
+	if localStore, ok := blobStore.(*storage.LocalStore); ok {
+		uploadFiles = localStore.Handler()
+	}
 
 	return &routes.Routes{
-		User:    userHandler,
-		Product: productHandler,
+		User:        userHandler,
+		Product:     productHandler,
+		Voucher:     voucherHandler,
+		Reservation: reservationHandler,
+		Upload:      uploadHandler,
+		Moderation:  moderationHandler,
+		Cache:       cacheHandler,
+		UploadFiles: uploadFiles,
+		Logger:      logger,
 	}
 }
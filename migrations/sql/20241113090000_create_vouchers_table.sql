@@ -0,0 +1,47 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS vouchers (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    code VARCHAR(64) NOT NULL UNIQUE,
+    shop_id UUID,
+    discount_type VARCHAR(16) NOT NULL CHECK (discount_type IN ('percentage', 'fixed')),
+    discount_value DECIMAL(19, 4) NOT NULL CHECK (discount_value > 0),
+    min_spend DECIMAL(19, 4) DEFAULT 0.0 NOT NULL,
+    max_discount DECIMAL(19, 4),
+    usage_limit INT,
+    usage_limit_per_user INT,
+    used_count INT DEFAULT 0 NOT NULL,
+    category_ids UUID[] DEFAULT '{}' NOT NULL,
+    product_ids UUID[] DEFAULT '{}' NOT NULL,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+
+    FOREIGN KEY (shop_id) REFERENCES shops(id),
+    CHECK (ends_at > starts_at),
+    CHECK (usage_limit IS NULL OR used_count <= usage_limit)
+);
+
+CREATE TABLE IF NOT EXISTS voucher_redemptions (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    voucher_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    order_id VARCHAR(255) NOT NULL,
+    discount_amount DECIMAL(19, 4) NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+
+    FOREIGN KEY (voucher_id) REFERENCES vouchers(id),
+    UNIQUE (voucher_id, order_id)
+);
+
+CREATE INDEX IF NOT EXISTS voucher_redemptions_voucher_user_idx ON voucher_redemptions (voucher_id, user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS voucher_redemptions;
+DROP TABLE IF EXISTS vouchers;
+-- +goose StatementEnd
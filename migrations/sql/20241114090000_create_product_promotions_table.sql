@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS product_promotions (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    product_id UUID NOT NULL,
+    type VARCHAR(16) NOT NULL CHECK (type IN ('sale', 'flash_sale')),
+    sale_price DECIMAL(19, 4) NOT NULL CHECK (sale_price >= 0),
+    quantity INT CHECK (quantity > 0),
+    claimed INT DEFAULT 0 NOT NULL,
+    starts_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    ends_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+
+    FOREIGN KEY (product_id) REFERENCES products(id),
+    CHECK (ends_at > starts_at),
+    CHECK (type = 'sale' OR quantity IS NOT NULL),
+    CHECK (quantity IS NULL OR claimed <= quantity)
+);
+
+CREATE INDEX IF NOT EXISTS product_promotions_product_window_idx ON product_promotions (product_id, starts_at, ends_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS product_promotions;
+-- +goose StatementEnd
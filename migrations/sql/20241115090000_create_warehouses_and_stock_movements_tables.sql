@@ -0,0 +1,92 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS warehouses (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    shop_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    is_default BOOLEAN DEFAULT false NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+
+    FOREIGN KEY (shop_id) REFERENCES shops(id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS warehouses_shop_default_idx ON warehouses (shop_id) WHERE is_default;
+
+CREATE TABLE IF NOT EXISTS warehouse_stocks (
+    warehouse_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    quantity INT DEFAULT 0 NOT NULL CHECK (quantity >= 0),
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+
+    PRIMARY KEY (warehouse_id, product_id),
+    FOREIGN KEY (warehouse_id) REFERENCES warehouses(id),
+    FOREIGN KEY (product_id) REFERENCES products(id)
+);
+
+CREATE INDEX IF NOT EXISTS warehouse_stocks_product_idx ON warehouse_stocks (product_id);
+
+-- product_id is deliberately not a foreign key, the ledger outlives the products it describes
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    product_id UUID NOT NULL,
+    warehouse_id UUID NOT NULL,
+    type VARCHAR(16) NOT NULL CHECK (type IN ('receipt', 'sale', 'adjustment', 'return', 'reservation')),
+    quantity INT NOT NULL CHECK (quantity <> 0),
+    reason TEXT NOT NULL,
+    reference VARCHAR(255),
+    created_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+
+    FOREIGN KEY (warehouse_id) REFERENCES warehouses(id)
+);
+
+CREATE INDEX IF NOT EXISTS stock_movements_product_idx ON stock_movements (product_id, created_at);
+
+CREATE OR REPLACE FUNCTION stock_movements_append_only() RETURNS TRIGGER AS $$
+BEGIN
+    RAISE EXCEPTION 'stock_movements is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER stock_movements_append_only
+    BEFORE UPDATE OR DELETE ON stock_movements
+    FOR EACH ROW EXECUTE FUNCTION stock_movements_append_only();
+
+-- every shop gets a default warehouse holding the stock products had so far
+INSERT INTO warehouses (shop_id, name, is_default)
+SELECT id, 'Main warehouse', true FROM shops;
+
+INSERT INTO warehouse_stocks (warehouse_id, product_id, quantity)
+SELECT w.id, p.id, p.stock
+FROM products p
+JOIN warehouses w ON w.shop_id = p.shop_id AND w.is_default;
+
+INSERT INTO stock_movements (product_id, warehouse_id, type, quantity, reason)
+SELECT ws.product_id, ws.warehouse_id, 'receipt', ws.quantity, 'opening balance'
+FROM warehouse_stocks ws
+WHERE ws.quantity > 0;
+
+ALTER TABLE products DROP COLUMN stock;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE products ADD COLUMN stock INT DEFAULT 0 NOT NULL;
+
+UPDATE products p
+SET stock = ws.quantity
+FROM (
+    SELECT product_id, SUM(quantity) AS quantity
+    FROM warehouse_stocks
+    GROUP BY product_id
+) ws
+WHERE ws.product_id = p.id;
+
+DROP TRIGGER IF EXISTS stock_movements_append_only ON stock_movements;
+DROP FUNCTION IF EXISTS stock_movements_append_only();
+DROP TABLE IF EXISTS stock_movements;
+DROP TABLE IF EXISTS warehouse_stocks;
+DROP TABLE IF EXISTS warehouses;
+-- +goose StatementEnd
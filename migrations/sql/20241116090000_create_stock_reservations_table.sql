@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS stock_reservations (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    user_id UUID NOT NULL,
+    reference VARCHAR(255) NOT NULL,
+    status VARCHAR(16) DEFAULT 'active' NOT NULL CHECK (status IN ('active', 'released', 'confirmed')),
+    expires_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS stock_reservations_active_expiry_idx ON stock_reservations (expires_at) WHERE status = 'active';
+
+CREATE TABLE IF NOT EXISTS stock_reservation_items (
+    reservation_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    warehouse_id UUID NOT NULL,
+    quantity INT NOT NULL CHECK (quantity > 0),
+
+    PRIMARY KEY (reservation_id, product_id, warehouse_id),
+    FOREIGN KEY (reservation_id) REFERENCES stock_reservations(id),
+    FOREIGN KEY (product_id) REFERENCES products(id),
+    FOREIGN KEY (warehouse_id) REFERENCES warehouses(id)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS stock_reservation_items;
+DROP TABLE IF EXISTS stock_reservations;
+-- +goose StatementEnd
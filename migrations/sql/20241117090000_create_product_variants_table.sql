@@ -0,0 +1,59 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS product_options (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    product_id UUID NOT NULL,
+    name VARCHAR(64) NOT NULL,
+    position INT DEFAULT 0 NOT NULL,
+    values TEXT[] NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+
+    FOREIGN KEY (product_id) REFERENCES products(id),
+    UNIQUE (product_id, name)
+);
+
+CREATE TABLE IF NOT EXISTS product_variants (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    product_id UUID NOT NULL,
+    sku VARCHAR(64) NOT NULL UNIQUE,
+    options JSONB NOT NULL,
+    price DECIMAL(19, 4) CHECK (price >= 0),
+    image_url TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE,
+
+    FOREIGN KEY (product_id) REFERENCES products(id),
+    UNIQUE (product_id, options)
+);
+
+-- variant stock lives in the same warehouse levels and ledger as product stock,
+-- rows without a variant hold the stock of products sold without variants
+ALTER TABLE warehouse_stocks DROP CONSTRAINT warehouse_stocks_pkey;
+ALTER TABLE warehouse_stocks ADD COLUMN variant_id UUID REFERENCES product_variants(id);
+CREATE UNIQUE INDEX warehouse_stocks_level_idx ON warehouse_stocks (warehouse_id, product_id, (COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'::uuid)));
+
+ALTER TABLE stock_movements ADD COLUMN variant_id UUID;
+
+ALTER TABLE stock_reservation_items DROP CONSTRAINT stock_reservation_items_pkey;
+ALTER TABLE stock_reservation_items ADD COLUMN variant_id UUID REFERENCES product_variants(id);
+CREATE INDEX stock_reservation_items_reservation_idx ON stock_reservation_items (reservation_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS stock_reservation_items_reservation_idx;
+DELETE FROM stock_reservation_items WHERE variant_id IS NOT NULL;
+ALTER TABLE stock_reservation_items DROP COLUMN variant_id;
+ALTER TABLE stock_reservation_items ADD PRIMARY KEY (reservation_id, product_id, warehouse_id);
+
+ALTER TABLE stock_movements DROP COLUMN variant_id;
+
+DROP INDEX IF EXISTS warehouse_stocks_level_idx;
+DELETE FROM warehouse_stocks WHERE variant_id IS NOT NULL;
+ALTER TABLE warehouse_stocks DROP COLUMN variant_id;
+ALTER TABLE warehouse_stocks ADD PRIMARY KEY (warehouse_id, product_id);
+
+DROP TABLE IF EXISTS product_variants;
+DROP TABLE IF EXISTS product_options;
+-- +goose StatementEnd
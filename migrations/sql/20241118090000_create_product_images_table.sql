@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE IF NOT EXISTS product_images (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    product_id UUID NOT NULL,
+    url TEXT NOT NULL,
+    alt_text VARCHAR(255) DEFAULT '' NOT NULL,
+    position INT DEFAULT 0 NOT NULL,
+    is_primary BOOLEAN DEFAULT false NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+
+    FOREIGN KEY (product_id) REFERENCES products(id)
+);
+
+CREATE INDEX IF NOT EXISTS product_images_product_idx ON product_images (product_id, position);
+CREATE UNIQUE INDEX IF NOT EXISTS product_images_primary_idx ON product_images (product_id) WHERE is_primary;
+
+INSERT INTO product_images (product_id, url, alt_text, position, is_primary)
+SELECT id, image_url, name, 0, true
+FROM products
+WHERE image_url IS NOT NULL AND image_url <> '';
+
+ALTER TABLE products DROP COLUMN image_url;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE products ADD COLUMN image_url TEXT;
+
+UPDATE products p
+SET image_url = pi.url
+FROM product_images pi
+WHERE pi.product_id = p.id AND pi.is_primary;
+
+DROP TABLE IF EXISTS product_images;
+-- +goose StatementEnd
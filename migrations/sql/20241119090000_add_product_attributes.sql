@@ -0,0 +1,42 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE product_categories ADD COLUMN attribute_schema JSONB DEFAULT '[]' NOT NULL;
+
+ALTER TABLE products ADD COLUMN attributes JSONB DEFAULT '{}' NOT NULL;
+
+-- starter schemas for the seeded categories, nothing is required so existing
+-- clients that do not send attributes keep working
+UPDATE product_categories
+SET attribute_schema = '[
+    {"name": "brand", "type": "string"},
+    {"name": "model", "type": "string"},
+    {"name": "weight", "type": "number", "unit": "g"},
+    {"name": "warranty_months", "type": "number"}
+]'
+WHERE name = 'Electronics';
+
+UPDATE product_categories
+SET attribute_schema = '[
+    {"name": "brand", "type": "string"},
+    {"name": "material", "type": "string"},
+    {"name": "gender", "type": "enum", "values": ["men", "women", "unisex"]}
+]'
+WHERE name = 'Clothing';
+
+UPDATE product_categories
+SET attribute_schema = '[
+    {"name": "author", "type": "string"},
+    {"name": "publisher", "type": "string"},
+    {"name": "isbn", "type": "string"},
+    {"name": "pages", "type": "number"},
+    {"name": "hardcover", "type": "boolean"}
+]'
+WHERE name = 'Books';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE products DROP COLUMN attributes;
+
+ALTER TABLE product_categories DROP COLUMN attribute_schema;
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+-- products that already exist are live, new products start as drafts
+ALTER TABLE products ADD COLUMN status VARCHAR(16) DEFAULT 'published' NOT NULL;
+ALTER TABLE products ALTER COLUMN status SET DEFAULT 'draft';
+ALTER TABLE products ADD CONSTRAINT products_status_check CHECK (status IN ('draft', 'published', 'archived', 'scheduled'));
+ALTER TABLE products ADD COLUMN publish_at TIMESTAMP WITH TIME ZONE;
+ALTER TABLE products ADD CONSTRAINT products_publish_at_check CHECK (status <> 'scheduled' OR publish_at IS NOT NULL);
+
+UPDATE products SET publish_at = created_at;
+
+CREATE INDEX IF NOT EXISTS products_shop_status_idx ON products (shop_id, status) WHERE deleted_at IS NULL;
+CREATE INDEX IF NOT EXISTS products_scheduled_idx ON products (publish_at) WHERE status = 'scheduled' AND deleted_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS products_scheduled_idx;
+DROP INDEX IF EXISTS products_shop_status_idx;
+
+ALTER TABLE products DROP COLUMN publish_at;
+ALTER TABLE products DROP COLUMN status;
+-- +goose StatementEnd
@@ -0,0 +1,36 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE products DROP CONSTRAINT products_status_check;
+ALTER TABLE products ADD CONSTRAINT products_status_check CHECK (status IN ('draft', 'published', 'archived', 'scheduled', 'pending_review', 'rejected'));
+
+-- a review is opened whenever a product would go public while moderation is
+-- on or its text hits the banned-word filter, target_status is what the
+-- product becomes once approved
+CREATE TABLE IF NOT EXISTS product_reviews (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    product_id UUID NOT NULL,
+    status VARCHAR(16) DEFAULT 'pending' NOT NULL CHECK (status IN ('pending', 'approved', 'rejected', 'cancelled')),
+    target_status VARCHAR(16) NOT NULL CHECK (target_status IN ('published', 'scheduled')),
+    publish_at TIMESTAMP WITH TIME ZONE,
+    flagged_words TEXT[] DEFAULT '{}' NOT NULL,
+    reason TEXT,
+    reviewed_by UUID,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    reviewed_at TIMESTAMP WITH TIME ZONE,
+
+    FOREIGN KEY (product_id) REFERENCES products(id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS product_reviews_pending_idx ON product_reviews (product_id) WHERE status = 'pending';
+CREATE INDEX IF NOT EXISTS product_reviews_queue_idx ON product_reviews (created_at) WHERE status = 'pending';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS product_reviews;
+
+UPDATE products SET status = 'draft' WHERE status IN ('pending_review', 'rejected');
+
+ALTER TABLE products DROP CONSTRAINT products_status_check;
+ALTER TABLE products ADD CONSTRAINT products_status_check CHECK (status IN ('draft', 'published', 'archived', 'scheduled'));
+-- +goose StatementEnd
@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+-- reservation lines, refund restocks and the stock ledger are order history
+-- and outlive the products they point at, purged products leave a tombstone
+-- so that history can still be read
+ALTER TABLE stock_reservation_items DROP CONSTRAINT stock_reservation_items_product_id_fkey;
+ALTER TABLE stock_reservation_items DROP CONSTRAINT stock_reservation_items_variant_id_fkey;
+ALTER TABLE product_restocks DROP CONSTRAINT product_restocks_product_id_fkey;
+
+CREATE TABLE IF NOT EXISTS purged_products (
+    id UUID PRIMARY KEY,
+    shop_id UUID NOT NULL,
+    category_id UUID NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    variant_skus JSONB DEFAULT '{}' NOT NULL,
+    deleted_at TIMESTAMP WITH TIME ZONE NOT NULL,
+    purged_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS products_deleted_at_idx ON products (deleted_at) WHERE deleted_at IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS products_deleted_at_idx;
+
+DROP TABLE IF EXISTS purged_products;
+
+ALTER TABLE product_restocks ADD CONSTRAINT product_restocks_product_id_fkey FOREIGN KEY (product_id) REFERENCES products(id) NOT VALID;
+ALTER TABLE stock_reservation_items ADD CONSTRAINT stock_reservation_items_variant_id_fkey FOREIGN KEY (variant_id) REFERENCES product_variants(id) NOT VALID;
+ALTER TABLE stock_reservation_items ADD CONSTRAINT stock_reservation_items_product_id_fkey FOREIGN KEY (product_id) REFERENCES products(id) NOT VALID;
+-- +goose StatementEnd
@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE products ADD COLUMN sku VARCHAR(64);
+
+CREATE UNIQUE INDEX IF NOT EXISTS products_shop_sku_idx ON products (shop_id, sku) WHERE sku IS NOT NULL AND deleted_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS product_imports (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    shop_id UUID NOT NULL,
+    user_id UUID NOT NULL,
+    format VARCHAR(16) NOT NULL CHECK (format IN ('csv', 'ndjson')),
+    status VARCHAR(16) DEFAULT 'pending' NOT NULL CHECK (status IN ('pending', 'running', 'completed', 'failed')),
+    total_rows INT DEFAULT 0 NOT NULL,
+    processed_rows INT DEFAULT 0 NOT NULL,
+    created_count INT DEFAULT 0 NOT NULL,
+    updated_count INT DEFAULT 0 NOT NULL,
+    failed_count INT DEFAULT 0 NOT NULL,
+    errors JSONB DEFAULT '[]' NOT NULL,
+    error TEXT,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    started_at TIMESTAMP WITH TIME ZONE,
+    finished_at TIMESTAMP WITH TIME ZONE,
+
+    FOREIGN KEY (shop_id) REFERENCES shops(id)
+);
+
+CREATE INDEX IF NOT EXISTS product_imports_shop_idx ON product_imports (shop_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS product_imports;
+
+DROP INDEX IF EXISTS products_shop_sku_idx;
+
+ALTER TABLE products DROP COLUMN sku;
+-- +goose StatementEnd
@@ -0,0 +1,15 @@
+-- +goose Up
+-- +goose StatementBegin
+-- restocks are checked against the units the order bought, rows recorded
+-- before the check have no order
+ALTER TABLE product_restocks ADD COLUMN order_id VARCHAR(255);
+
+CREATE INDEX IF NOT EXISTS product_restocks_order_idx ON product_restocks (order_id, product_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS product_restocks_order_idx;
+
+ALTER TABLE product_restocks DROP COLUMN order_id;
+-- +goose StatementEnd
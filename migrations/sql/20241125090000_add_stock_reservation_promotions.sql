@@ -0,0 +1,12 @@
+-- +goose Up
+-- +goose StatementBegin
+-- flash-sale claims hold their units in a reservation, releasing it gives
+-- the claim back to the promotion. like the reservation lines it keeps no
+-- foreign key, purging a product deletes its promotions
+ALTER TABLE stock_reservations ADD COLUMN promotion_id UUID;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE stock_reservations DROP COLUMN promotion_id;
+-- +goose StatementEnd
@@ -0,0 +1,20 @@
+-- +goose Up
+-- +goose StatementBegin
+-- products with variants are restocked per variant, a refund can restock
+-- several variants of the same product. like stock_movements.variant_id it
+-- keeps no foreign key, purging a product deletes its variants
+ALTER TABLE product_restocks ADD COLUMN variant_id UUID;
+
+ALTER TABLE product_restocks DROP CONSTRAINT IF EXISTS product_restocks_refund_id_product_id_key;
+
+CREATE UNIQUE INDEX IF NOT EXISTS product_restocks_refund_idx ON product_restocks (refund_id, product_id, (COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'::uuid)));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX IF EXISTS product_restocks_refund_idx;
+
+ALTER TABLE product_restocks ADD CONSTRAINT product_restocks_refund_id_product_id_key UNIQUE (refund_id, product_id);
+
+ALTER TABLE product_restocks DROP COLUMN variant_id;
+-- +goose StatementEnd
@@ -0,0 +1,66 @@
+-- +goose Up
+-- +goose StatementBegin
+-- reservation lines keep the price the buyer paid, refunds are priced from
+-- it. lines reserved before prices were recorded get the current price
+ALTER TABLE stock_reservation_items ADD COLUMN unit_price DECIMAL(19, 4);
+
+UPDATE stock_reservation_items i
+SET unit_price = COALESCE(
+    (SELECT pv.price FROM product_variants pv WHERE pv.id = i.variant_id),
+    (SELECT p.price FROM products p WHERE p.id = i.product_id),
+    0
+);
+
+ALTER TABLE stock_reservation_items ALTER COLUMN unit_price SET NOT NULL;
+
+-- paid reservations are refunded in part or in full
+ALTER TABLE stock_reservations DROP CONSTRAINT IF EXISTS stock_reservations_status_check;
+ALTER TABLE stock_reservations ADD CONSTRAINT stock_reservations_status_check CHECK (status IN ('active', 'released', 'confirmed', 'partially_refunded', 'refunded'));
+
+CREATE TABLE IF NOT EXISTS refunds (
+    id UUID DEFAULT gen_random_uuid() PRIMARY KEY,
+    reservation_id UUID NOT NULL,
+    order_id VARCHAR(255) NOT NULL,
+    status VARCHAR(16) DEFAULT 'pending' NOT NULL CHECK (status IN ('pending', 'succeeded', 'failed')),
+    amount DECIMAL(19, 4) NOT NULL CHECK (amount >= 0),
+    reason TEXT NOT NULL,
+    restock BOOLEAN DEFAULT false NOT NULL,
+    provider_reference VARCHAR(255),
+    failure_reason TEXT,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP WITH TIME ZONE DEFAULT CURRENT_TIMESTAMP NOT NULL,
+
+    FOREIGN KEY (reservation_id) REFERENCES stock_reservations(id)
+);
+
+CREATE INDEX IF NOT EXISTS refunds_reservation_idx ON refunds (reservation_id);
+
+-- refund lines are order history like the reservation lines, they keep no
+-- foreign key to products or variants
+CREATE TABLE IF NOT EXISTS refund_items (
+    refund_id UUID NOT NULL,
+    product_id UUID NOT NULL,
+    variant_id UUID,
+    quantity INT NOT NULL CHECK (quantity > 0),
+    unit_price DECIMAL(19, 4) NOT NULL,
+    amount DECIMAL(19, 4) NOT NULL,
+
+    FOREIGN KEY (refund_id) REFERENCES refunds(id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS refund_items_line_idx ON refund_items (refund_id, product_id, (COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'::uuid)));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE IF EXISTS refund_items;
+DROP TABLE IF EXISTS refunds;
+
+UPDATE stock_reservations SET status = 'confirmed' WHERE status IN ('partially_refunded', 'refunded');
+
+ALTER TABLE stock_reservations DROP CONSTRAINT IF EXISTS stock_reservations_status_check;
+ALTER TABLE stock_reservations ADD CONSTRAINT stock_reservations_status_check CHECK (status IN ('active', 'released', 'confirmed'));
+
+ALTER TABLE stock_reservation_items DROP COLUMN unit_price;
+-- +goose StatementEnd
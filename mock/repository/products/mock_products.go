@@ -3,6 +3,8 @@ package mock_products
 import (
 	model "codebase-service/models"
 	"codebase-service/repository/products"
+	"context"
+	"time"
 
 	"github.com/stretchr/testify/mock"
 )
@@ -17,8 +19,8 @@ func NewMockProductRepo() *MockProductRepo {
 	return &MockProductRepo{}
 }
 
-func (m *MockProductRepo) IsShopOwner(userId, shopId string) error {
-	args := m.Called(userId, shopId)
+func (m *MockProductRepo) IsShopOwner(ctx context.Context, userId, shopId string) error {
+	args := m.Called(ctx, userId, shopId)
 	var (
 		err error
 	)
@@ -30,8 +32,8 @@ func (m *MockProductRepo) IsShopOwner(userId, shopId string) error {
 	return err
 }
 
-func (m *MockProductRepo) CreateProduct(req *model.CreateProductReq) (*model.GetProductResp, error) {
-	args := m.Called(req)
+func (m *MockProductRepo) CreateProduct(ctx context.Context, req *model.CreateProductReq) (*model.GetProductResp, error) {
+	args := m.Called(ctx, req)
 	var (
 		resp *model.GetProductResp
 		err  error
@@ -47,8 +49,8 @@ func (m *MockProductRepo) CreateProduct(req *model.CreateProductReq) (*model.Get
 
 	return resp, err
 }
-func (m *MockProductRepo) GetProduct(req *model.GetProductReq) (*model.GetProductResp, error) {
-	args := m.Called(req)
+func (m *MockProductRepo) GetProduct(ctx context.Context, req *model.GetProductReq) (*model.GetProductResp, error) {
+	args := m.Called(ctx, req)
 	var (
 		resp *model.GetProductResp
 		err  error
@@ -65,8 +67,8 @@ func (m *MockProductRepo) GetProduct(req *model.GetProductReq) (*model.GetProduc
 	return resp, err
 }
 
-func (m *MockProductRepo) GetProducts(req *model.GetProductsReq) (*model.GetProductsResp, error) {
-	args := m.Called(req)
+func (m *MockProductRepo) GetProducts(ctx context.Context, req *model.GetProductsReq) (*model.GetProductsResp, error) {
+	args := m.Called(ctx, req)
 	var (
 		resp *model.GetProductsResp
 		err  error
@@ -83,8 +85,653 @@ func (m *MockProductRepo) GetProducts(req *model.GetProductsReq) (*model.GetProd
 	return resp, err
 }
 
-func (m *MockProductRepo) DeleteProduct(req *model.DeleteProductReq) error {
-	args := m.Called(req)
+func (m *MockProductRepo) DeleteProduct(ctx context.Context, req *model.DeleteProductReq) error {
+	args := m.Called(ctx, req)
+	var (
+		err error
+	)
+
+	if n, ok := args.Get(0).(error); ok {
+		err = n
+	}
+
+	return err
+}
+
+func (m *MockProductRepo) IsProductOwner(ctx context.Context, userId, productId string) error {
+	args := m.Called(ctx, userId, productId)
+	var (
+		err error
+	)
+
+	if n, ok := args.Get(0).(error); ok {
+		err = n
+	}
+
+	return err
+}
+
+func (m *MockProductRepo) CreatePromotion(ctx context.Context, req *model.CreatePromotionReq) (*model.Promotion, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp *model.Promotion
+		err  error
+	)
+
+	if n, ok := args.Get(0).(*model.Promotion); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) ClaimFlashSale(ctx context.Context, req *model.ClaimFlashSaleReq) (*model.ClaimFlashSaleResp, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp *model.ClaimFlashSaleResp
+		err  error
+	)
+
+	if n, ok := args.Get(0).(*model.ClaimFlashSaleResp); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) CreateWarehouse(ctx context.Context, req *model.CreateWarehouseReq) (*model.Warehouse, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp *model.Warehouse
+		err  error
+	)
+
+	if n, ok := args.Get(0).(*model.Warehouse); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) GetWarehouses(ctx context.Context, req *model.GetWarehousesReq) ([]*model.Warehouse, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp []*model.Warehouse
+		err  error
+	)
+
+	if n, ok := args.Get(0).([]*model.Warehouse); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) CreateStockAdjustment(ctx context.Context, req *model.CreateStockAdjustmentReq) (*model.StockAdjustmentResp, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp *model.StockAdjustmentResp
+		err  error
+	)
+
+	if n, ok := args.Get(0).(*model.StockAdjustmentResp); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) CreateReservation(ctx context.Context, req *model.CreateReservationReq) (*model.Reservation, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp *model.Reservation
+		err  error
+	)
+
+	if n, ok := args.Get(0).(*model.Reservation); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) ConfirmReservation(ctx context.Context, req *model.ReservationReq) (*model.Reservation, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp *model.Reservation
+		err  error
+	)
+
+	if n, ok := args.Get(0).(*model.Reservation); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) ReleaseReservation(ctx context.Context, req *model.ReservationReq) (*model.Reservation, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp *model.Reservation
+		err  error
+	)
+
+	if n, ok := args.Get(0).(*model.Reservation); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) ReleaseExpiredReservations(ctx context.Context, limit int) (int, error) {
+	args := m.Called(ctx, limit)
+	var (
+		resp int
+		err  error
+	)
+
+	if n, ok := args.Get(0).(int); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) CreateRefund(ctx context.Context, req *model.CreateRefundReq) (*model.Refund, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp *model.Refund
+		err  error
+	)
+
+	if n, ok := args.Get(0).(*model.Refund); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) CompleteRefund(ctx context.Context, req *model.CompleteRefundReq) (*model.Refund, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp *model.Refund
+		err  error
+	)
+
+	if n, ok := args.Get(0).(*model.Refund); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) SetProductOptions(ctx context.Context, req *model.SetProductOptionsReq) ([]*model.ProductOption, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp []*model.ProductOption
+		err  error
+	)
+
+	if n, ok := args.Get(0).([]*model.ProductOption); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) GetProductOptions(ctx context.Context, productId string) ([]*model.ProductOption, error) {
+	args := m.Called(ctx, productId)
+	var (
+		resp []*model.ProductOption
+		err  error
+	)
+
+	if n, ok := args.Get(0).([]*model.ProductOption); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) CreateVariant(ctx context.Context, req *model.CreateVariantReq) (*model.ProductVariant, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp *model.ProductVariant
+		err  error
+	)
+
+	if n, ok := args.Get(0).(*model.ProductVariant); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) AddProductImage(ctx context.Context, req *model.AddProductImageReq) (*model.ProductImage, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp *model.ProductImage
+		err  error
+	)
+
+	if n, ok := args.Get(0).(*model.ProductImage); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) ReorderProductImages(ctx context.Context, req *model.ReorderProductImagesReq) ([]*model.ProductImage, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp []*model.ProductImage
+		err  error
+	)
+
+	if n, ok := args.Get(0).([]*model.ProductImage); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) DeleteProductImage(ctx context.Context, req *model.DeleteProductImageReq) error {
+	args := m.Called(ctx, req)
+	var (
+		err error
+	)
+
+	if n, ok := args.Get(0).(error); ok {
+		err = n
+	}
+
+	return err
+}
+
+func (m *MockProductRepo) UpdateProduct(ctx context.Context, req *model.UpdateProductReq) (*model.GetProductResp, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp *model.GetProductResp
+		err  error
+	)
+
+	if n, ok := args.Get(0).(*model.GetProductResp); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) GetCategoryAttributes(ctx context.Context, categoryId string) ([]*model.AttributeDefinition, error) {
+	args := m.Called(ctx, categoryId)
+	var (
+		resp []*model.AttributeDefinition
+		err  error
+	)
+
+	if n, ok := args.Get(0).([]*model.AttributeDefinition); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) SetCategoryAttributes(ctx context.Context, req *model.SetCategoryAttributesReq) ([]*model.AttributeDefinition, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp []*model.AttributeDefinition
+		err  error
+	)
+
+	if n, ok := args.Get(0).([]*model.AttributeDefinition); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) SetProductStatus(ctx context.Context, req *model.SetProductStatusReq) (*model.GetProductResp, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp *model.GetProductResp
+		err  error
+	)
+
+	if n, ok := args.Get(0).(*model.GetProductResp); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) GetShopProducts(ctx context.Context, req *model.GetShopProductsReq) (*model.GetProductsResp, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp *model.GetProductsResp
+		err  error
+	)
+
+	if n, ok := args.Get(0).(*model.GetProductsResp); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) PublishScheduledProducts(ctx context.Context, limit int) (int, error) {
+	args := m.Called(ctx, limit)
+	var (
+		resp int
+		err  error
+	)
+
+	if n, ok := args.Get(0).(int); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) GetModerationQueue(ctx context.Context, req *model.GetModerationQueueReq) (*model.GetModerationQueueResp, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp *model.GetModerationQueueResp
+		err  error
+	)
+
+	if n, ok := args.Get(0).(*model.GetModerationQueueResp); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) DecideReview(ctx context.Context, req *model.ReviewDecisionReq) (*model.ProductReview, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp *model.ProductReview
+		err  error
+	)
+
+	if n, ok := args.Get(0).(*model.ProductReview); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) RestoreProduct(ctx context.Context, req *model.RestoreProductReq) (*model.GetProductResp, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp *model.GetProductResp
+		err  error
+	)
+
+	if n, ok := args.Get(0).(*model.GetProductResp); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) PurgeDeletedProducts(ctx context.Context, deletedBefore time.Time, limit int) (int, error) {
+	args := m.Called(ctx, deletedBefore, limit)
+	var (
+		resp int
+		err  error
+	)
+
+	if n, ok := args.Get(0).(int); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) CreateImport(ctx context.Context, req *model.ProductImport) (*model.ProductImport, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp *model.ProductImport
+		err  error
+	)
+
+	if n, ok := args.Get(0).(*model.ProductImport); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) GetImport(ctx context.Context, id string) (*model.ProductImport, error) {
+	args := m.Called(ctx, id)
+	var (
+		resp *model.ProductImport
+		err  error
+	)
+
+	if n, ok := args.Get(0).(*model.ProductImport); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) UpdateImport(ctx context.Context, req *model.ProductImport) error {
+	args := m.Called(ctx, req)
+	var (
+		err error
+	)
+
+	if n, ok := args.Get(0).(error); ok {
+		err = n
+	}
+
+	return err
+}
+
+func (m *MockProductRepo) UpsertProducts(ctx context.Context, req *model.UpsertProductsReq) ([]*model.ImportRowResult, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp []*model.ImportRowResult
+		err  error
+	)
+
+	if n, ok := args.Get(0).([]*model.ImportRowResult); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) ExportProducts(ctx context.Context, req *model.ExportProductsReq, fn func(row *model.ExportProductRow) error) error {
+	args := m.Called(ctx, req, fn)
+	var (
+		err error
+	)
+
+	// rows given to Return after the error are passed to fn in order
+	for _, arg := range args[1:] {
+		if row, ok := arg.(*model.ExportProductRow); ok {
+			if err := fn(row); err != nil {
+				return err
+			}
+		}
+	}
+
+	if n, ok := args.Get(0).(error); ok {
+		err = n
+	}
+
+	return err
+}
+
+func (m *MockProductRepo) GetTopProductIds(ctx context.Context, since time.Time, limit int) ([]string, error) {
+	args := m.Called(ctx, since, limit)
+	var (
+		resp []string
+		err  error
+	)
+
+	if n, ok := args.Get(0).([]string); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) GetShopProductIds(ctx context.Context, shopId string) ([]string, error) {
+	args := m.Called(ctx, shopId)
+	var (
+		resp []string
+		err  error
+	)
+
+	if n, ok := args.Get(0).([]string); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockProductRepo) PurgeProductCache(ctx context.Context, ids ...string) error {
+	args := m.Called(ctx, ids)
+	var (
+		err error
+	)
+
+	if n, ok := args.Get(0).(error); ok {
+		err = n
+	}
+
+	return err
+}
+
+func (m *MockProductRepo) EvictShopCache(ctx context.Context, shopId string) error {
+	args := m.Called(ctx, shopId)
 	var (
 		err error
 	)
@@ -0,0 +1,56 @@
+package mock_users
+
+import (
+	model "codebase-service/models"
+	"codebase-service/repository/users"
+	"context"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+)
+
+var _ users.UserRepository = &MockUserRepo{}
+
+type MockUserRepo struct {
+	mock.Mock
+}
+
+func NewMockUserRepo() *MockUserRepo {
+	return &MockUserRepo{}
+}
+
+func (m *MockUserRepo) UserRegister(ctx context.Context, req model.Users) (*uuid.UUID, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp *uuid.UUID
+		err  error
+	)
+
+	if n, ok := args.Get(0).(*uuid.UUID); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockUserRepo) GetUserDetail(ctx context.Context, req model.Users) (*model.Users, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp *model.Users
+		err  error
+	)
+
+	if n, ok := args.Get(0).(*model.Users); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
@@ -0,0 +1,122 @@
+package mock_vouchers
+
+import (
+	model "codebase-service/models"
+	"codebase-service/repository/vouchers"
+	"context"
+
+	"github.com/stretchr/testify/mock"
+)
+
+var _ vouchers.VoucherRepository = &MockVoucherRepo{}
+
+type MockVoucherRepo struct {
+	mock.Mock
+}
+
+func NewMockVoucherRepo() *MockVoucherRepo {
+	return &MockVoucherRepo{}
+}
+
+func (m *MockVoucherRepo) IsShopOwner(ctx context.Context, userId, shopId string) error {
+	args := m.Called(ctx, userId, shopId)
+	var (
+		err error
+	)
+
+	if n, ok := args.Get(0).(error); ok {
+		err = n
+	}
+
+	return err
+}
+
+func (m *MockVoucherRepo) CreateVoucher(ctx context.Context, req *model.CreateVoucherReq) (*model.Voucher, error) {
+	args := m.Called(ctx, req)
+	var (
+		resp *model.Voucher
+		err  error
+	)
+
+	if n, ok := args.Get(0).(*model.Voucher); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockVoucherRepo) GetVoucherByCode(ctx context.Context, code string) (*model.Voucher, error) {
+	args := m.Called(ctx, code)
+	var (
+		resp *model.Voucher
+		err  error
+	)
+
+	if n, ok := args.Get(0).(*model.Voucher); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockVoucherRepo) GetVoucherProducts(ctx context.Context, items []*model.VoucherItemReq) ([]*model.VoucherProduct, error) {
+	args := m.Called(ctx, items)
+	var (
+		resp []*model.VoucherProduct
+		err  error
+	)
+
+	if n, ok := args.Get(0).([]*model.VoucherProduct); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockVoucherRepo) CountUserRedemptions(ctx context.Context, voucherId, userId string) (int64, error) {
+	args := m.Called(ctx, voucherId, userId)
+	var (
+		resp int64
+		err  error
+	)
+
+	if n, ok := args.Get(0).(int64); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
+
+func (m *MockVoucherRepo) RedeemVoucher(ctx context.Context, voucherId string, req *model.RedeemVoucherReq, discount float64) (*model.RedeemVoucherResp, error) {
+	args := m.Called(ctx, voucherId, req, discount)
+	var (
+		resp *model.RedeemVoucherResp
+		err  error
+	)
+
+	if n, ok := args.Get(0).(*model.RedeemVoucherResp); ok {
+		resp = n
+	}
+
+	if n, ok := args.Get(1).(error); ok {
+		err = n
+	}
+
+	return resp, err
+}
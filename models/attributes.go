@@ -0,0 +1,28 @@
+package model
+
+const (
+	AttributeTypeString  = "string"
+	AttributeTypeNumber  = "number"
+	AttributeTypeBoolean = "boolean"
+	AttributeTypeEnum    = "enum"
+)
+
+// AttributeDefinition is one entry of a category attribute schema. Values
+// lists the allowed values of an enum attribute.
+type AttributeDefinition struct {
+	Name     string   `json:"name" validate:"required,max=64"`
+	Type     string   `json:"type" validate:"oneof=string number boolean enum"`
+	Required bool     `json:"required"`
+	Unit     string   `json:"unit,omitempty" validate:"max=16"`
+	Values   []string `json:"values,omitempty" validate:"dive,required"`
+}
+
+type GetCategoryAttributesReq struct {
+	CategoryId string `json:"category_id" validate:"uuid"`
+}
+
+type SetCategoryAttributesReq struct {
+	Role       string                 `json:"-"`
+	CategoryId string                 `json:"category_id" validate:"uuid"`
+	Attributes []*AttributeDefinition `json:"attributes" validate:"dive"`
+}
@@ -0,0 +1,74 @@
+package model
+
+type CacheNamespace struct {
+	Name      string `json:"name"`
+	Versioned bool   `json:"versioned"`
+	TTL       string `json:"ttl"`
+}
+
+type GetCacheNamespacesReq struct {
+	Role string `json:"-"`
+}
+
+type InspectCacheReq struct {
+	Role      string `json:"-"`
+	Namespace string `json:"namespace" validate:"required"`
+	Key       string `json:"key" validate:"required"`
+}
+
+// CacheEntry.TTL is the time left in redis. Value is empty for keys cached
+// as not found.
+type CacheEntry struct {
+	Namespace string      `json:"namespace"`
+	Key       string      `json:"key"`
+	RedisKey  string      `json:"redis_key"`
+	InL1      bool        `json:"in_l1"`
+	InL2      bool        `json:"in_l2"`
+	TTL       string      `json:"ttl"`
+	Size      int         `json:"size"`
+	NotFound  bool        `json:"not_found"`
+	Value     interface{} `json:"value"`
+}
+
+// PurgeCacheReq purges one of a product, the products of a shop or a whole
+// namespace, the route sets which.
+type PurgeCacheReq struct {
+	Role      string `json:"-"`
+	ProductId string `json:"product_id" validate:"omitempty,uuid"`
+	ShopId    string `json:"shop_id" validate:"omitempty,uuid"`
+	Namespace string `json:"namespace"`
+}
+
+type PurgeCacheResp struct {
+	Products   int      `json:"products"`
+	Namespaces []string `json:"namespaces"`
+}
+
+// WarmCacheReq.Products is how many of the best selling products to load,
+// Pages how many listing pages of Limit products.
+type WarmCacheReq struct {
+	Role     string `json:"-"`
+	Products int    `json:"products" validate:"min=0,max=1000"`
+	Pages    int    `json:"pages" validate:"min=0,max=50"`
+	Limit    int    `json:"limit" validate:"min=0,max=100"`
+}
+
+func (w *WarmCacheReq) SetDefault() {
+	if w.Products < 1 {
+		w.Products = 100
+	}
+
+	if w.Pages < 1 {
+		w.Pages = 3
+	}
+
+	if w.Limit < 1 {
+		w.Limit = 10
+	}
+}
+
+type WarmCacheResp struct {
+	Products int `json:"products"`
+	Pages    int `json:"pages"`
+	Failed   int `json:"failed"`
+}
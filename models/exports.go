@@ -0,0 +1,40 @@
+package model
+
+import "time"
+
+const (
+	ExportFormatCSV    = "csv"
+	ExportFormatNDJSON = "ndjson"
+	ExportFormatXLSX   = "xlsx"
+)
+
+// ExportProductsReq takes the filters of the product lists, Attributes
+// holds attr.<name>=<value> filters.
+type ExportProductsReq struct {
+	UserId     string            `json:"user_id" validate:"uuid"`
+	Role       string            `json:"-"`
+	ShopId     string            `json:"shop_id" validate:"uuid"`
+	Format     string            `json:"format" validate:"oneof=csv ndjson xlsx"`
+	Status     string            `json:"status" validate:"omitempty,oneof=draft published archived scheduled pending_review rejected"`
+	Attributes map[string]string `json:"attributes"`
+}
+
+// ExportProductRow uses the import column names, so an export can be edited
+// and imported back.
+type ExportProductRow struct {
+	Id           string                 `json:"id"`
+	Sku          *string                `json:"sku"`
+	Name         string                 `json:"name"`
+	Description  string                 `json:"description"`
+	CategoryId   string                 `json:"category_id"`
+	CategoryName string                 `json:"category_name"`
+	Status       string                 `json:"status"`
+	Price        float64                `json:"price"`
+	SalePrice    *float64               `json:"sale_price"`
+	Stock        int64                  `json:"stock"`
+	ImageUrl     string                 `json:"image_url"`
+	Attributes   map[string]interface{} `json:"attributes"`
+	PublishAt    *time.Time             `json:"publish_at"`
+	CreatedAt    time.Time              `json:"created_at"`
+	UpdatedAt    time.Time              `json:"updated_at"`
+}
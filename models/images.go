@@ -0,0 +1,30 @@
+package model
+
+type ProductImage struct {
+	Id        string `json:"id"`
+	Url       string `json:"url"`
+	AltText   string `json:"alt_text"`
+	Position  int    `json:"position"`
+	IsPrimary bool   `json:"is_primary"`
+}
+
+type AddProductImageReq struct {
+	UserId    string `json:"user_id" validate:"uuid"`
+	ProductId string `json:"product_id" validate:"uuid"`
+	Url       string `json:"url" validate:"required,url"`
+	AltText   string `json:"alt_text" validate:"max=255"`
+	IsPrimary bool   `json:"is_primary"`
+}
+
+type ReorderProductImagesReq struct {
+	UserId         string   `json:"user_id" validate:"uuid"`
+	ProductId      string   `json:"product_id" validate:"uuid"`
+	ImageIds       []string `json:"image_ids" validate:"required,min=1,dive,uuid"`
+	PrimaryImageId *string  `json:"primary_image_id" validate:"omitempty,uuid"`
+}
+
+type DeleteProductImageReq struct {
+	UserId    string `json:"user_id" validate:"uuid"`
+	ProductId string `json:"product_id" validate:"uuid"`
+	Id        string `json:"id" validate:"uuid"`
+}
@@ -0,0 +1,84 @@
+package model
+
+import "time"
+
+const (
+	ImportFormatCSV    = "csv"
+	ImportFormatNDJSON = "ndjson"
+
+	ImportStatusPending   = "pending"
+	ImportStatusRunning   = "running"
+	ImportStatusCompleted = "completed"
+	ImportStatusFailed    = "failed"
+)
+
+// ImportProductRow is one product of an import file, matched to existing
+// products of the shop by SKU. Stock, image and status are only used when
+// the row creates a product, existing products keep theirs.
+type ImportProductRow struct {
+	Row         int                    `json:"-"`
+	Sku         string                 `json:"sku" validate:"required,max=64"`
+	Name        string                 `json:"name" validate:"required,max=255"`
+	Description string                 `json:"description"`
+	CategoryId  string                 `json:"category_id" validate:"uuid"`
+	Price       float64                `json:"price" validate:"gt=0"`
+	Stock       int64                  `json:"stock" validate:"gte=0"`
+	ImageUrl    string                 `json:"image_url" validate:"omitempty,url"`
+	Status      string                 `json:"status" validate:"omitempty,oneof=draft published"`
+	Attributes  map[string]interface{} `json:"attributes"`
+
+	// set by the usecase from the moderation settings
+	NeedsReview  bool     `json:"-"`
+	FlaggedWords []string `json:"-"`
+}
+
+type ImportRowError struct {
+	Row     int    `json:"row"`
+	Sku     string `json:"sku"`
+	Message string `json:"message"`
+}
+
+type ProductImport struct {
+	Id            string            `json:"id"`
+	ShopId        string            `json:"shop_id"`
+	UserId        string            `json:"-"`
+	Format        string            `json:"format"`
+	Status        string            `json:"status"`
+	TotalRows     int               `json:"total_rows"`
+	ProcessedRows int               `json:"processed_rows"`
+	CreatedCount  int               `json:"created_count"`
+	UpdatedCount  int               `json:"updated_count"`
+	FailedCount   int               `json:"failed_count"`
+	Errors        []*ImportRowError `json:"errors"`
+	Error         *string           `json:"error"`
+	CreatedAt     time.Time         `json:"created_at"`
+	StartedAt     *time.Time        `json:"started_at"`
+	FinishedAt    *time.Time        `json:"finished_at"`
+}
+
+type CreateImportReq struct {
+	UserId string `json:"user_id" validate:"uuid"`
+	ShopId string `json:"shop_id" validate:"uuid"`
+	Format string `json:"format" validate:"oneof=csv ndjson"`
+	Data   []byte `json:"-" validate:"required"`
+}
+
+type GetImportReq struct {
+	UserId string `json:"user_id" validate:"uuid"`
+	Id     string `json:"id" validate:"uuid"`
+}
+
+type UpsertProductsReq struct {
+	ShopId string
+	UserId string
+	Rows   []*ImportProductRow
+}
+
+// ImportRowResult.Error is set when the row was rolled back.
+type ImportRowResult struct {
+	Row       int
+	Sku       string
+	ProductId string
+	Created   bool
+	Error     string
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	StockMovementReceipt     = "receipt"
+	StockMovementSale        = "sale"
+	StockMovementAdjustment  = "adjustment"
+	StockMovementReturn      = "return"
+	StockMovementReservation = "reservation"
+)
+
+type Warehouse struct {
+	Id        string    `json:"id"`
+	ShopId    string    `json:"shop_id"`
+	Name      string    `json:"name"`
+	IsDefault bool      `json:"is_default"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateWarehouseReq struct {
+	UserId string `json:"user_id" validate:"uuid"`
+	ShopId string `json:"shop_id" validate:"uuid"`
+	Name   string `json:"name" validate:"required,max=255"`
+}
+
+type GetWarehousesReq struct {
+	UserId string `json:"user_id" validate:"uuid"`
+	ShopId string `json:"shop_id" validate:"uuid"`
+}
+
+// StockMovement is one entry of the append-only stock ledger. Quantity is
+// signed: receipts and returns add stock, sales and reservations take it.
+type StockMovement struct {
+	Id          string    `json:"id"`
+	ProductId   string    `json:"product_id"`
+	WarehouseId string    `json:"warehouse_id"`
+	VariantId   *string   `json:"variant_id"`
+	Type        string    `json:"type"`
+	Quantity    int64     `json:"quantity"`
+	Reason      string    `json:"reason"`
+	Reference   *string   `json:"reference"`
+	CreatedBy   *string   `json:"created_by"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+type CreateStockAdjustmentReq struct {
+	UserId      string  `json:"user_id" validate:"uuid"`
+	ProductId   string  `json:"product_id" validate:"uuid"`
+	WarehouseId *string `json:"warehouse_id" validate:"omitempty,uuid"`
+	VariantId   *string `json:"variant_id" validate:"omitempty,uuid"`
+	Type        string  `json:"type" validate:"required,oneof=receipt adjustment"`
+	Quantity    int64   `json:"quantity" validate:"required"`
+	Reason      string  `json:"reason" validate:"required"`
+	Reference   *string `json:"reference" validate:"omitempty,max=255"`
+}
+
+type StockAdjustmentResp struct {
+	Movement       *StockMovement `json:"movement"`
+	WarehouseStock int64          `json:"warehouse_stock"`
+	Stock          int64          `json:"stock"`
+}
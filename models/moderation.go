@@ -0,0 +1,76 @@
+package model
+
+import "time"
+
+const (
+	ReviewStatusPending   = "pending"
+	ReviewStatusApproved  = "approved"
+	ReviewStatusRejected  = "rejected"
+	ReviewStatusCancelled = "cancelled"
+)
+
+// ReviewRequest asks for a product to be reviewed before it becomes
+// TargetStatus. It is set by the usecase, never by the client.
+type ReviewRequest struct {
+	TargetStatus string
+	PublishAt    *time.Time
+	FlaggedWords []string
+}
+
+type ProductReview struct {
+	Id           string     `json:"id"`
+	ProductId    string     `json:"product_id"`
+	ProductName  string     `json:"product_name"`
+	Description  string     `json:"description"`
+	ShopId       string     `json:"shop_id"`
+	ShopName     string     `json:"shop_name"`
+	SellerId     string     `json:"-"`
+	Status       string     `json:"status"`
+	TargetStatus string     `json:"target_status"`
+	PublishAt    *time.Time `json:"publish_at"`
+	FlaggedWords []string   `json:"flagged_words"`
+	Reason       *string    `json:"reason"`
+	CreatedAt    time.Time  `json:"created_at"`
+	ReviewedAt   *time.Time `json:"reviewed_at"`
+}
+
+type GetModerationQueueReq struct {
+	Role    string `json:"-"`
+	Flagged bool   `json:"flagged"`
+	Page    int    `json:"page"`
+	Limit   int    `json:"limit"`
+}
+
+func (g *GetModerationQueueReq) SetDefault() {
+	if g.Page < 1 {
+		g.Page = 1
+	}
+
+	if g.Limit < 1 {
+		g.Limit = 10
+	}
+}
+
+type GetModerationQueueResp struct {
+	Items []*ProductReview `json:"items"`
+	Meta  *Meta            `json:"meta"`
+}
+
+// ReviewDecisionReq.Status is set from the route, approve or reject.
+type ReviewDecisionReq struct {
+	UserId string `json:"user_id" validate:"uuid"`
+	Role   string `json:"-"`
+	Id     string `json:"id" validate:"uuid"`
+	Status string `json:"-"`
+	Reason string `json:"reason" validate:"max=1000"`
+}
+
+// ModerationNotification is sent to the notification service once a review
+// is decided.
+type ModerationNotification struct {
+	UserId      string  `json:"user_id"`
+	ProductId   string  `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	Decision    string  `json:"decision"`
+	Reason      *string `json:"reason"`
+}
@@ -1,9 +1,22 @@
 package model
 
 import (
+	"time"
+
 	"github.com/google/uuid"
 )
 
+const (
+	ProductStatusDraft     = "draft"
+	ProductStatusPublished = "published"
+	ProductStatusArchived  = "archived"
+	ProductStatusScheduled = "scheduled"
+
+	// set by moderation only, sellers cannot pick them
+	ProductStatusPendingReview = "pending_review"
+	ProductStatusRejected      = "rejected"
+)
+
 type Shop struct {
 	Id   uuid.UUID `json:"id"`
 	Name string    `json:"name"`
@@ -13,26 +26,108 @@ type GetProductReq struct {
 	Id string `json:"id" validate:"uuid"`
 }
 
+// GetProductResp.Price is what a buyer pays right now, which is the sale
+// price while a promotion is running and the original price otherwise.
 type GetProductResp struct {
-	Id           string  `json:"id"`
-	ShopId       string  `json:"shop_id"`
-	CategoryId   string  `json:"category_id"`
-	ShopName     string  `json:"shop_name"`
-	CategoryName string  `json:"category_name"`
-	Name         string  `json:"name"`
-	Price        float64 `json:"price"`
-	Stock        int64   `json:"stock"`
-	ImageUrl     string  `json:"image_url"`
+	Id            string     `json:"id"`
+	ShopId        string     `json:"shop_id"`
+	CategoryId    string     `json:"category_id"`
+	ShopName      string     `json:"shop_name"`
+	CategoryName  string     `json:"category_name"`
+	Sku           *string    `json:"sku"`
+	Name          string     `json:"name"`
+	Description   string     `json:"description"`
+	Price         float64    `json:"price"`
+	OriginalPrice float64    `json:"original_price"`
+	SalePrice     *float64   `json:"sale_price"`
+	SaleEndsAt    *time.Time `json:"sale_ends_at"`
+	Stock         int64      `json:"stock"`
+	ImageUrl      string     `json:"image_url"`
+	Status        string     `json:"status"`
+	PublishAt     *time.Time `json:"publish_at"`
+
+	// NextSaleStartsAt is when the next promotion starts, it only bounds how
+	// long the product is cached
+	NextSaleStartsAt *time.Time `json:"-"`
+
+	Attributes map[string]interface{} `json:"attributes"`
+
+	Images   []*ProductImage   `json:"images"`
+	Options  []*ProductOption  `json:"options"`
+	Variants []*ProductVariant `json:"variants"`
+}
+
+// ApplySale fills the effective price from the original price and the
+// running promotion, if any.
+func (p *GetProductResp) ApplySale() {
+	p.Price = p.OriginalPrice
+	if p.SalePrice != nil {
+		p.Price = *p.SalePrice
+	}
 }
 
 type CreateProductReq struct {
-	UserId     string  `json:"user_id" validate:"uuid"`
-	ShopId     string  `json:"shop_id" validate:"uuid"`
-	CategoryId string  `json:"category_id" validate:"uuid"`
-	Name       string  `json:"name" validate:"required"`
-	Price      float64 `json:"price" validate:"required"`
-	Stock      int64   `json:"stock" validate:"required"`
-	ImageUrl   string  `json:"image_url" validate:"required"`
+	UserId      string                 `json:"user_id" validate:"uuid"`
+	ShopId      string                 `json:"shop_id" validate:"uuid"`
+	CategoryId  string                 `json:"category_id" validate:"uuid"`
+	Sku         *string                `json:"sku" validate:"omitempty,min=1,max=64"`
+	Name        string                 `json:"name" validate:"required"`
+	Description string                 `json:"description"`
+	Price       float64                `json:"price" validate:"required"`
+	Stock       int64                  `json:"stock" validate:"required"`
+	ImageUrl    string                 `json:"image_url" validate:"required"`
+	Attributes  map[string]interface{} `json:"attributes"`
+	Status      string                 `json:"status" validate:"omitempty,oneof=draft published scheduled"`
+	PublishAt   *time.Time             `json:"publish_at"`
+
+	Review *ReviewRequest `json:"-"`
+}
+
+// SetProductStatusReq.PublishAt is only used, and required, when the status
+// is scheduled.
+type SetProductStatusReq struct {
+	UserId    string     `json:"user_id" validate:"uuid"`
+	Id        string     `json:"id" validate:"uuid"`
+	Status    string     `json:"status" validate:"oneof=draft published archived scheduled"`
+	PublishAt *time.Time `json:"publish_at"`
+
+	Review *ReviewRequest `json:"-"`
+}
+
+// GetShopProductsReq lists every product of a shop whatever its status, for
+// the shop owner only.
+type GetShopProductsReq struct {
+	UserId string `json:"user_id" validate:"uuid"`
+	ShopId string `json:"shop_id" validate:"uuid"`
+	Status string `json:"status" validate:"omitempty,oneof=draft published archived scheduled pending_review rejected"`
+	Page   int    `json:"page"`
+	Limit  int    `json:"limit"`
+}
+
+func (g *GetShopProductsReq) SetDefault() {
+	if g.Page < 1 {
+		g.Page = 1
+	}
+
+	if g.Limit < 1 {
+		g.Limit = 10
+	}
+}
+
+// UpdateProductReq replaces the editable fields of a product, a missing sku
+// keeps the current one. Stock, images, options and variants have their own
+// endpoints.
+type UpdateProductReq struct {
+	UserId      string                 `json:"user_id" validate:"uuid"`
+	Id          string                 `json:"id" validate:"uuid"`
+	CategoryId  string                 `json:"category_id" validate:"uuid"`
+	Sku         *string                `json:"sku" validate:"omitempty,min=1,max=64"`
+	Name        string                 `json:"name" validate:"required"`
+	Description string                 `json:"description"`
+	Price       float64                `json:"price" validate:"required"`
+	Attributes  map[string]interface{} `json:"attributes"`
+
+	Review *ReviewRequest `json:"-"`
 }
 
 type DeleteProductReq struct {
@@ -40,9 +135,12 @@ type DeleteProductReq struct {
 	Id     string `json:"id" validate:"uuid"`
 }
 
+// GetProductsReq.Attributes holds attribute filters, a product matches when
+// every listed attribute equals the given value.
 type GetProductsReq struct {
-	Page  int `json:"page"`
-	Limit int `json:"limit"`
+	Page       int               `json:"page"`
+	Limit      int               `json:"limit"`
+	Attributes map[string]string `json:"attributes"`
 }
 
 func (g *GetProductsReq) SetDefault() {
@@ -61,9 +159,36 @@ type GetProductsResp struct {
 }
 
 type ProductItem struct {
-	Id       string  `json:"id"`
-	Name     string  `json:"name"`
-	Price    float64 `json:"price"`
-	Stock    int64   `json:"stock"`
-	ImageUrl string  `json:"image_url"`
+	Id            string     `json:"id"`
+	Sku           *string    `json:"sku"`
+	Name          string     `json:"name"`
+	Price         float64    `json:"price"`
+	OriginalPrice float64    `json:"original_price"`
+	SalePrice     *float64   `json:"sale_price"`
+	SaleEndsAt    *time.Time `json:"sale_ends_at"`
+	Stock         int64      `json:"stock"`
+	ImageUrl      string     `json:"image_url"`
+	Status        string     `json:"status"`
+	PublishAt     *time.Time `json:"publish_at"`
+
+	// NextSaleStartsAt is when the next promotion starts, it only bounds how
+	// long the product is cached
+	NextSaleStartsAt *time.Time `json:"-"`
+}
+
+// ApplySale fills the effective price from the original price and the
+// running promotion, if any.
+func (p *ProductItem) ApplySale() {
+	p.Price = p.OriginalPrice
+	if p.SalePrice != nil {
+		p.Price = *p.SalePrice
+	}
+}
+
+// RestoreProductReq.DeletedAfter is the start of the restore window, set by
+// the usecase.
+type RestoreProductReq struct {
+	UserId       string    `json:"user_id" validate:"uuid"`
+	Id           string    `json:"id" validate:"uuid"`
+	DeletedAfter time.Time `json:"-"`
 }
@@ -0,0 +1,53 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	PromotionTypeSale      = "sale"
+	PromotionTypeFlashSale = "flash_sale"
+)
+
+type Promotion struct {
+	Id        string    `json:"id"`
+	ProductId string    `json:"product_id"`
+	Type      string    `json:"type"`
+	SalePrice float64   `json:"sale_price"`
+	Quantity  *int64    `json:"quantity"`
+	Claimed   int64     `json:"claimed"`
+	StartsAt  time.Time `json:"starts_at"`
+	EndsAt    time.Time `json:"ends_at"`
+}
+
+type CreatePromotionReq struct {
+	UserId    string    `json:"user_id" validate:"uuid"`
+	ProductId string    `json:"product_id" validate:"uuid"`
+	Type      string    `json:"type" validate:"required,oneof=sale flash_sale"`
+	SalePrice float64   `json:"sale_price" validate:"gte=0"`
+	Quantity  *int64    `json:"quantity" validate:"omitempty,min=1"`
+	StartsAt  time.Time `json:"starts_at" validate:"required"`
+	EndsAt    time.Time `json:"ends_at" validate:"required,gtfield=StartsAt"`
+}
+
+// ClaimFlashSaleReq.Reference is the checkout the claimed units are held for,
+// ExpiresAt is set by the usecase.
+type ClaimFlashSaleReq struct {
+	UserId      string    `json:"user_id" validate:"uuid"`
+	PromotionId string    `json:"promotion_id" validate:"uuid"`
+	VariantId   *string   `json:"variant_id" validate:"omitempty,uuid"`
+	Reference   string    `json:"reference" validate:"required,max=255"`
+	Quantity    int64     `json:"quantity" validate:"required,min=1"`
+	ExpiresAt   time.Time `json:"-"`
+}
+
+// ClaimFlashSaleResp.Reservation holds the claimed units until the checkout
+// is paid, the claim is given back when it is released.
+type ClaimFlashSaleResp struct {
+	PromotionId string       `json:"promotion_id"`
+	ProductId   string       `json:"product_id"`
+	Quantity    int64        `json:"quantity"`
+	SalePrice   float64      `json:"sale_price"`
+	Remaining   int64        `json:"remaining"`
+	Reservation *Reservation `json:"reservation"`
+}
@@ -0,0 +1,68 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	RefundStatusPending   = "pending"
+	RefundStatusSucceeded = "succeeded"
+	RefundStatusFailed    = "failed"
+)
+
+// Refund pays back units of a paid reservation, OrderId is the reference the
+// checkout reserved with. A pending refund holds its units until the payment
+// provider answers, a failed one gives them back for another refund.
+type Refund struct {
+	Id                string        `json:"id"`
+	ReservationId     string        `json:"reservation_id"`
+	OrderId           string        `json:"order_id"`
+	Status            string        `json:"status"`
+	Amount            float64       `json:"amount"`
+	Reason            string        `json:"reason"`
+	Restock           bool          `json:"restock"`
+	ProviderReference *string       `json:"provider_reference"`
+	FailureReason     *string       `json:"failure_reason"`
+	CreatedBy         string        `json:"created_by"`
+	CreatedAt         time.Time     `json:"created_at"`
+	Items             []*RefundItem `json:"items"`
+}
+
+// RefundItem.Amount is Quantity times the UnitPrice the line was bought at.
+type RefundItem struct {
+	ProductId string  `json:"product_id"`
+	VariantId *string `json:"variant_id"`
+	Quantity  int64   `json:"quantity"`
+	UnitPrice float64 `json:"unit_price"`
+	Amount    float64 `json:"amount"`
+}
+
+type RefundItemReq struct {
+	ProductId string  `json:"product_id" validate:"uuid"`
+	VariantId *string `json:"variant_id" validate:"omitempty,uuid"`
+	Quantity  int64   `json:"quantity" validate:"required,min=1"`
+}
+
+// CreateRefundReq refunds the listed lines of a paid reservation, or every
+// unit not refunded yet when Items is empty. Restock puts the refunded units
+// back into stock once the refund succeeds. SellerId is set by the usecase
+// for sellers, who may only refund the lines of their own shops.
+type CreateRefundReq struct {
+	UserId        string           `json:"user_id" validate:"uuid"`
+	Role          string           `json:"-"`
+	ReservationId string           `json:"reservation_id" validate:"uuid"`
+	Reason        string           `json:"reason" validate:"required"`
+	Restock       bool             `json:"restock"`
+	Items         []*RefundItemReq `json:"items" validate:"dive"`
+	SellerId      string           `json:"-"`
+}
+
+// CompleteRefundReq records the payment provider's answer to a pending
+// refund, Status is either succeeded or failed.
+type CompleteRefundReq struct {
+	Id                string
+	UserId            string
+	Status            string
+	ProviderReference *string
+	FailureReason     *string
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	ReservationStatusActive    = "active"
+	ReservationStatusReleased  = "released"
+	ReservationStatusConfirmed = "confirmed"
+
+	// a confirmed reservation is the paid order, refunds move it on
+	ReservationStatusPartiallyRefunded = "partially_refunded"
+	ReservationStatusRefunded          = "refunded"
+)
+
+// Reservation.PromotionId is set on reservations holding flash-sale claims.
+type Reservation struct {
+	Id          string             `json:"id"`
+	UserId      string             `json:"user_id"`
+	Reference   string             `json:"reference"`
+	Status      string             `json:"status"`
+	PromotionId *string            `json:"promotion_id"`
+	ExpiresAt   time.Time          `json:"expires_at"`
+	Items       []*ReservationItem `json:"items"`
+}
+
+// Paid reports whether the reservation was confirmed, whether or not it was
+// refunded since.
+func (r *Reservation) Paid() bool {
+	switch r.Status {
+	case ReservationStatusConfirmed, ReservationStatusPartiallyRefunded, ReservationStatusRefunded:
+		return true
+	}
+	return false
+}
+
+// ReservationItem.UnitPrice is the price of one unit when it was reserved.
+type ReservationItem struct {
+	ProductId   string  `json:"product_id"`
+	VariantId   *string `json:"variant_id"`
+	WarehouseId string  `json:"warehouse_id"`
+	Quantity    int64   `json:"quantity"`
+	UnitPrice   float64 `json:"unit_price"`
+}
+
+type ReservationItemReq struct {
+	ProductId string  `json:"product_id" validate:"uuid"`
+	VariantId *string `json:"variant_id" validate:"omitempty,uuid"`
+	Quantity  int64   `json:"quantity" validate:"required,min=1"`
+}
+
+type CreateReservationReq struct {
+	UserId    string                `json:"user_id" validate:"uuid"`
+	Reference string                `json:"reference" validate:"required,max=255"`
+	Items     []*ReservationItemReq `json:"items" validate:"required,min=1,dive"`
+	ExpiresAt time.Time             `json:"-"`
+}
+
+type ReservationReq struct {
+	UserId string `json:"user_id" validate:"uuid"`
+	Role   string `json:"-"`
+	Id     string `json:"id" validate:"uuid"`
+}
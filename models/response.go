@@ -5,6 +5,24 @@ type Response struct {
 	Data    interface{} `json:"data"`
 }
 
+// Problem is an RFC 7807 error body, sent to clients that accept
+// application/problem+json.
+type Problem struct {
+	Type      string        `json:"type"`
+	Title     string        `json:"title"`
+	Status    int           `json:"status"`
+	Detail    string        `json:"detail,omitempty"`
+	Instance  string        `json:"instance,omitempty"`
+	RequestId string        `json:"request_id,omitempty"`
+	Errors    []*FieldError `json:"errors,omitempty"`
+}
+
+type FieldError struct {
+	Field   string `json:"field"`
+	Rule    string `json:"rule"`
+	Message string `json:"message"`
+}
+
 type Meta struct {
 	TotalData int `json:"total_data"`
 	TotalPage int `json:"total_page"`
@@ -0,0 +1,23 @@
+package model
+
+type UploadImageReq struct {
+	UserId   string `json:"user_id" validate:"uuid"`
+	Filename string `json:"filename"`
+	Data     []byte `json:"-" validate:"required"`
+}
+
+type UploadImageResp struct {
+	Url         string       `json:"url"`
+	ContentType string       `json:"content_type"`
+	Size        int64        `json:"size"`
+	Width       int          `json:"width"`
+	Height      int          `json:"height"`
+	Thumbnails  []*Thumbnail `json:"thumbnails"`
+}
+
+type Thumbnail struct {
+	Name   string `json:"name"`
+	Url    string `json:"url"`
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
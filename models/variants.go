@@ -0,0 +1,39 @@
+package model
+
+type ProductOption struct {
+	Id       string   `json:"id"`
+	Name     string   `json:"name"`
+	Position int      `json:"position"`
+	Values   []string `json:"values"`
+}
+
+// ProductVariant.Price is nil when the variant sells at the product price.
+type ProductVariant struct {
+	Id       string            `json:"id"`
+	Sku      string            `json:"sku"`
+	Options  map[string]string `json:"options"`
+	Price    *float64          `json:"price"`
+	Stock    int64             `json:"stock"`
+	ImageUrl *string           `json:"image_url"`
+}
+
+type ProductOptionReq struct {
+	Name   string   `json:"name" validate:"required,max=64"`
+	Values []string `json:"values" validate:"required,min=1,dive,required"`
+}
+
+type SetProductOptionsReq struct {
+	UserId    string              `json:"user_id" validate:"uuid"`
+	ProductId string              `json:"product_id" validate:"uuid"`
+	Options   []*ProductOptionReq `json:"options" validate:"dive"`
+}
+
+type CreateVariantReq struct {
+	UserId    string            `json:"user_id" validate:"uuid"`
+	ProductId string            `json:"product_id" validate:"uuid"`
+	Sku       string            `json:"sku" validate:"required,max=64"`
+	Options   map[string]string `json:"options" validate:"required,min=1"`
+	Price     *float64          `json:"price" validate:"omitempty,gte=0"`
+	Stock     int64             `json:"stock" validate:"gte=0"`
+	ImageUrl  *string           `json:"image_url" validate:"omitempty,url"`
+}
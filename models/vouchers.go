@@ -0,0 +1,88 @@
+package model
+
+import (
+	"time"
+)
+
+const (
+	VoucherTypePercentage = "percentage"
+	VoucherTypeFixed      = "fixed"
+)
+
+type Voucher struct {
+	Id                string    `json:"id"`
+	Code              string    `json:"code"`
+	ShopId            *string   `json:"shop_id"`
+	DiscountType      string    `json:"discount_type"`
+	DiscountValue     float64   `json:"discount_value"`
+	MinSpend          float64   `json:"min_spend"`
+	MaxDiscount       *float64  `json:"max_discount"`
+	UsageLimit        *int64    `json:"usage_limit"`
+	UsageLimitPerUser *int64    `json:"usage_limit_per_user"`
+	UsedCount         int64     `json:"used_count"`
+	CategoryIds       []string  `json:"category_ids"`
+	ProductIds        []string  `json:"product_ids"`
+	StartsAt          time.Time `json:"starts_at"`
+	EndsAt            time.Time `json:"ends_at"`
+}
+
+type CreateVoucherReq struct {
+	UserId            string    `json:"user_id" validate:"uuid"`
+	Role              string    `json:"-"`
+	Code              string    `json:"code" validate:"required,max=64"`
+	ShopId            *string   `json:"shop_id" validate:"omitempty,uuid"`
+	DiscountType      string    `json:"discount_type" validate:"required,oneof=percentage fixed"`
+	DiscountValue     float64   `json:"discount_value" validate:"required,gt=0"`
+	MinSpend          float64   `json:"min_spend" validate:"gte=0"`
+	MaxDiscount       *float64  `json:"max_discount" validate:"omitempty,gt=0"`
+	UsageLimit        *int64    `json:"usage_limit" validate:"omitempty,min=1"`
+	UsageLimitPerUser *int64    `json:"usage_limit_per_user" validate:"omitempty,min=1"`
+	CategoryIds       []string  `json:"category_ids" validate:"dive,uuid"`
+	ProductIds        []string  `json:"product_ids" validate:"dive,uuid"`
+	StartsAt          time.Time `json:"starts_at" validate:"required"`
+	EndsAt            time.Time `json:"ends_at" validate:"required,gtfield=StartsAt"`
+}
+
+type VoucherItemReq struct {
+	ProductId string  `json:"product_id" validate:"uuid"`
+	VariantId *string `json:"variant_id" validate:"omitempty,uuid"`
+	Quantity  int64   `json:"quantity" validate:"required,min=1"`
+}
+
+type ApplyVoucherReq struct {
+	UserId string            `json:"user_id" validate:"uuid"`
+	Code   string            `json:"code" validate:"required"`
+	Items  []*VoucherItemReq `json:"items" validate:"required,min=1,dive"`
+}
+
+type RedeemVoucherReq struct {
+	UserId  string            `json:"user_id" validate:"uuid"`
+	OrderId string            `json:"order_id" validate:"required"`
+	Code    string            `json:"code" validate:"required"`
+	Items   []*VoucherItemReq `json:"items" validate:"required,min=1,dive"`
+}
+
+// VoucherProduct is the part of a product a voucher needs to decide whether
+// the product is in scope and how much it contributes to the spend. Price is
+// what the buyer pays for one unit of the product or its variant.
+type VoucherProduct struct {
+	Id         string
+	VariantId  *string
+	ShopId     string
+	CategoryId string
+	Price      float64
+}
+
+type ApplyVoucherResp struct {
+	VoucherId        string  `json:"voucher_id"`
+	Code             string  `json:"code"`
+	EligibleSubtotal float64 `json:"eligible_subtotal"`
+	DiscountAmount   float64 `json:"discount_amount"`
+}
+
+type RedeemVoucherResp struct {
+	RedemptionId   string  `json:"redemption_id"`
+	VoucherId      string  `json:"voucher_id"`
+	OrderId        string  `json:"order_id"`
+	DiscountAmount float64 `json:"discount_amount"`
+}
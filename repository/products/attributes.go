@@ -0,0 +1,99 @@
+package products
+
+import (
+	"codebase-service/helper"
+	model "codebase-service/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+)
+
+func (s *store) GetCategoryAttributes(ctx context.Context, categoryId string) ([]*model.AttributeDefinition, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var (
+		res  = make([]*model.AttributeDefinition, 0)
+		data []byte
+	)
+
+	query := `
+		SELECT
+			attribute_schema
+		FROM
+			product_categories
+		WHERE
+			id = ?
+			AND deleted_at IS NULL
+	`
+	query = helper.RebindQuery(query)
+
+	row := s.db.QueryRowContext(ctx, query, categoryId)
+	if err := row.Scan(&data); err != nil {
+		if err == sql.ErrNoRows {
+			s.logger.InfoContext(ctx, "no category found", "method", "GetCategoryAttributes")
+			return nil, ErrCategoryNotFound
+		}
+		s.logger.ErrorContext(ctx, "failed to fetch attribute schema", "method", "GetCategoryAttributes", "err", err)
+		return nil, err
+	}
+
+	if err := json.Unmarshal(data, &res); err != nil {
+		s.logger.ErrorContext(ctx, "failed to unmarshal attribute schema", "method", "GetCategoryAttributes", "err", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *store) SetCategoryAttributes(ctx context.Context, req *model.SetCategoryAttributesReq) ([]*model.AttributeDefinition, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	data, err := json.Marshal(req.Attributes)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to marshal attribute schema", "method", "SetCategoryAttributes", "err", err)
+		return nil, err
+	}
+
+	query := `
+		UPDATE
+			product_categories
+		SET
+			attribute_schema = ?,
+			updated_at = NOW()
+		WHERE
+			id = ?
+			AND deleted_at IS NULL
+	`
+	query = helper.RebindQuery(query)
+
+	result, err := s.db.ExecContext(ctx, query, data, req.CategoryId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to update attribute schema", "method", "SetCategoryAttributes", "err", err)
+		return nil, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to read affected rows", "method", "SetCategoryAttributes", "err", err)
+		return nil, err
+	}
+
+	if affected == 0 {
+		s.logger.InfoContext(ctx, "no category found", "method", "SetCategoryAttributes")
+		return nil, ErrCategoryNotFound
+	}
+
+	return req.Attributes, nil
+}
+
+// marshalAttributes turns product attributes into a JSONB value, a nil map
+// is stored as an empty object.
+func marshalAttributes(attributes map[string]interface{}) ([]byte, error) {
+	if attributes == nil {
+		attributes = map[string]interface{}{}
+	}
+
+	return json.Marshal(attributes)
+}
@@ -0,0 +1,146 @@
+package products
+
+import (
+	"codebase-service/helper"
+	model "codebase-service/models"
+	"codebase-service/util/cache"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/url"
+	"slices"
+	"time"
+)
+
+// the namespaces the store caches in, their TTLs can be set per namespace
+const (
+	productCacheNamespace  = "product"
+	productsCacheNamespace = "products"
+)
+
+// productsCacheKey appends the sorted attribute filters to the page key, so
+// the same filters in any order share an entry.
+func productsCacheKey(req *model.GetProductsReq) string {
+	key := fmt.Sprintf("page:%d:limit:%d", req.Page, req.Limit)
+	for _, name := range slices.Sorted(maps.Keys(req.Attributes)) {
+		key += fmt.Sprintf(":attr:%s=%s", url.QueryEscape(name), url.QueryEscape(req.Attributes[name]))
+	}
+
+	return key
+}
+
+func newProductCaches(client *cache.Client) (*cache.Cache[*model.GetProductResp], *cache.Cache[*model.GetProductsResp]) {
+	// list pages are versioned, most writes change some page and the pages
+	// of a product cannot be told apart
+	return cache.New[*model.GetProductResp](client, productCacheNamespace),
+		cache.NewVersioned[*model.GetProductsResp](client, productsCacheNamespace)
+}
+
+// evictProductCache drops the cached detail of a product. Every write to a
+// product, its stock, images, variants or promotions calls it after commit.
+func (s *store) evictProductCache(ctx context.Context, id string) {
+	s.products.Delete(ctx, id)
+}
+
+// evictProductsCache invalidates every cached product list page. Writes
+// that change what a list shows call it after commit.
+func (s *store) evictProductsCache(ctx context.Context) {
+	s.lists.Flush(ctx)
+}
+
+// PurgeProductCache drops the cached details of the given products and
+// every cached list page, for admins clearing entries by hand. It reports
+// the pending cache errors when redis is down, the purge then runs once it
+// is back.
+func (s *store) PurgeProductCache(ctx context.Context, ids ...string) error {
+	return errors.Join(s.products.Delete(ctx, ids...), s.lists.Flush(ctx))
+}
+
+// EvictShopCache drops the cached details of every product of a shop and
+// every cached list page. Closing a shop calls it after commit, so its
+// products leave the listings at once.
+func (s *store) EvictShopCache(ctx context.Context, shopId string) error {
+	ids, err := s.GetShopProductIds(ctx, shopId)
+	if err != nil {
+		return err
+	}
+
+	return s.PurgeProductCache(ctx, ids...)
+}
+
+// GetTopProductIds ranks published products by the units sold since the
+// given time, newest first among equals, to pick what to warm the cache with.
+func (s *store) GetTopProductIds(ctx context.Context, since time.Time, limit int) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT
+			p.id
+		FROM
+			products p
+		LEFT JOIN
+			stock_movements sm ON sm.product_id = p.id
+			AND sm.type = ?
+			AND sm.created_at >= ?
+		WHERE
+			p.deleted_at IS NULL
+			AND p.status = ?
+		GROUP BY
+			p.id
+		ORDER BY
+			COALESCE(SUM(-sm.quantity), 0) DESC,
+			p.created_at DESC
+		LIMIT ?
+	`
+	query = helper.RebindQuery(query)
+
+	return s.queryProductIds(ctx, "GetTopProductIds", query, model.StockMovementSale, since, model.ProductStatusPublished, limit)
+}
+
+// GetShopProductIds returns every product of a shop, deleted ones included
+// since they may still be cached as missing.
+func (s *store) GetShopProductIds(ctx context.Context, shopId string) ([]string, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	query := `
+		SELECT
+			id
+		FROM
+			products
+		WHERE
+			shop_id = ?
+	`
+	query = helper.RebindQuery(query)
+
+	return s.queryProductIds(ctx, "GetShopProductIds", query, shopId)
+}
+
+func (s *store) queryProductIds(ctx context.Context, method, query string, args ...interface{}) ([]string, error) {
+	var ids = make([]string, 0)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to fetch product ids", "method", method, "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			s.logger.ErrorContext(ctx, "failed to scan product id", "method", method, "err", err)
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to iterate product ids", "method", method, "err", err)
+		return nil, err
+	}
+
+	return ids, nil
+}
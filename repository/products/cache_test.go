@@ -0,0 +1,140 @@
+package products
+
+import (
+	model "codebase-service/models"
+	"codebase-service/util/cache"
+	"codebase-service/util/logging"
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestProductsCache(t *testing.T) {
+	suite.Run(t, new(ProductCacheTestSuite))
+}
+
+type ProductCacheTestSuite struct {
+	suite.Suite
+	ctx   context.Context
+	redis *miniredis.Miniredis
+	store *store
+}
+
+func (s *ProductCacheTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.redis = miniredis.RunT(s.T())
+	s.store = NewStore(nil, cache.NewClient(redis.NewClient(&redis.Options{Addr: s.redis.Addr()}), cache.Config{}), logging.Discard())
+}
+
+func (s *ProductCacheTestSuite) TestProductsCacheKey() {
+	req := &model.GetProductsReq{Page: 2, Limit: 10, Attributes: map[string]string{"size": "M", "color": "red blue"}}
+
+	s.Equal("page:2:limit:10:attr:color=red+blue:attr:size=M", productsCacheKey(req))
+	s.Equal("page:1:limit:10", productsCacheKey(&model.GetProductsReq{Page: 1, Limit: 10}))
+}
+
+func (s *ProductCacheTestSuite) TestGetProduct_ServedFromCache() {
+	s.NoError(s.store.products.Set(s.ctx, "p1", &model.GetProductResp{Id: "p1", Name: "Kemeja"}, time.Minute))
+
+	// the store has no db, a lookup past the cache would panic
+	resp, err := s.store.GetProduct(s.ctx, &model.GetProductReq{Id: "p1"})
+
+	s.NoError(err)
+	s.Equal("Kemeja", resp.Name)
+}
+
+func (s *ProductCacheTestSuite) TestGetProduct_NotFoundIsCached() {
+	_, err := s.store.products.Load(s.ctx, "p1", func(context.Context) (*model.GetProductResp, error) {
+		return nil, cache.ErrNotFound
+	}, nil)
+	s.ErrorIs(err, cache.ErrNotFound)
+
+	resp, err := s.store.GetProduct(s.ctx, &model.GetProductReq{Id: "p1"})
+
+	s.ErrorIs(err, ErrProductNotFound)
+	s.Nil(resp)
+}
+
+func (s *ProductCacheTestSuite) TestEvictProductCache() {
+	s.NoError(s.store.products.Set(s.ctx, "p1", &model.GetProductResp{Id: "p1"}, time.Minute))
+	s.NoError(s.store.products.Set(s.ctx, "p2", &model.GetProductResp{Id: "p2"}, time.Minute))
+
+	s.store.evictProductCache(s.ctx, "p1")
+
+	s.False(s.redis.Exists("cache:product:p1"))
+	s.True(s.redis.Exists("cache:product:p2"))
+}
+
+func (s *ProductCacheTestSuite) TestEvictProductsCache_HidesCachedPages() {
+	req := &model.GetProductsReq{Page: 1, Limit: 10}
+	page := &model.GetProductsResp{Items: []*model.ProductItem{{Id: "p1"}}}
+	s.NoError(s.store.lists.Set(s.ctx, productsCacheKey(req), page, time.Minute))
+
+	resp, err := s.store.GetProducts(s.ctx, req)
+	s.NoError(err)
+	s.Equal("p1", resp.Items[0].Id)
+
+	// a product is created
+	s.store.evictProductsCache(s.ctx)
+
+	_, err = s.store.lists.Get(s.ctx, productsCacheKey(req))
+	s.ErrorIs(err, cache.ErrMiss)
+	s.Equal("1", s.mustGet("cache:products:generation"))
+}
+
+func (s *ProductCacheTestSuite) TestPurgeProductCache() {
+	s.NoError(s.store.products.Set(s.ctx, "p1", &model.GetProductResp{Id: "p1"}, time.Minute))
+
+	s.NoError(s.store.PurgeProductCache(s.ctx, "p1"))
+
+	s.False(s.redis.Exists("cache:product:p1"))
+	s.Equal("1", s.mustGet("cache:products:generation"))
+}
+
+func (s *ProductCacheTestSuite) TestPurgeProductCache_PendingWhileRedisFails() {
+	s.redis.SetError("LOADING redis is loading")
+
+	err := s.store.PurgeProductCache(s.ctx, "p1")
+
+	s.ErrorIs(err, cache.ErrDeletePending)
+	s.ErrorIs(err, cache.ErrFlushPending)
+}
+
+func (s *ProductCacheTestSuite) TestEvictShopCache() {
+	db, mockDB, err := sqlmock.New()
+	s.Require().NoError(err)
+	defer db.Close()
+	s.store.db = db
+
+	req := &model.GetProductsReq{Page: 1, Limit: 10}
+	s.NoError(s.store.lists.Set(s.ctx, productsCacheKey(req), &model.GetProductsResp{}, time.Minute))
+	for _, id := range []string{"p1", "p2", "p3"} {
+		s.NoError(s.store.products.Set(s.ctx, id, &model.GetProductResp{Id: id}, time.Minute))
+	}
+
+	// the shop is closed, p3 belongs to another shop
+	mockDB.ExpectQuery(`SELECT\s+id\s+FROM\s+products\s+WHERE\s+shop_id = \$1`).
+		WithArgs("s1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("p1").AddRow("p2"))
+
+	s.NoError(s.store.EvictShopCache(s.ctx, "s1"))
+
+	s.NoError(mockDB.ExpectationsWereMet())
+	s.False(s.redis.Exists("cache:product:p1"))
+	s.False(s.redis.Exists("cache:product:p2"))
+	s.True(s.redis.Exists("cache:product:p3"))
+	s.Equal("1", s.mustGet("cache:products:generation"))
+	_, err = s.store.lists.Get(s.ctx, productsCacheKey(req))
+	s.ErrorIs(err, cache.ErrMiss)
+}
+
+func (s *ProductCacheTestSuite) mustGet(key string) string {
+	value, err := s.redis.Get(key)
+	s.NoError(err)
+	return value
+}
@@ -0,0 +1,41 @@
+package products
+
+import "codebase-service/util/apperror"
+
+// Errors callers may need to tell apart with errors.Is. Their kind decides
+// the status code the handlers answer with.
+var (
+	ErrProductNotFound       = apperror.New(apperror.NotFound, "product_not_found", "no product found")
+	ErrProductAlreadyDeleted = apperror.New(apperror.NotFound, "product_already_deleted", "product already deleted")
+	ErrNotShopOwner          = apperror.New(apperror.Forbidden, "not_shop_owner", "user is not shop owner")
+	ErrCategoryNotFound      = apperror.New(apperror.NotFound, "category_not_found", "no category found")
+	ErrWarehouseNotFound     = apperror.New(apperror.NotFound, "warehouse_not_found", "warehouse not found")
+	ErrImportNotFound        = apperror.New(apperror.NotFound, "import_not_found", "import not found")
+	ErrReviewNotFound        = apperror.New(apperror.NotFound, "review_not_found", "review not found")
+	ErrImageNotFound         = apperror.New(apperror.NotFound, "image_not_found", "image not found")
+	ErrPromotionNotFound     = apperror.New(apperror.NotFound, "promotion_not_found", "promotion not found")
+	ErrReservationNotFound   = apperror.New(apperror.NotFound, "reservation_not_found", "reservation not found")
+	ErrVariantNotFound       = apperror.New(apperror.NotFound, "variant_not_found", "variant not found")
+	ErrRefundNotFound        = apperror.New(apperror.NotFound, "refund_not_found", "refund not found")
+	ErrOrderLineNotFound     = apperror.New(apperror.NotFound, "order_line_not_found", "product is not part of the order")
+	ErrSkuExists             = apperror.New(apperror.Conflict, "sku_exists", "sku already exists")
+	ErrVariantExists         = apperror.New(apperror.Conflict, "variant_exists", "variant already exists")
+	ErrReviewDecided         = apperror.New(apperror.Conflict, "review_decided", "review already decided")
+	ErrProductNotDeleted     = apperror.New(apperror.Conflict, "product_not_deleted", "product is not deleted")
+	ErrPromotionOverlaps     = apperror.New(apperror.Conflict, "promotion_overlaps", "promotion overlaps an existing promotion")
+	ErrFlashSaleSoldOut      = apperror.New(apperror.Conflict, "flash_sale_sold_out", "flash sale sold out")
+	ErrReservationNotActive  = apperror.New(apperror.Conflict, "reservation_not_active", "reservation is no longer active")
+	ErrReservationConfirmed  = apperror.New(apperror.Conflict, "reservation_confirmed", "reservation is already confirmed")
+	ErrReservationNotPaid    = apperror.New(apperror.Conflict, "reservation_not_paid", "reservation is not paid")
+	ErrReservationRefunded   = apperror.New(apperror.Conflict, "reservation_refunded", "reservation is already fully refunded")
+	ErrNothingToRefund       = apperror.New(apperror.Conflict, "nothing_to_refund", "every unit of the order is already refunded")
+	ErrOptionsLocked         = apperror.New(apperror.Conflict, "options_locked", "options cannot be changed while the product has variants")
+	ErrRestoreWindowExpired  = apperror.New(apperror.Gone, "restore_window_expired", "restore window has expired")
+	ErrImageOrder            = apperror.New(apperror.Validation, "image_order", "image ids must list every image of the product exactly once")
+	ErrOptionNamesNotUnique  = apperror.New(apperror.Validation, "option_names_not_unique", "option names must be unique")
+	ErrInsufficientStock     = apperror.New(apperror.Unprocessable, "insufficient_stock", "insufficient stock")
+	ErrSalePriceTooHigh      = apperror.New(apperror.Unprocessable, "sale_price_too_high", "sale price must be lower than the original price")
+	ErrFlashSaleNotActive    = apperror.New(apperror.Unprocessable, "flash_sale_not_active", "flash sale is not active")
+	ErrVariantRequired       = apperror.New(apperror.Unprocessable, "variant_required", "variant is required")
+	ErrRefundExceedsOrder    = apperror.New(apperror.Unprocessable, "refund_exceeds_order", "refund quantity exceeds the units bought and not yet refunded")
+)
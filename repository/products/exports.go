@@ -0,0 +1,160 @@
+package products
+
+import (
+	"codebase-service/helper"
+	model "codebase-service/models"
+	"context"
+	"encoding/json"
+	"fmt"
+)
+
+// exportFetchSize is how many rows are fetched from the export cursor at a
+// time, it bounds the memory an export uses whatever the catalog size.
+const exportFetchSize = 500
+
+// ExportProducts streams the products of a shop matching req to fn, reading
+// them through a server-side cursor. It stops at the first error fn returns.
+func (s *store) ExportProducts(ctx context.Context, req *model.ExportProductsReq, fn func(row *model.ExportProductRow) error) error {
+	var (
+		args   = []interface{}{req.ShopId}
+		filter string
+	)
+
+	if req.Status != "" {
+		filter = " AND p.status = ?"
+		args = append(args, req.Status)
+	}
+
+	attributeFilter, attributeArgs := attributeFilters(req.Attributes)
+	filter += attributeFilter
+	args = append(args, attributeArgs...)
+
+	// cursors only live inside a transaction, a read only one also keeps the
+	// export consistent while the catalog changes
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "method", "ExportProducts", "err", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.ExecContext(ctx, `SET TRANSACTION ISOLATION LEVEL REPEATABLE READ READ ONLY`); err != nil {
+		s.logger.ErrorContext(ctx, "failed to set transaction mode", "method", "ExportProducts", "err", err)
+		return err
+	}
+
+	query := fmt.Sprintf(`
+		DECLARE product_export NO SCROLL CURSOR FOR
+		SELECT
+			p.id,
+			p.sku,
+			p.name,
+			COALESCE(p.description, '') AS description,
+			p.category_id,
+			pc.name AS category_name,
+			p.status,
+			p.price,
+			promo.sale_price,
+			COALESCE((SELECT SUM(ws.quantity) FROM warehouse_stocks ws WHERE ws.product_id = p.id AND (ws.variant_id IS NOT NULL OR NOT EXISTS (SELECT 1 FROM product_variants pv WHERE pv.product_id = p.id AND pv.deleted_at IS NULL))), 0) AS stock,
+			COALESCE((SELECT pi.url FROM product_images pi WHERE pi.product_id = p.id AND pi.is_primary), '') AS image_url,
+			p.attributes,
+			p.publish_at,
+			p.created_at,
+			p.updated_at
+		FROM
+			products p
+		JOIN
+			product_categories pc ON pc.id = p.category_id
+		LEFT JOIN LATERAL (
+			SELECT
+				pp.sale_price
+			FROM
+				product_promotions pp
+			WHERE
+				pp.product_id = p.id
+				AND pp.deleted_at IS NULL
+				AND pp.starts_at <= NOW()
+				AND pp.ends_at > NOW()
+				AND (pp.quantity IS NULL OR pp.claimed < pp.quantity)
+			LIMIT 1
+		) promo ON true
+		WHERE
+			p.shop_id = ?
+			AND p.deleted_at IS NULL
+			%s
+		ORDER BY
+			p.created_at, p.id
+	`, filter)
+	query = helper.RebindQuery(query)
+
+	if _, err := tx.ExecContext(ctx, query, args...); err != nil {
+		s.logger.ErrorContext(ctx, "failed to declare cursor", "method", "ExportProducts", "err", err)
+		return err
+	}
+
+	for {
+		fetched, err := s.fetchExportRows(ctx, tx, fn)
+		if err != nil {
+			return err
+		}
+
+		if fetched < exportFetchSize {
+			return nil
+		}
+	}
+}
+
+func (s *store) fetchExportRows(ctx context.Context, tx queryer, fn func(row *model.ExportProductRow) error) (int, error) {
+	rows, err := tx.QueryContext(ctx, fmt.Sprintf(`FETCH %d FROM product_export`, exportFetchSize))
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to fetch from cursor", "method", "ExportProducts", "err", err)
+		return 0, err
+	}
+	defer rows.Close()
+
+	var fetched int
+	for rows.Next() {
+		var (
+			d          model.ExportProductRow
+			attributes []byte
+		)
+
+		if err := rows.Scan(
+			&d.Id,
+			&d.Sku,
+			&d.Name,
+			&d.Description,
+			&d.CategoryId,
+			&d.CategoryName,
+			&d.Status,
+			&d.Price,
+			&d.SalePrice,
+			&d.Stock,
+			&d.ImageUrl,
+			&attributes,
+			&d.PublishAt,
+			&d.CreatedAt,
+			&d.UpdatedAt,
+		); err != nil {
+			s.logger.ErrorContext(ctx, "failed to scan product data", "method", "ExportProducts", "err", err)
+			return fetched, err
+		}
+
+		if err := json.Unmarshal(attributes, &d.Attributes); err != nil {
+			s.logger.ErrorContext(ctx, "failed to unmarshal product attributes", "method", "ExportProducts", "err", err)
+			return fetched, err
+		}
+
+		if err := fn(&d); err != nil {
+			return fetched, err
+		}
+		fetched++
+	}
+
+	if err := rows.Err(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to read from cursor", "method", "ExportProducts", "err", err)
+		return fetched, err
+	}
+
+	return fetched, nil
+}
@@ -0,0 +1,318 @@
+package products
+
+import (
+	"codebase-service/helper"
+	model "codebase-service/models"
+	"context"
+	"database/sql"
+	"slices"
+)
+
+func (s *store) AddProductImage(ctx context.Context, req *model.AddProductImageReq) (*model.ProductImage, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var (
+		res = &model.ProductImage{
+			Url:       req.Url,
+			AltText:   req.AltText,
+			IsPrimary: req.IsPrimary,
+		}
+		count int
+	)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "method", "AddProductImage", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := s.lockProduct(ctx, tx, req.ProductId); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT
+			COUNT(*)
+		FROM
+			product_images
+		WHERE
+			product_id = ?
+	`
+	query = helper.RebindQuery(query)
+
+	row := tx.QueryRowContext(ctx, query, req.ProductId)
+	if err := row.Scan(&count); err != nil {
+		s.logger.ErrorContext(ctx, "failed to count product images", "method", "AddProductImage", "err", err)
+		return nil, err
+	}
+
+	// new images go to the end of the gallery, the first one is always primary
+	res.Position = count
+	if count == 0 {
+		res.IsPrimary = true
+	}
+
+	if res.IsPrimary {
+		if err := s.clearPrimaryImage(ctx, tx, req.ProductId); err != nil {
+			return nil, err
+		}
+	}
+
+	query = `
+		INSERT INTO
+			product_images (product_id, url, alt_text, position, is_primary)
+		VALUES
+			(?, ?, ?, ?, ?)
+		RETURNING
+			id
+	`
+	query = helper.RebindQuery(query)
+
+	row = tx.QueryRowContext(ctx, query, req.ProductId, res.Url, res.AltText, res.Position, res.IsPrimary)
+	if err := row.Scan(&res.Id); err != nil {
+		s.logger.ErrorContext(ctx, "failed to insert product image", "method", "AddProductImage", "err", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to commit transaction", "method", "AddProductImage", "err", err)
+		return nil, err
+	}
+
+	s.evictProductImagesCache(ctx, req.ProductId, res.IsPrimary)
+
+	return res, nil
+}
+
+// ReorderProductImages takes the full gallery in its new order and can move
+// the primary flag along the way.
+func (s *store) ReorderProductImages(ctx context.Context, req *model.ReorderProductImagesReq) ([]*model.ProductImage, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "method", "ReorderProductImages", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := s.lockProduct(ctx, tx, req.ProductId); err != nil {
+		return nil, err
+	}
+
+	images, err := s.getProductImages(ctx, tx, req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+
+	current := make([]string, 0, len(images))
+	for _, image := range images {
+		current = append(current, image.Id)
+	}
+
+	ordered := slices.Clone(req.ImageIds)
+	slices.Sort(current)
+	slices.Sort(ordered)
+	if !slices.Equal(current, ordered) {
+		s.logger.InfoContext(ctx, "image ids do not match the gallery", "method", "ReorderProductImages")
+		return nil, ErrImageOrder
+	}
+
+	if req.PrimaryImageId != nil {
+		if !slices.Contains(req.ImageIds, *req.PrimaryImageId) {
+			s.logger.InfoContext(ctx, "primary image is not part of the gallery", "method", "ReorderProductImages")
+			return nil, ErrImageNotFound
+		}
+
+		if err := s.clearPrimaryImage(ctx, tx, req.ProductId); err != nil {
+			return nil, err
+		}
+	}
+
+	query := `
+		UPDATE product_images
+		SET position = ?, is_primary = (is_primary OR COALESCE(id = ?::uuid, false))
+		WHERE id = ?
+	`
+	query = helper.RebindQuery(query)
+
+	for position, id := range req.ImageIds {
+		if _, err := tx.ExecContext(ctx, query, position, req.PrimaryImageId, id); err != nil {
+			s.logger.ErrorContext(ctx, "failed to update image position", "method", "ReorderProductImages", "err", err)
+			return nil, err
+		}
+	}
+
+	images, err = s.getProductImages(ctx, tx, req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to commit transaction", "method", "ReorderProductImages", "err", err)
+		return nil, err
+	}
+
+	s.evictProductImagesCache(ctx, req.ProductId, req.PrimaryImageId != nil)
+
+	return images, nil
+}
+
+// DeleteProductImage removes an image and closes the gap it leaves. When the
+// primary image goes, the next image in the gallery takes its place.
+func (s *store) DeleteProductImage(ctx context.Context, req *model.DeleteProductImageReq) error {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var isPrimary bool
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "method", "DeleteProductImage", "err", err)
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := s.lockProduct(ctx, tx, req.ProductId); err != nil {
+		return err
+	}
+
+	query := `
+		DELETE FROM product_images
+		WHERE
+			id = ?
+			AND product_id = ?
+		RETURNING
+			is_primary
+	`
+	query = helper.RebindQuery(query)
+
+	row := tx.QueryRowContext(ctx, query, req.Id, req.ProductId)
+	if err := row.Scan(&isPrimary); err != nil {
+		if err == sql.ErrNoRows {
+			s.logger.InfoContext(ctx, "no image found", "method", "DeleteProductImage")
+			return ErrImageNotFound
+		}
+		s.logger.ErrorContext(ctx, "failed to delete product image", "method", "DeleteProductImage", "err", err)
+		return err
+	}
+
+	query = `
+		UPDATE product_images pi
+		SET position = ordered.position
+		FROM (
+			SELECT id, ROW_NUMBER() OVER (ORDER BY position) - 1 AS position
+			FROM product_images
+			WHERE product_id = ?
+		) ordered
+		WHERE pi.id = ordered.id
+	`
+	query = helper.RebindQuery(query)
+
+	if _, err := tx.ExecContext(ctx, query, req.ProductId); err != nil {
+		s.logger.ErrorContext(ctx, "failed to compact image positions", "method", "DeleteProductImage", "err", err)
+		return err
+	}
+
+	if isPrimary {
+		query = `
+			UPDATE product_images
+			SET is_primary = true
+			WHERE
+				product_id = ?
+				AND position = 0
+		`
+		query = helper.RebindQuery(query)
+
+		if _, err := tx.ExecContext(ctx, query, req.ProductId); err != nil {
+			s.logger.ErrorContext(ctx, "failed to promote primary image", "method", "DeleteProductImage", "err", err)
+			return err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to commit transaction", "method", "DeleteProductImage", "err", err)
+		return err
+	}
+
+	s.evictProductImagesCache(ctx, req.ProductId, isPrimary)
+
+	return nil
+}
+
+func (s *store) clearPrimaryImage(ctx context.Context, tx *sql.Tx, productId string) error {
+	query := `
+		UPDATE product_images
+		SET is_primary = false
+		WHERE
+			product_id = ?
+			AND is_primary
+	`
+	query = helper.RebindQuery(query)
+
+	if _, err := tx.ExecContext(ctx, query, productId); err != nil {
+		s.logger.ErrorContext(ctx, "failed to clear primary image", "method", "clearPrimaryImage", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+}
+
+func (s *store) getProductImages(ctx context.Context, q queryer, productId string) ([]*model.ProductImage, error) {
+	var res = make([]*model.ProductImage, 0)
+
+	query := `
+		SELECT
+			id,
+			url,
+			alt_text,
+			position,
+			is_primary
+		FROM
+			product_images
+		WHERE
+			product_id = ?
+		ORDER BY
+			position
+	`
+	query = helper.RebindQuery(query)
+
+	rows, err := q.QueryContext(ctx, query, productId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to fetch product images", "method", "getProductImages", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d model.ProductImage
+		if err := rows.Scan(&d.Id, &d.Url, &d.AltText, &d.Position, &d.IsPrimary); err != nil {
+			s.logger.ErrorContext(ctx, "failed to scan product image", "method", "getProductImages", "err", err)
+			return nil, err
+		}
+		res = append(res, &d)
+	}
+
+	if err := rows.Err(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to iterate product images", "method", "getProductImages", "err", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// evictProductImagesCache drops the cached gallery, and the listing pages too
+// when the primary image, which listings show, may have changed.
+func (s *store) evictProductImagesCache(ctx context.Context, productId string, primaryChanged bool) {
+	s.evictProductCache(ctx, productId)
+	if primaryChanged {
+		s.evictProductsCache(ctx)
+	}
+}
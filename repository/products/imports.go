@@ -0,0 +1,399 @@
+package products
+
+import (
+	"codebase-service/helper"
+	model "codebase-service/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+func (s *store) CreateImport(ctx context.Context, req *model.ProductImport) (*model.ProductImport, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	query := `
+		INSERT INTO
+			product_imports (shop_id, user_id, format, total_rows)
+		VALUES
+			(?, ?, ?, ?)
+		RETURNING
+			id, status, created_at
+	`
+	query = helper.RebindQuery(query)
+
+	row := s.db.QueryRowContext(ctx, query, req.ShopId, req.UserId, req.Format, req.TotalRows)
+	if err := row.Scan(&req.Id, &req.Status, &req.CreatedAt); err != nil {
+		s.logger.ErrorContext(ctx, "failed to insert import", "method", "CreateImport", "err", err)
+		return nil, err
+	}
+
+	if req.Errors == nil {
+		req.Errors = make([]*model.ImportRowError, 0)
+	}
+
+	return req, nil
+}
+
+func (s *store) GetImport(ctx context.Context, id string) (*model.ProductImport, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var (
+		res    = new(model.ProductImport)
+		errors []byte
+	)
+
+	query := `
+		SELECT
+			id,
+			shop_id,
+			user_id,
+			format,
+			status,
+			total_rows,
+			processed_rows,
+			created_count,
+			updated_count,
+			failed_count,
+			errors,
+			error,
+			created_at,
+			started_at,
+			finished_at
+		FROM
+			product_imports
+		WHERE
+			id = ?
+	`
+	query = helper.RebindQuery(query)
+
+	row := s.db.QueryRowContext(ctx, query, id)
+	if err := row.Scan(
+		&res.Id,
+		&res.ShopId,
+		&res.UserId,
+		&res.Format,
+		&res.Status,
+		&res.TotalRows,
+		&res.ProcessedRows,
+		&res.CreatedCount,
+		&res.UpdatedCount,
+		&res.FailedCount,
+		&errors,
+		&res.Error,
+		&res.CreatedAt,
+		&res.StartedAt,
+		&res.FinishedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			s.logger.InfoContext(ctx, "import not found", "method", "GetImport")
+			return nil, ErrImportNotFound
+		}
+		s.logger.ErrorContext(ctx, "failed to fetch import", "method", "GetImport", "err", err)
+		return nil, err
+	}
+
+	if err := json.Unmarshal(errors, &res.Errors); err != nil {
+		s.logger.ErrorContext(ctx, "failed to unmarshal import errors", "method", "GetImport", "err", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// UpdateImport saves the status and progress of a running import.
+func (s *store) UpdateImport(ctx context.Context, req *model.ProductImport) error {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	errors, err := json.Marshal(req.Errors)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to marshal import errors", "method", "UpdateImport", "err", err)
+		return err
+	}
+
+	query := `
+		UPDATE
+			product_imports
+		SET
+			status = ?,
+			total_rows = ?,
+			processed_rows = ?,
+			created_count = ?,
+			updated_count = ?,
+			failed_count = ?,
+			errors = ?,
+			error = ?,
+			started_at = ?,
+			finished_at = ?,
+			updated_at = NOW()
+		WHERE
+			id = ?
+	`
+	query = helper.RebindQuery(query)
+
+	if _, err := s.db.ExecContext(ctx,
+		query,
+		req.Status, req.TotalRows, req.ProcessedRows, req.CreatedCount, req.UpdatedCount, req.FailedCount,
+		errors, req.Error, req.StartedAt, req.FinishedAt, req.Id,
+	); err != nil {
+		s.logger.ErrorContext(ctx, "failed to update import", "method", "UpdateImport", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// UpsertProducts creates or updates one batch of import rows by SKU in a
+// single transaction. A failing row is rolled back to its savepoint and
+// reported in its result, the rest of the batch still commits.
+func (s *store) UpsertProducts(ctx context.Context, req *model.UpsertProductsReq) ([]*model.ImportRowResult, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var (
+		res         = make([]*model.ImportRowResult, 0, len(req.Rows))
+		warehouseId string
+		evictList   bool
+	)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "method", "UpsertProducts", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	for _, row := range req.Rows {
+		result := &model.ImportRowResult{Row: row.Row, Sku: row.Sku}
+		res = append(res, result)
+
+		if _, err := tx.ExecContext(ctx, `SAVEPOINT import_row`); err != nil {
+			s.logger.ErrorContext(ctx, "failed to create savepoint", "method", "UpsertProducts", "err", err)
+			return nil, err
+		}
+
+		var existing struct {
+			id        string
+			status    string
+			publishAt *time.Time
+		}
+
+		query := `
+			SELECT
+				id,
+				status,
+				publish_at
+			FROM
+				products
+			WHERE
+				shop_id = ?
+				AND sku = ?
+				AND deleted_at IS NULL
+			FOR UPDATE
+		`
+		query = helper.RebindQuery(query)
+
+		err := tx.QueryRowContext(ctx, query, req.ShopId, row.Sku).Scan(&existing.id, &existing.status, &existing.publishAt)
+		switch {
+		case err == sql.ErrNoRows:
+			if warehouseId == "" {
+				warehouseId, err = s.defaultWarehouse(ctx, tx, req.ShopId)
+				if err != nil {
+					return nil, err
+				}
+			}
+			result.Created = true
+			result.ProductId, err = s.insertImportedProduct(ctx, tx, req, row, warehouseId)
+		case err == nil:
+			result.ProductId = existing.id
+			err = s.updateImportedProduct(ctx, tx, row, existing.id, existing.status, existing.publishAt)
+		default:
+			s.logger.ErrorContext(ctx, "failed to look up sku", "method", "UpsertProducts", "err", err)
+		}
+
+		if err != nil {
+			if _, rbErr := tx.ExecContext(ctx, `ROLLBACK TO SAVEPOINT import_row`); rbErr != nil {
+				s.logger.ErrorContext(ctx, "failed to roll back row", "method", "UpsertProducts", "row", row.Row, "err", rbErr)
+				return nil, rbErr
+			}
+			result.Error = importRowError(err)
+			continue
+		}
+
+		if _, err := tx.ExecContext(ctx, `RELEASE SAVEPOINT import_row`); err != nil {
+			s.logger.ErrorContext(ctx, "failed to release savepoint", "method", "UpsertProducts", "err", err)
+			return nil, err
+		}
+
+		if !result.Created || row.Status == model.ProductStatusPublished {
+			evictList = true
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to commit transaction", "method", "UpsertProducts", "err", err)
+		return nil, err
+	}
+
+	for _, result := range res {
+		if result.Error == "" && !result.Created {
+			s.evictProductCache(ctx, result.ProductId)
+		}
+	}
+
+	if evictList {
+		s.evictProductsCache(ctx)
+	}
+
+	return res, nil
+}
+
+func (s *store) insertImportedProduct(ctx context.Context, tx *sql.Tx, req *model.UpsertProductsReq, row *model.ImportProductRow, warehouseId string) (string, error) {
+	var (
+		id        string
+		status    = row.Status
+		publishAt *time.Time
+		review    *model.ReviewRequest
+	)
+
+	if status == "" {
+		status = model.ProductStatusDraft
+	}
+
+	if status == model.ProductStatusPublished {
+		if row.NeedsReview {
+			review = &model.ReviewRequest{TargetStatus: status, FlaggedWords: row.FlaggedWords}
+			status = model.ProductStatusPendingReview
+		} else {
+			now := time.Now()
+			publishAt = &now
+		}
+	}
+
+	attributes, err := marshalAttributes(row.Attributes)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to marshal product attributes", "method", "insertImportedProduct", "err", err)
+		return "", err
+	}
+
+	query := `
+		INSERT INTO
+			products (shop_id, category_id, sku, name, description, attributes, status, publish_at, price)
+		VALUES
+			(?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING
+			id
+	`
+	query = helper.RebindQuery(query)
+
+	scan := tx.QueryRowContext(ctx, query, req.ShopId, row.CategoryId, row.Sku, row.Name, row.Description, attributes, status, publishAt, row.Price)
+	if err := scan.Scan(&id); err != nil {
+		s.logger.ErrorContext(ctx, "failed to insert product", "method", "insertImportedProduct", "err", err)
+		return "", err
+	}
+
+	if row.ImageUrl != "" {
+		query = `
+			INSERT INTO
+				product_images (product_id, url, alt_text, position, is_primary)
+			VALUES
+				(?, ?, ?, 0, true)
+		`
+		query = helper.RebindQuery(query)
+
+		if _, err := tx.ExecContext(ctx, query, id, row.ImageUrl, row.Name); err != nil {
+			s.logger.ErrorContext(ctx, "failed to insert primary image", "method", "insertImportedProduct", "err", err)
+			return "", err
+		}
+	}
+
+	if row.Stock > 0 {
+		if _, err := s.recordStockMovement(ctx, tx, &model.StockMovement{
+			ProductId:   id,
+			WarehouseId: warehouseId,
+			Type:        model.StockMovementReceipt,
+			Quantity:    row.Stock,
+			Reason:      "initial stock",
+			CreatedBy:   &req.UserId,
+		}); err != nil {
+			return "", err
+		}
+	}
+
+	if review != nil {
+		if err := s.submitForReview(ctx, tx, id, review); err != nil {
+			return "", err
+		}
+	}
+
+	return id, nil
+}
+
+// updateImportedProduct applies the same review rules as UpdateProduct, live
+// products go back through review when the row needs one.
+func (s *store) updateImportedProduct(ctx context.Context, tx *sql.Tx, row *model.ImportProductRow, id, status string, publishAt *time.Time) error {
+	var review *model.ReviewRequest
+
+	switch status {
+	case model.ProductStatusPublished, model.ProductStatusScheduled:
+		if row.NeedsReview {
+			review = &model.ReviewRequest{TargetStatus: status, PublishAt: publishAt, FlaggedWords: row.FlaggedWords}
+		}
+	case model.ProductStatusPendingReview:
+		review = &model.ReviewRequest{FlaggedWords: row.FlaggedWords}
+	}
+
+	attributes, err := marshalAttributes(row.Attributes)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to marshal product attributes", "method", "updateImportedProduct", "err", err)
+		return err
+	}
+
+	query := `
+		UPDATE
+			products
+		SET
+			category_id = ?,
+			name = ?,
+			description = ?,
+			attributes = ?,
+			price = ?,
+			status = CASE WHEN ? THEN 'pending_review' ELSE status END,
+			updated_at = NOW()
+		WHERE
+			id = ?
+	`
+	query = helper.RebindQuery(query)
+
+	if _, err := tx.ExecContext(ctx, query, row.CategoryId, row.Name, row.Description, attributes, row.Price, review != nil, id); err != nil {
+		s.logger.ErrorContext(ctx, "failed to update product", "method", "updateImportedProduct", "err", err)
+		return err
+	}
+
+	if review != nil {
+		if err := s.submitForReview(ctx, tx, id, review); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// importRowError turns a row failure into a message for the import report.
+func importRowError(err error) string {
+	if pqErr, ok := err.(*pq.Error); ok {
+		switch pqErr.Code {
+		case "23503":
+			return "no category found"
+		case "23505":
+			return "sku already exists"
+		}
+	}
+
+	return err.Error()
+}
@@ -0,0 +1,286 @@
+package products
+
+import (
+	"codebase-service/helper"
+	model "codebase-service/models"
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+)
+
+const defaultWarehouseName = "Main warehouse"
+
+func (s *store) CreateWarehouse(ctx context.Context, req *model.CreateWarehouseReq) (*model.Warehouse, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var res = new(model.Warehouse)
+
+	// the first warehouse of a shop becomes its default one
+	query := `
+		INSERT INTO
+			warehouses (shop_id, name, is_default)
+		VALUES
+			(?, ?, NOT EXISTS (SELECT 1 FROM warehouses WHERE shop_id = ? AND is_default))
+		RETURNING
+			id, shop_id, name, is_default, created_at
+	`
+	query = helper.RebindQuery(query)
+
+	row := s.db.QueryRowContext(ctx, query, req.ShopId, req.Name, req.ShopId)
+	if err := row.Scan(&res.Id, &res.ShopId, &res.Name, &res.IsDefault, &res.CreatedAt); err != nil {
+		s.logger.ErrorContext(ctx, "failed to insert warehouse", "method", "CreateWarehouse", "err", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *store) GetWarehouses(ctx context.Context, req *model.GetWarehousesReq) ([]*model.Warehouse, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var res = make([]*model.Warehouse, 0)
+
+	query := `
+		SELECT
+			id,
+			shop_id,
+			name,
+			is_default,
+			created_at
+		FROM
+			warehouses
+		WHERE
+			shop_id = ?
+			AND deleted_at IS NULL
+		ORDER BY
+			is_default DESC, created_at
+	`
+	query = helper.RebindQuery(query)
+
+	rows, err := s.db.QueryContext(ctx, query, req.ShopId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to fetch warehouses data", "method", "GetWarehouses", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d model.Warehouse
+		if err := rows.Scan(&d.Id, &d.ShopId, &d.Name, &d.IsDefault, &d.CreatedAt); err != nil {
+			s.logger.ErrorContext(ctx, "failed to scan warehouse data", "method", "GetWarehouses", "err", err)
+			return nil, err
+		}
+		res = append(res, &d)
+	}
+
+	if err := rows.Err(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to iterate warehouses data", "method", "GetWarehouses", "err", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *store) CreateStockAdjustment(ctx context.Context, req *model.CreateStockAdjustmentReq) (*model.StockAdjustmentResp, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var (
+		res    = new(model.StockAdjustmentResp)
+		shopId string
+	)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "method", "CreateStockAdjustment", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	shopId, err = s.lockProduct(ctx, tx, req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkVariant(ctx, tx, req.ProductId, req.VariantId); err != nil {
+		return nil, err
+	}
+
+	var warehouseId string
+	if req.WarehouseId != nil {
+		query := `
+			SELECT
+				id
+			FROM
+				warehouses
+			WHERE
+				id = ?
+				AND shop_id = ?
+				AND deleted_at IS NULL
+		`
+		query = helper.RebindQuery(query)
+
+		row := tx.QueryRowContext(ctx, query, *req.WarehouseId, shopId)
+		if err := row.Scan(&warehouseId); err != nil {
+			if err == sql.ErrNoRows {
+				s.logger.InfoContext(ctx, "no warehouse found", "method", "CreateStockAdjustment")
+				return nil, ErrWarehouseNotFound
+			}
+			s.logger.ErrorContext(ctx, "failed to fetch warehouse", "method", "CreateStockAdjustment", "err", err)
+			return nil, err
+		}
+	} else {
+		warehouseId, err = s.defaultWarehouse(ctx, tx, shopId)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	res.Movement = &model.StockMovement{
+		ProductId:   req.ProductId,
+		VariantId:   req.VariantId,
+		WarehouseId: warehouseId,
+		Type:        req.Type,
+		Quantity:    req.Quantity,
+		Reason:      req.Reason,
+		Reference:   req.Reference,
+		CreatedBy:   &req.UserId,
+	}
+
+	res.WarehouseStock, err = s.recordStockMovement(ctx, tx, res.Movement)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Stock, err = s.productStock(ctx, tx, req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to commit transaction", "method", "CreateStockAdjustment", "err", err)
+		return nil, err
+	}
+
+	s.evictProductCache(ctx, req.ProductId)
+	s.evictProductsCache(ctx)
+
+	return res, nil
+}
+
+// defaultWarehouse returns the default warehouse of a shop, creating it for
+// shops that never had one.
+func (s *store) defaultWarehouse(ctx context.Context, tx *sql.Tx, shopId string) (string, error) {
+	var id string
+
+	query := `
+		INSERT INTO
+			warehouses (shop_id, name, is_default)
+		VALUES
+			(?, ?, true)
+		ON CONFLICT (shop_id) WHERE is_default DO NOTHING
+	`
+	query = helper.RebindQuery(query)
+
+	if _, err := tx.ExecContext(ctx, query, shopId, defaultWarehouseName); err != nil {
+		s.logger.ErrorContext(ctx, "failed to ensure default warehouse", "method", "defaultWarehouse", "err", err)
+		return "", err
+	}
+
+	query = `
+		SELECT
+			id
+		FROM
+			warehouses
+		WHERE
+			shop_id = ?
+			AND is_default
+	`
+	query = helper.RebindQuery(query)
+
+	row := tx.QueryRowContext(ctx, query, shopId)
+	if err := row.Scan(&id); err != nil {
+		s.logger.ErrorContext(ctx, "failed to fetch default warehouse", "method", "defaultWarehouse", "err", err)
+		return "", err
+	}
+
+	return id, nil
+}
+
+// recordStockMovement applies a movement to the warehouse level and appends
+// it to the ledger, returning the new warehouse level. Movements that would
+// take a warehouse below zero are rejected.
+func (s *store) recordStockMovement(ctx context.Context, tx *sql.Tx, m *model.StockMovement) (int64, error) {
+	var quantity int64
+
+	query := `
+		INSERT INTO
+			warehouse_stocks (warehouse_id, product_id, variant_id, quantity)
+		VALUES
+			(?, ?, ?, ?)
+		ON CONFLICT (warehouse_id, product_id, (COALESCE(variant_id, '00000000-0000-0000-0000-000000000000'::uuid))) DO UPDATE
+		SET quantity = warehouse_stocks.quantity + EXCLUDED.quantity, updated_at = NOW()
+		RETURNING
+			quantity
+	`
+	query = helper.RebindQuery(query)
+
+	row := tx.QueryRowContext(ctx, query, m.WarehouseId, m.ProductId, m.VariantId, m.Quantity)
+	if err := row.Scan(&quantity); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23514" {
+			s.logger.InfoContext(ctx, "insufficient stock", "method", "recordStockMovement")
+			return 0, ErrInsufficientStock
+		}
+		s.logger.ErrorContext(ctx, "failed to update warehouse stock", "method", "recordStockMovement", "err", err)
+		return 0, err
+	}
+
+	query = `
+		INSERT INTO
+			stock_movements (product_id, variant_id, warehouse_id, type, quantity, reason, reference, created_by)
+		VALUES
+			(?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING
+			id, created_at
+	`
+	query = helper.RebindQuery(query)
+
+	row = tx.QueryRowContext(ctx, query, m.ProductId, m.VariantId, m.WarehouseId, m.Type, m.Quantity, m.Reason, m.Reference, m.CreatedBy)
+	if err := row.Scan(&m.Id, &m.CreatedAt); err != nil {
+		s.logger.ErrorContext(ctx, "failed to insert stock movement", "method", "recordStockMovement", "err", err)
+		return 0, err
+	}
+
+	return quantity, nil
+}
+
+func (s *store) productStock(ctx context.Context, tx *sql.Tx, productId string) (int64, error) {
+	var stock int64
+
+	// once a product has variants only their rows count, stock left on the
+	// product level from before is no longer sellable
+	query := `
+		SELECT
+			COALESCE(SUM(ws.quantity), 0)
+		FROM
+			warehouse_stocks ws
+		WHERE
+			ws.product_id = ?
+			AND (
+				ws.variant_id IS NOT NULL
+				OR NOT EXISTS (SELECT 1 FROM product_variants pv WHERE pv.product_id = ws.product_id AND pv.deleted_at IS NULL)
+			)
+	`
+	query = helper.RebindQuery(query)
+
+	row := tx.QueryRowContext(ctx, query, productId)
+	if err := row.Scan(&stock); err != nil {
+		s.logger.ErrorContext(ctx, "failed to sum product stock", "method", "productStock", "err", err)
+		return 0, err
+	}
+
+	return stock, nil
+}
@@ -0,0 +1,240 @@
+package products
+
+import (
+	"codebase-service/helper"
+	model "codebase-service/models"
+	"context"
+	"fmt"
+)
+
+func (s *store) SetProductStatus(ctx context.Context, req *model.SetProductStatusReq) (*model.GetProductResp, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var status = req.Status
+	if req.Review != nil {
+		status = model.ProductStatusPendingReview
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "method", "SetProductStatus", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE
+			products
+		SET
+			status = ?,
+			publish_at = ?,
+			updated_at = NOW()
+		WHERE
+			id = ?
+			AND deleted_at IS NULL
+	`
+	query = helper.RebindQuery(query)
+
+	result, err := tx.ExecContext(ctx, query, status, req.PublishAt, req.Id)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to update product status", "method", "SetProductStatus", "err", err)
+		return nil, err
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to read affected rows", "method", "SetProductStatus", "err", err)
+		return nil, err
+	}
+
+	if affected == 0 {
+		s.logger.InfoContext(ctx, "no product found", "method", "SetProductStatus")
+		return nil, ErrProductNotFound
+	}
+
+	if req.Review != nil {
+		err = s.submitForReview(ctx, tx, req.Id, req.Review)
+	} else {
+		err = s.cancelReview(ctx, tx, req.Id)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to commit transaction", "method", "SetProductStatus", "err", err)
+		return nil, err
+	}
+
+	s.evictProductCache(ctx, req.Id)
+	s.evictProductsCache(ctx)
+
+	return s.getProductInDB(ctx, &model.GetProductReq{Id: req.Id})
+}
+
+// GetShopProducts is read straight from the database, the seller view must
+// reflect status changes immediately and is not worth caching.
+func (s *store) GetShopProducts(ctx context.Context, req *model.GetShopProductsReq) (*model.GetProductsResp, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var (
+		totalData int
+		res       = new(model.GetProductsResp)
+		args      = make([]interface{}, 0)
+		filter    string
+	)
+	res.Items = make([]*model.ProductItem, 0)
+	res.Meta = new(model.Meta)
+
+	args = append(args, req.ShopId)
+	if req.Status != "" {
+		filter = "AND p.status = ?"
+		args = append(args, req.Status)
+	}
+
+	query := fmt.Sprintf(`
+		SELECT
+			COUNT(*) OVER() AS total_data,
+			p.id,
+			p.sku,
+			p.name,
+			p.status,
+			p.publish_at,
+			p.price,
+			promo.sale_price,
+			promo.ends_at,
+			COALESCE((SELECT SUM(ws.quantity) FROM warehouse_stocks ws WHERE ws.product_id = p.id AND (ws.variant_id IS NOT NULL OR NOT EXISTS (SELECT 1 FROM product_variants pv WHERE pv.product_id = p.id AND pv.deleted_at IS NULL))), 0) AS stock,
+			COALESCE((SELECT pi.url FROM product_images pi WHERE pi.product_id = p.id AND pi.is_primary), '') AS image_url
+		FROM
+			products p
+		LEFT JOIN LATERAL (
+			SELECT
+				pp.sale_price,
+				pp.ends_at
+			FROM
+				product_promotions pp
+			WHERE
+				pp.product_id = p.id
+				AND pp.deleted_at IS NULL
+				AND pp.starts_at <= NOW()
+				AND pp.ends_at > NOW()
+				AND (pp.quantity IS NULL OR pp.claimed < pp.quantity)
+			LIMIT 1
+		) promo ON true
+		WHERE
+			p.shop_id = ?
+			AND p.deleted_at IS NULL
+			%s
+		ORDER BY
+			p.created_at DESC
+		LIMIT ? OFFSET ?
+	`, filter)
+	args = append(args, req.Limit, (req.Page-1)*req.Limit)
+
+	query = helper.RebindQuery(query)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to fetch products data", "method", "GetShopProducts", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d model.ProductItem
+		if err := rows.Scan(
+			&totalData,
+			&d.Id,
+			&d.Sku,
+			&d.Name,
+			&d.Status,
+			&d.PublishAt,
+			&d.OriginalPrice,
+			&d.SalePrice,
+			&d.SaleEndsAt,
+			&d.Stock,
+			&d.ImageUrl,
+		); err != nil {
+			s.logger.ErrorContext(ctx, "failed to scan product data", "method", "GetShopProducts", "err", err)
+			return nil, err
+		}
+		d.ApplySale()
+		res.Items = append(res.Items, &d)
+	}
+
+	if err := rows.Err(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to iterate products data", "method", "GetShopProducts", "err", err)
+		return nil, err
+	}
+
+	res.Meta.SetMeta(req.Page, req.Limit, totalData)
+
+	return res, nil
+}
+
+// PublishScheduledProducts publishes up to limit scheduled products whose
+// publish_at has passed. SKIP LOCKED lets several instances run the scheduler
+// without publishing the same product twice.
+func (s *store) PublishScheduledProducts(ctx context.Context, limit int) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	query := `
+		UPDATE
+			products
+		SET
+			status = 'published',
+			updated_at = NOW()
+		WHERE
+			id IN (
+				SELECT id
+				FROM
+					products
+				WHERE
+					status = 'scheduled'
+					AND publish_at <= NOW()
+					AND deleted_at IS NULL
+				ORDER BY
+					publish_at
+				LIMIT ?
+				FOR UPDATE SKIP LOCKED
+			)
+		RETURNING
+			id
+	`
+	query = helper.RebindQuery(query)
+
+	rows, err := s.db.QueryContext(ctx, query, limit)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to publish scheduled products", "method", "PublishScheduledProducts", "err", err)
+		return 0, err
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			s.logger.ErrorContext(ctx, "failed to scan product id", "method", "PublishScheduledProducts", "err", err)
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+
+	if err := rows.Err(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to iterate product ids", "method", "PublishScheduledProducts", "err", err)
+		return 0, err
+	}
+
+	for _, id := range ids {
+		s.evictProductCache(ctx, id)
+	}
+
+	if len(ids) > 0 {
+		s.evictProductsCache(ctx)
+	}
+
+	return len(ids), nil
+}
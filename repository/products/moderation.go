@@ -0,0 +1,303 @@
+package products
+
+import (
+	"codebase-service/helper"
+	model "codebase-service/models"
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/lib/pq"
+)
+
+// submitForReview opens a pending review for the product or refreshes the
+// one already open. A review request without a target status only refreshes
+// the flagged words of the open review, for edits made while it is pending.
+func (s *store) submitForReview(ctx context.Context, tx *sql.Tx, productId string, review *model.ReviewRequest) error {
+	if review.TargetStatus == "" {
+		query := `
+			UPDATE
+				product_reviews
+			SET
+				flagged_words = ?
+			WHERE
+				product_id = ?
+				AND status = 'pending'
+		`
+		query = helper.RebindQuery(query)
+
+		if _, err := tx.ExecContext(ctx, query, pq.Array(review.FlaggedWords), productId); err != nil {
+			s.logger.ErrorContext(ctx, "failed to refresh review", "method", "submitForReview", "err", err)
+			return err
+		}
+
+		return nil
+	}
+
+	query := `
+		INSERT INTO
+			product_reviews (product_id, target_status, publish_at, flagged_words)
+		VALUES
+			(?, ?, ?, ?)
+		ON CONFLICT (product_id) WHERE status = 'pending'
+		DO UPDATE SET
+			target_status = EXCLUDED.target_status,
+			publish_at = EXCLUDED.publish_at,
+			flagged_words = EXCLUDED.flagged_words
+	`
+	query = helper.RebindQuery(query)
+
+	if _, err := tx.ExecContext(ctx, query, productId, review.TargetStatus, review.PublishAt, pq.Array(review.FlaggedWords)); err != nil {
+		s.logger.ErrorContext(ctx, "failed to submit review", "method", "submitForReview", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+// cancelReview closes the open review of a product the seller took back to
+// draft or archived.
+func (s *store) cancelReview(ctx context.Context, tx *sql.Tx, productId string) error {
+	query := `
+		UPDATE
+			product_reviews
+		SET
+			status = 'cancelled'
+		WHERE
+			product_id = ?
+			AND status = 'pending'
+	`
+	query = helper.RebindQuery(query)
+
+	if _, err := tx.ExecContext(ctx, query, productId); err != nil {
+		s.logger.ErrorContext(ctx, "failed to cancel review", "method", "cancelReview", "err", err)
+		return err
+	}
+
+	return nil
+}
+
+func (s *store) GetModerationQueue(ctx context.Context, req *model.GetModerationQueueReq) (*model.GetModerationQueueResp, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var (
+		totalData int
+		res       = new(model.GetModerationQueueResp)
+		filter    string
+	)
+	res.Items = make([]*model.ProductReview, 0)
+	res.Meta = new(model.Meta)
+
+	if req.Flagged {
+		filter = "AND cardinality(r.flagged_words) > 0"
+	}
+
+	// flagged products are reviewed first, then oldest first
+	query := fmt.Sprintf(`
+		SELECT
+			COUNT(*) OVER() AS total_data,
+			r.id,
+			r.product_id,
+			p.name,
+			COALESCE(p.description, '') AS description,
+			p.shop_id,
+			s.name AS shop_name,
+			r.status,
+			r.target_status,
+			r.publish_at,
+			r.flagged_words,
+			r.reason,
+			r.created_at,
+			r.reviewed_at
+		FROM
+			product_reviews r
+		JOIN
+			products p ON r.product_id = p.id
+		JOIN
+			shops s ON p.shop_id = s.id
+		WHERE
+			r.status = 'pending'
+			AND p.deleted_at IS NULL
+			%s
+		ORDER BY
+			cardinality(r.flagged_words) > 0 DESC,
+			r.created_at
+		LIMIT ? OFFSET ?
+	`, filter)
+	query = helper.RebindQuery(query)
+
+	rows, err := s.db.QueryContext(ctx, query, req.Limit, (req.Page-1)*req.Limit)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to fetch reviews", "method", "GetModerationQueue", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d model.ProductReview
+		if err := rows.Scan(
+			&totalData,
+			&d.Id,
+			&d.ProductId,
+			&d.ProductName,
+			&d.Description,
+			&d.ShopId,
+			&d.ShopName,
+			&d.Status,
+			&d.TargetStatus,
+			&d.PublishAt,
+			pq.Array(&d.FlaggedWords),
+			&d.Reason,
+			&d.CreatedAt,
+			&d.ReviewedAt,
+		); err != nil {
+			s.logger.ErrorContext(ctx, "failed to scan review", "method", "GetModerationQueue", "err", err)
+			return nil, err
+		}
+		res.Items = append(res.Items, &d)
+	}
+
+	if err := rows.Err(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to iterate reviews", "method", "GetModerationQueue", "err", err)
+		return nil, err
+	}
+
+	res.Meta.SetMeta(req.Page, req.Limit, totalData)
+
+	return res, nil
+}
+
+// DecideReview approves or rejects a pending review. An approved product
+// takes the status the seller asked for, a rejected one waits for the seller
+// to fix it and submit it again.
+func (s *store) DecideReview(ctx context.Context, req *model.ReviewDecisionReq) (*model.ProductReview, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var res = new(model.ProductReview)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "method", "DecideReview", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT
+			r.id,
+			r.product_id,
+			p.name,
+			p.shop_id,
+			s.user_id,
+			r.status,
+			r.target_status,
+			r.publish_at,
+			r.flagged_words,
+			r.created_at
+		FROM
+			product_reviews r
+		JOIN
+			products p ON r.product_id = p.id
+		JOIN
+			shops s ON p.shop_id = s.id
+		WHERE
+			r.id = ?
+		FOR UPDATE OF r
+	`
+	query = helper.RebindQuery(query)
+
+	row := tx.QueryRowContext(ctx, query, req.Id)
+	if err := row.Scan(
+		&res.Id,
+		&res.ProductId,
+		&res.ProductName,
+		&res.ShopId,
+		&res.SellerId,
+		&res.Status,
+		&res.TargetStatus,
+		&res.PublishAt,
+		pq.Array(&res.FlaggedWords),
+		&res.CreatedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			s.logger.InfoContext(ctx, "review not found", "method", "DecideReview")
+			return nil, ErrReviewNotFound
+		}
+		s.logger.ErrorContext(ctx, "failed to lock review", "method", "DecideReview", "err", err)
+		return nil, err
+	}
+
+	if res.Status != model.ReviewStatusPending {
+		s.logger.InfoContext(ctx, "review already decided", "method", "DecideReview", "review_id", res.Id, "status", res.Status)
+		return nil, ErrReviewDecided
+	}
+
+	query = `
+		UPDATE
+			product_reviews
+		SET
+			status = ?,
+			reason = NULLIF(?, ''),
+			reviewed_by = ?,
+			reviewed_at = NOW()
+		WHERE
+			id = ?
+		RETURNING
+			status, reason, reviewed_at
+	`
+	query = helper.RebindQuery(query)
+
+	row = tx.QueryRowContext(ctx, query, req.Status, req.Reason, req.UserId, req.Id)
+	if err := row.Scan(&res.Status, &res.Reason, &res.ReviewedAt); err != nil {
+		s.logger.ErrorContext(ctx, "failed to update review", "method", "DecideReview", "err", err)
+		return nil, err
+	}
+
+	if req.Status == model.ReviewStatusApproved {
+		query = `
+			UPDATE
+				products
+			SET
+				status = ?,
+				publish_at = CASE WHEN ? = 'published' THEN NOW() ELSE ?::timestamptz END,
+				updated_at = NOW()
+			WHERE
+				id = ?
+				AND status = 'pending_review'
+		`
+		query = helper.RebindQuery(query)
+
+		_, err = tx.ExecContext(ctx, query, res.TargetStatus, res.TargetStatus, res.PublishAt, res.ProductId)
+	} else {
+		query = `
+			UPDATE
+				products
+			SET
+				status = 'rejected',
+				publish_at = NULL,
+				updated_at = NOW()
+			WHERE
+				id = ?
+				AND status = 'pending_review'
+		`
+		query = helper.RebindQuery(query)
+
+		_, err = tx.ExecContext(ctx, query, res.ProductId)
+	}
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to update product status", "method", "DecideReview", "err", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to commit transaction", "method", "DecideReview", "err", err)
+		return nil, err
+	}
+
+	s.evictProductCache(ctx, res.ProductId)
+	s.evictProductsCache(ctx)
+
+	return res, nil
+}
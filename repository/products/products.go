@@ -3,67 +3,128 @@ package products
 import (
 	"codebase-service/helper"
 	model "codebase-service/models"
+	"codebase-service/util/cache"
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"log"
+	"log/slog"
+	"maps"
+	"slices"
+	"strings"
 	"time"
 
-	"github.com/redis/go-redis/v9"
+	"github.com/lib/pq"
 )
 
 var _ ProductRepository = &store{}
 
+// queryTimeout bounds each repository operation. Callers pass the request
+// context, so a client going away cancels the work even sooner.
+const queryTimeout = 5 * time.Second
+
 type store struct {
-	db    *sql.DB
-	redis *redis.Client
+	db       *sql.DB
+	cache    *cache.Client
+	products *cache.Cache[*model.GetProductResp]
+	lists    *cache.Cache[*model.GetProductsResp]
+	logger   *slog.Logger
 }
 
-func NewStore(db *sql.DB, redis *redis.Client) *store {
-	return &store{
-		db:    db,
-		redis: redis,
+func NewStore(db *sql.DB, cacheClient *cache.Client, logger *slog.Logger) *store {
+	s := &store{
+		db:     db,
+		cache:  cacheClient,
+		logger: logger.With("component", "repo.products"),
 	}
+	s.products, s.lists = newProductCaches(cacheClient)
+
+	return s
 }
 
 type ProductRepository interface {
-	IsShopOwner(userId, shopId string) error
-	CreateProduct(req *model.CreateProductReq) (*model.GetProductResp, error)
-	GetProduct(req *model.GetProductReq) (*model.GetProductResp, error)
-	GetProducts(req *model.GetProductsReq) (*model.GetProductsResp, error)
-	DeleteProduct(req *model.DeleteProductReq) error
+	IsShopOwner(ctx context.Context, userId, shopId string) error
+	CreateProduct(ctx context.Context, req *model.CreateProductReq) (*model.GetProductResp, error)
+	GetProduct(ctx context.Context, req *model.GetProductReq) (*model.GetProductResp, error)
+	GetProducts(ctx context.Context, req *model.GetProductsReq) (*model.GetProductsResp, error)
+	DeleteProduct(ctx context.Context, req *model.DeleteProductReq) error
+	IsProductOwner(ctx context.Context, userId, productId string) error
+	CreatePromotion(ctx context.Context, req *model.CreatePromotionReq) (*model.Promotion, error)
+	ClaimFlashSale(ctx context.Context, req *model.ClaimFlashSaleReq) (*model.ClaimFlashSaleResp, error)
+	CreateWarehouse(ctx context.Context, req *model.CreateWarehouseReq) (*model.Warehouse, error)
+	GetWarehouses(ctx context.Context, req *model.GetWarehousesReq) ([]*model.Warehouse, error)
+	CreateStockAdjustment(ctx context.Context, req *model.CreateStockAdjustmentReq) (*model.StockAdjustmentResp, error)
+	CreateReservation(ctx context.Context, req *model.CreateReservationReq) (*model.Reservation, error)
+	ConfirmReservation(ctx context.Context, req *model.ReservationReq) (*model.Reservation, error)
+	ReleaseReservation(ctx context.Context, req *model.ReservationReq) (*model.Reservation, error)
+	ReleaseExpiredReservations(ctx context.Context, limit int) (int, error)
+	CreateRefund(ctx context.Context, req *model.CreateRefundReq) (*model.Refund, error)
+	CompleteRefund(ctx context.Context, req *model.CompleteRefundReq) (*model.Refund, error)
+	SetProductOptions(ctx context.Context, req *model.SetProductOptionsReq) ([]*model.ProductOption, error)
+	GetProductOptions(ctx context.Context, productId string) ([]*model.ProductOption, error)
+	CreateVariant(ctx context.Context, req *model.CreateVariantReq) (*model.ProductVariant, error)
+	AddProductImage(ctx context.Context, req *model.AddProductImageReq) (*model.ProductImage, error)
+	ReorderProductImages(ctx context.Context, req *model.ReorderProductImagesReq) ([]*model.ProductImage, error)
+	DeleteProductImage(ctx context.Context, req *model.DeleteProductImageReq) error
+	UpdateProduct(ctx context.Context, req *model.UpdateProductReq) (*model.GetProductResp, error)
+	GetCategoryAttributes(ctx context.Context, categoryId string) ([]*model.AttributeDefinition, error)
+	SetCategoryAttributes(ctx context.Context, req *model.SetCategoryAttributesReq) ([]*model.AttributeDefinition, error)
+	SetProductStatus(ctx context.Context, req *model.SetProductStatusReq) (*model.GetProductResp, error)
+	GetShopProducts(ctx context.Context, req *model.GetShopProductsReq) (*model.GetProductsResp, error)
+	PublishScheduledProducts(ctx context.Context, limit int) (int, error)
+	GetModerationQueue(ctx context.Context, req *model.GetModerationQueueReq) (*model.GetModerationQueueResp, error)
+	DecideReview(ctx context.Context, req *model.ReviewDecisionReq) (*model.ProductReview, error)
+	RestoreProduct(ctx context.Context, req *model.RestoreProductReq) (*model.GetProductResp, error)
+	PurgeDeletedProducts(ctx context.Context, deletedBefore time.Time, limit int) (int, error)
+	CreateImport(ctx context.Context, req *model.ProductImport) (*model.ProductImport, error)
+	GetImport(ctx context.Context, id string) (*model.ProductImport, error)
+	UpdateImport(ctx context.Context, req *model.ProductImport) error
+	UpsertProducts(ctx context.Context, req *model.UpsertProductsReq) ([]*model.ImportRowResult, error)
+	ExportProducts(ctx context.Context, req *model.ExportProductsReq, fn func(row *model.ExportProductRow) error) error
+	GetTopProductIds(ctx context.Context, since time.Time, limit int) ([]string, error)
+	GetShopProductIds(ctx context.Context, shopId string) ([]string, error)
+	PurgeProductCache(ctx context.Context, ids ...string) error
+	EvictShopCache(ctx context.Context, shopId string) error
 }
 
-func (s *store) GetProduct(req *model.GetProductReq) (*model.GetProductResp, error) {
-	resp, err := s.getProductInRedis(req)
-	if err != nil {
-		if err != redis.Nil {
-			log.Printf("repo::GetProduct - failed to get product data from redis: %v", err)
-			return nil, err
+// GetProduct serves from the cache when it can. Cache errors are treated as
+// misses, the product is then read from the db. Ids without a product are
+// cached too, for a short while.
+func (s *store) GetProduct(ctx context.Context, req *model.GetProductReq) (*model.GetProductResp, error) {
+	res, err := s.products.Load(ctx, req.Id, func(ctx context.Context) (*model.GetProductResp, error) {
+		ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+		defer cancel()
+
+		res, err := s.getProductInDB(ctx, req)
+		if errors.Is(err, ErrProductNotFound) {
+			return nil, cache.ErrNotFound
 		}
-
-		resp, err = s.getProductInDB(req)
 		if err != nil {
-			log.Printf("repo::GetProduct - failed to get product data from db: %v", err)
+			s.logger.ErrorContext(ctx, "failed to get product data from db", "method", "GetProduct", "err", err)
 			return nil, err
 		}
 
-		err = s.setProductInRedis(req, resp)
-		if err != nil {
-			log.Printf("repo::GetProduct - failed to set product data in redis: %v", err)
-			return nil, err
-		}
+		return res, nil
+	}, func(_ context.Context, res *model.GetProductResp) time.Duration {
+		return cacheExpiration(time.Now(), res.SaleEndsAt, res.NextSaleStartsAt)
+	})
+	if errors.Is(err, cache.ErrNotFound) {
+		return nil, ErrProductNotFound
+	}
+	if err != nil {
+		return nil, err
 	}
 
-	return resp, nil
+	return res, nil
 }
 
-func (s *store) getProductInDB(req *model.GetProductReq) (*model.GetProductResp, error) {
-	log.Printf("repo::getProductInDB - fetching product data from db")
+func (s *store) getProductInDB(ctx context.Context, req *model.GetProductReq) (*model.GetProductResp, error) {
+	s.logger.InfoContext(ctx, "fetching product data from db", "method", "getProductInDB")
 	var (
-		res  = new(model.GetProductResp)
-		args = make([]interface{}, 0)
+		res        = new(model.GetProductResp)
+		args       = make([]interface{}, 0)
+		attributes []byte
 	)
 
 	query := `
@@ -73,134 +134,220 @@ func (s *store) getProductInDB(req *model.GetProductReq) (*model.GetProductResp,
 			p.category_id,
 			s.name AS shop_name,
 			c.name AS category_name,
+			p.sku,
 			p.name,
+			COALESCE(p.description, '') AS description,
+			p.attributes,
+			p.status,
+			p.publish_at,
 			p.price,
-			p.stock,
-			p.image_url
+			promo.sale_price,
+			promo.ends_at,
+			COALESCE((SELECT SUM(ws.quantity) FROM warehouse_stocks ws WHERE ws.product_id = p.id AND (ws.variant_id IS NOT NULL OR NOT EXISTS (SELECT 1 FROM product_variants pv WHERE pv.product_id = p.id AND pv.deleted_at IS NULL))), 0) AS stock,
+			COALESCE((SELECT pi.url FROM product_images pi WHERE pi.product_id = p.id AND pi.is_primary), '') AS image_url,
+			(SELECT MIN(pp.starts_at) FROM product_promotions pp WHERE pp.product_id = p.id AND pp.deleted_at IS NULL AND pp.starts_at > NOW()) AS next_sale_starts_at
 		FROM
 			products p
 		JOIN
 			shops s ON p.shop_id = s.id
 		JOIN
 			product_categories c ON p.category_id = c.id
+		LEFT JOIN LATERAL (
+			SELECT
+				pp.sale_price,
+				pp.ends_at
+			FROM
+				product_promotions pp
+			WHERE
+				pp.product_id = p.id
+				AND pp.deleted_at IS NULL
+				AND pp.starts_at <= NOW()
+				AND pp.ends_at > NOW()
+				AND (pp.quantity IS NULL OR pp.claimed < pp.quantity)
+			LIMIT 1
+		) promo ON true
 		WHERE
 			p.id = ?
+			AND p.deleted_at IS NULL
 	`
 	args = append(args, req.Id)
 
 	query = helper.RebindQuery(query)
 
-	row := s.db.QueryRow(query, args...)
+	row := s.db.QueryRowContext(ctx, query, args...)
 	if err := row.Scan(
 		&res.Id,
 		&res.ShopId,
 		&res.CategoryId,
 		&res.ShopName,
 		&res.CategoryName,
+		&res.Sku,
 		&res.Name,
-		&res.Price,
+		&res.Description,
+		&attributes,
+		&res.Status,
+		&res.PublishAt,
+		&res.OriginalPrice,
+		&res.SalePrice,
+		&res.SaleEndsAt,
 		&res.Stock,
 		&res.ImageUrl,
+		&res.NextSaleStartsAt,
 	); err != nil {
 		if err == sql.ErrNoRows {
-			log.Printf("repo::GetProduct - no product found")
-			return nil, fmt.Errorf("no product found")
+			s.logger.InfoContext(ctx, "no product found", "method", "GetProduct")
+			return nil, ErrProductNotFound
 		}
-		log.Printf("repo::GetProduct - failed to fetch product data: %v", err)
+		s.logger.ErrorContext(ctx, "failed to fetch product data", "method", "GetProduct", "err", err)
 		return nil, err
 	}
+	res.ApplySale()
 
-	return res, nil
-}
+	if err := json.Unmarshal(attributes, &res.Attributes); err != nil {
+		s.logger.ErrorContext(ctx, "failed to unmarshal product attributes", "method", "GetProduct", "err", err)
+		return nil, err
+	}
 
-func (s *store) getProductInRedis(req *model.GetProductReq) (*model.GetProductResp, error) {
-	log.Printf("repo::getProductInRedis - fetching product data from redis")
-	var (
-		res = new(model.GetProductResp)
-		key = fmt.Sprintf("product:%s", req.Id)
-	)
-	log.Printf("repo::getProductInRedis - key: %s", key)
+	var err error
+	res.Images, err = s.getProductImages(ctx, s.db, req.Id)
+	if err != nil {
+		return nil, err
+	}
 
-	data, err := s.redis.Get(context.Background(), key).Result()
+	res.Options, err = s.GetProductOptions(ctx, req.Id)
 	if err != nil {
-		log.Printf("repo::getProductInRedis - failed to get product data from redis: %v", err)
 		return nil, err
 	}
 
-	// unmarshal json
-	if err := json.Unmarshal([]byte(data), res); err != nil {
-		log.Printf("repo::getProductInRedis - failed to unmarshal product data: %v", err)
+	res.Variants, err = s.getProductVariants(ctx, req.Id)
+	if err != nil {
 		return nil, err
 	}
 
 	return res, nil
 }
 
-func (s *store) setProductInRedis(req *model.GetProductReq, res *model.GetProductResp) error {
-	log.Printf("repo::setProductInRedis - setting product data in redis")
+func (s *store) CreateProduct(ctx context.Context, req *model.CreateProductReq) (*model.GetProductResp, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
 	var (
-		key        = fmt.Sprintf("product:%s", req.Id)
-		expiration = time.Minute * 5
-		ctx        = context.Background()
+		res  = new(model.GetProductResp)
+		args = make([]interface{}, 0)
 	)
 
-	// to string json
-	data, err := json.Marshal(res)
+	attributes, err := marshalAttributes(req.Attributes)
 	if err != nil {
-		log.Printf("repo::setProductInRedis - failed to marshal product data: %v", err)
-		return err
+		s.logger.ErrorContext(ctx, "failed to marshal product attributes", "method", "CreateProduct", "err", err)
+		return nil, err
 	}
 
-	if err := s.redis.Set(ctx, key, string(data), expiration).Err(); err != nil {
-		log.Printf("repo::setProductInRedis - failed to set product data in redis: %v", err)
-		return err
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "method", "CreateProduct", "err", err)
+		return nil, err
 	}
-
-	return nil
-}
-
-func (s *store) CreateProduct(req *model.CreateProductReq) (*model.GetProductResp, error) {
-	var (
-		res  = new(model.GetProductResp)
-		args = make([]interface{}, 0)
-	)
+	defer tx.Rollback()
 
 	query := `
 		INSERT INTO
-			products (shop_id, category_id, name, price, stock, image_url)
+			products (shop_id, category_id, sku, name, description, attributes, status, publish_at, price)
 		VALUES
-			(?, ?, ?, ?, ?, ?)
+			(?, ?, ?, ?, ?, ?, ?, ?, ?)
 		RETURNING
 			id, (SELECT name FROM shops WHERE id = ?) AS shop_name, (SELECT name FROM product_categories WHERE id = ?) AS category_name
 	`
 	args = append(
-		args, req.ShopId, req.CategoryId, req.Name, req.Price, req.Stock, req.ImageUrl,
+		args, req.ShopId, req.CategoryId, req.Sku, req.Name, req.Description, attributes, req.Status, req.PublishAt, req.Price,
 		req.ShopId, req.CategoryId,
 	)
 
 	query = helper.RebindQuery(query)
 
-	row := s.db.QueryRow(query, args...)
+	row := tx.QueryRowContext(ctx, query, args...)
 	if err := row.Scan(
 		&res.Id,
 		&res.ShopName,
 		&res.CategoryName,
 	); err != nil {
-		log.Printf("repo::CreateProduct - failed to scan product id: %v", err)
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			s.logger.InfoContext(ctx, "sku already exists", "method", "CreateProduct")
+			return nil, ErrSkuExists
+		}
+		s.logger.ErrorContext(ctx, "failed to scan product id", "method", "CreateProduct", "err", err)
+		return nil, err
+	}
+
+	image := &model.ProductImage{Url: req.ImageUrl, AltText: req.Name, IsPrimary: true}
+
+	query = `
+		INSERT INTO
+			product_images (product_id, url, alt_text, position, is_primary)
+		VALUES
+			(?, ?, ?, 0, true)
+		RETURNING
+			id
+	`
+	query = helper.RebindQuery(query)
+
+	row = tx.QueryRowContext(ctx, query, res.Id, image.Url, image.AltText)
+	if err := row.Scan(&image.Id); err != nil {
+		s.logger.ErrorContext(ctx, "failed to insert primary image", "method", "CreateProduct", "err", err)
+		return nil, err
+	}
+
+	if req.Review != nil {
+		if err := s.submitForReview(ctx, tx, res.Id, req.Review); err != nil {
+			return nil, err
+		}
+	}
+
+	warehouseId, err := s.defaultWarehouse(ctx, tx, req.ShopId)
+	if err != nil {
+		return nil, err
+	}
+
+	res.Stock, err = s.recordStockMovement(ctx, tx, &model.StockMovement{
+		ProductId:   res.Id,
+		WarehouseId: warehouseId,
+		Type:        model.StockMovementReceipt,
+		Quantity:    req.Stock,
+		Reason:      "initial stock",
+		CreatedBy:   &req.UserId,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to commit transaction", "method", "CreateProduct", "err", err)
 		return nil, err
 	}
 
 	res.ShopId = req.ShopId
 	res.CategoryId = req.CategoryId
+	res.Sku = req.Sku
 	res.Name = req.Name
-	res.Price = req.Price
-	res.Stock = req.Stock
+	res.Description = req.Description
+	res.Attributes = req.Attributes
+	res.Status = req.Status
+	res.PublishAt = req.PublishAt
+	res.OriginalPrice = req.Price
 	res.ImageUrl = req.ImageUrl
+	res.Images = []*model.ProductImage{image}
+	res.ApplySale()
+
+	if res.Status == model.ProductStatusPublished {
+		s.evictProductsCache(ctx)
+	}
 
 	return res, nil
 }
 
-func (s *store) IsShopOwner(userId, shopId string) error {
+func (s *store) IsShopOwner(ctx context.Context, userId, shopId string) error {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
 	var isShopOwner bool
 
 	query := `
@@ -215,68 +362,128 @@ func (s *store) IsShopOwner(userId, shopId string) error {
 	`
 	query = helper.RebindQuery(query)
 
-	row := s.db.QueryRow(query, userId, shopId)
+	row := s.db.QueryRowContext(ctx, query, userId, shopId)
 	if err := row.Scan(&isShopOwner); err != nil {
-		log.Printf("repo::IsShopOwner - failed to check if user is shop owner: %v", err)
+		s.logger.ErrorContext(ctx, "failed to check if user is shop owner", "method", "IsShopOwner", "err", err)
 		return err
 	}
 
 	if !isShopOwner {
-		log.Printf("repo::IsShopOwner - user is not shop owner")
-		return fmt.Errorf("user is not shop owner")
+		s.logger.InfoContext(ctx, "user is not shop owner", "method", "IsShopOwner")
+		return ErrNotShopOwner
 	}
 
 	return nil
 }
 
-func (s *store) DeleteProduct(req *model.DeleteProductReq) error {
+// DeleteProduct soft-deletes a product. It returns ErrProductNotFound,
+// ErrNotShopOwner or ErrProductAlreadyDeleted instead of succeeding silently,
+// ownership is checked first so other sellers cannot probe deleted products.
+func (s *store) DeleteProduct(ctx context.Context, req *model.DeleteProductReq) error {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var (
+		isOwner   bool
+		isDeleted bool
+	)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "method", "DeleteProduct", "err", err)
+		return err
+	}
+	defer tx.Rollback()
+
 	query := `
-		UPDATE products
-		SET deleted_at = NOW()
-		FROM shops
+		SELECT
+			s.user_id = ?,
+			p.deleted_at IS NOT NULL
+		FROM
+			products p
+		JOIN
+			shops s ON p.shop_id = s.id
 		WHERE
-			products.id = ?
-			AND products.shop_id = shops.id
-			AND shops.user_id = ?
-			AND products.deleted_at IS NULL
+			p.id = ?
+		FOR UPDATE OF p
 	`
+	query = helper.RebindQuery(query)
+
+	row := tx.QueryRowContext(ctx, query, req.UserId, req.Id)
+	if err := row.Scan(&isOwner, &isDeleted); err != nil {
+		if err == sql.ErrNoRows {
+			s.logger.InfoContext(ctx, "no product found", "method", "DeleteProduct")
+			return ErrProductNotFound
+		}
+		s.logger.ErrorContext(ctx, "failed to lock product", "method", "DeleteProduct", "err", err)
+		return err
+	}
 
+	if !isOwner {
+		s.logger.InfoContext(ctx, "user is not shop owner", "method", "DeleteProduct")
+		return ErrNotShopOwner
+	}
+
+	if isDeleted {
+		s.logger.InfoContext(ctx, "product already deleted", "method", "DeleteProduct", "product_id", req.Id)
+		return ErrProductAlreadyDeleted
+	}
+
+	query = `
+		UPDATE
+			products
+		SET
+			deleted_at = NOW(),
+			updated_at = NOW()
+		WHERE
+			id = ?
+	`
 	query = helper.RebindQuery(query)
 
-	if _, err := s.db.Exec(query, req.Id, req.UserId); err != nil {
-		log.Printf("repo::DeleteProduct - failed to delete product: %v", err)
+	if _, err := tx.ExecContext(ctx, query, req.Id); err != nil {
+		s.logger.ErrorContext(ctx, "failed to delete product", "method", "DeleteProduct", "err", err)
+		return err
+	}
+
+	if err := s.cancelReview(ctx, tx, req.Id); err != nil {
 		return err
 	}
 
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to commit transaction", "method", "DeleteProduct", "err", err)
+		return err
+	}
+
+	s.evictProductCache(ctx, req.Id)
+	s.evictProductsCache(ctx)
+
 	return nil
 }
 
-func (s *store) GetProducts(req *model.GetProductsReq) (*model.GetProductsResp, error) {
-	resp, err := s.getProductsInRedis(req)
-	if err != nil {
-		if err != redis.Nil {
-			log.Printf("repo::GetProducts - failed to get products data from redis: %v", err)
-			return nil, err
-		}
+func (s *store) GetProducts(ctx context.Context, req *model.GetProductsReq) (*model.GetProductsResp, error) {
+	return s.lists.Load(ctx, productsCacheKey(req), func(ctx context.Context) (*model.GetProductsResp, error) {
+		ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+		defer cancel()
 
-		resp, err = s.getProductsInDB(req)
+		res, err := s.getProductsInDB(ctx, req)
 		if err != nil {
-			log.Printf("repo::GetProducts - failed to get products data from db: %v", err)
+			s.logger.ErrorContext(ctx, "failed to get products data from db", "method", "GetProducts", "err", err)
 			return nil, err
 		}
 
-		err = s.setProductsInRedis(req, resp)
-		if err != nil {
-			log.Printf("repo::GetProducts - failed to set products data in redis: %v", err)
-			return nil, err
+		return res, nil
+	}, func(_ context.Context, res *model.GetProductsResp) time.Duration {
+		boundaries := make([]*time.Time, 0, len(res.Items)*2)
+		for _, item := range res.Items {
+			boundaries = append(boundaries, item.SaleEndsAt, item.NextSaleStartsAt)
 		}
-	}
 
-	return resp, nil
+		return cacheExpiration(time.Now(), boundaries...)
+	})
 }
 
-func (s *store) getProductsInDB(req *model.GetProductsReq) (*model.GetProductsResp, error) {
-	log.Printf("repo::getProductsInDB - fetching products data from db")
+func (s *store) getProductsInDB(ctx context.Context, req *model.GetProductsReq) (*model.GetProductsResp, error) {
+	s.logger.InfoContext(ctx, "fetching products data from db", "method", "getProductsInDB")
 	var (
 		totalData int
 		res       = new(model.GetProductsResp)
@@ -285,27 +492,52 @@ func (s *store) getProductsInDB(req *model.GetProductsReq) (*model.GetProductsRe
 	res.Items = make([]*model.ProductItem, 0)
 	res.Meta = new(model.Meta)
 
-	query := `
+	filters, filterArgs := attributeFilters(req.Attributes)
+	args = append(args, filterArgs...)
+
+	query := fmt.Sprintf(`
 		SELECT
 			COUNT(*) OVER() AS total_data,
 			p.id,
+			p.sku,
 			p.name,
+			p.status,
+			p.publish_at,
 			p.price,
-			p.stock,
-			p.image_url
+			promo.sale_price,
+			promo.ends_at,
+			COALESCE((SELECT SUM(ws.quantity) FROM warehouse_stocks ws WHERE ws.product_id = p.id AND (ws.variant_id IS NOT NULL OR NOT EXISTS (SELECT 1 FROM product_variants pv WHERE pv.product_id = p.id AND pv.deleted_at IS NULL))), 0) AS stock,
+			COALESCE((SELECT pi.url FROM product_images pi WHERE pi.product_id = p.id AND pi.is_primary), '') AS image_url,
+			(SELECT MIN(pp.starts_at) FROM product_promotions pp WHERE pp.product_id = p.id AND pp.deleted_at IS NULL AND pp.starts_at > NOW()) AS next_sale_starts_at
 		FROM
 			products p
+		LEFT JOIN LATERAL (
+			SELECT
+				pp.sale_price,
+				pp.ends_at
+			FROM
+				product_promotions pp
+			WHERE
+				pp.product_id = p.id
+				AND pp.deleted_at IS NULL
+				AND pp.starts_at <= NOW()
+				AND pp.ends_at > NOW()
+				AND (pp.quantity IS NULL OR pp.claimed < pp.quantity)
+			LIMIT 1
+		) promo ON true
 		WHERE
 			p.deleted_at IS NULL
+			AND p.status = 'published'
+			%s
 		LIMIT ? OFFSET ?
-	`
+	`, filters)
 	args = append(args, req.Limit, (req.Page-1)*req.Limit)
 
 	query = helper.RebindQuery(query)
 
-	rows, err := s.db.Query(query, args...)
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		log.Printf("repo::getProductsInDB - failed to fetch products data: %v", err)
+		s.logger.ErrorContext(ctx, "failed to fetch products data", "method", "getProductsInDB", "err", err)
 		return nil, err
 	}
 
@@ -314,15 +546,22 @@ func (s *store) getProductsInDB(req *model.GetProductsReq) (*model.GetProductsRe
 		if err := rows.Scan(
 			&totalData,
 			&d.Id,
+			&d.Sku,
 			&d.Name,
-			&d.Price,
+			&d.Status,
+			&d.PublishAt,
+			&d.OriginalPrice,
+			&d.SalePrice,
+			&d.SaleEndsAt,
 			&d.Stock,
 			&d.ImageUrl,
+			&d.NextSaleStartsAt,
 		); err != nil {
 			rows.Close()
-			log.Printf("repo::getProductsInDB - failed to scan product data: %v", err)
+			s.logger.ErrorContext(ctx, "failed to scan product data", "method", "getProductsInDB", "err", err)
 			return nil, err
 		}
+		d.ApplySale()
 		res.Items = append(res.Items, &d)
 	}
 	rows.Close()
@@ -332,48 +571,91 @@ func (s *store) getProductsInDB(req *model.GetProductsReq) (*model.GetProductsRe
 	return res, nil
 }
 
-func (s *store) getProductsInRedis(req *model.GetProductsReq) (*model.GetProductsResp, error) {
-	log.Printf("repo::getProductsInRedis - fetching products data from redis")
+// attributeFilters builds the SQL conditions for attribute filters. Values
+// are compared as text so numbers and booleans match their query string form.
+func attributeFilters(attributes map[string]string) (string, []interface{}) {
 	var (
-		res = new(model.GetProductsResp)
-		key = fmt.Sprintf("products:page:%d:limit:%d", req.Page, req.Limit)
+		filters strings.Builder
+		args    = make([]interface{}, 0, len(attributes)*2)
 	)
-	log.Printf("repo::getProductsInRedis - key: %s", key)
 
-	data, err := s.redis.Get(context.Background(), key).Result()
+	for _, name := range slices.Sorted(maps.Keys(attributes)) {
+		filters.WriteString(" AND p.attributes ->> ? = ?")
+		args = append(args, name, attributes[name])
+	}
+
+	return filters.String(), args
+}
+
+func (s *store) UpdateProduct(ctx context.Context, req *model.UpdateProductReq) (*model.GetProductResp, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	attributes, err := marshalAttributes(req.Attributes)
 	if err != nil {
-		log.Printf("repo::getProductsInRedis - failed to get products data from redis: %v", err)
+		s.logger.ErrorContext(ctx, "failed to marshal product attributes", "method", "UpdateProduct", "err", err)
 		return nil, err
 	}
 
-	// unmarshal json
-	if err := json.Unmarshal([]byte(data), res); err != nil {
-		log.Printf("repo::getProductsInRedis - failed to unmarshal products data: %v", err)
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "method", "UpdateProduct", "err", err)
 		return nil, err
 	}
+	defer tx.Rollback()
 
-	return res, nil
-}
+	query := `
+		UPDATE
+			products
+		SET
+			category_id = ?,
+			sku = COALESCE(?, sku),
+			name = ?,
+			description = ?,
+			attributes = ?,
+			price = ?,
+			status = CASE WHEN ? THEN 'pending_review' ELSE status END,
+			updated_at = NOW()
+		WHERE
+			id = ?
+			AND deleted_at IS NULL
+	`
+	query = helper.RebindQuery(query)
 
-func (s *store) setProductsInRedis(req *model.GetProductsReq, res *model.GetProductsResp) error {
-	log.Printf("repo::setProductsInRedis - setting products data in redis")
-	var (
-		key        = fmt.Sprintf("products:page:%d:limit:%d", req.Page, req.Limit)
-		expiration = time.Minute * 5
-		ctx        = context.Background()
-	)
+	result, err := tx.ExecContext(ctx, query, req.CategoryId, req.Sku, req.Name, req.Description, attributes, req.Price, req.Review != nil, req.Id)
+	if err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			s.logger.InfoContext(ctx, "sku already exists", "method", "UpdateProduct")
+			return nil, ErrSkuExists
+		}
+		s.logger.ErrorContext(ctx, "failed to update product", "method", "UpdateProduct", "err", err)
+		return nil, err
+	}
 
-	// to string json
-	data, err := json.Marshal(res)
+	affected, err := result.RowsAffected()
 	if err != nil {
-		log.Printf("repo::setProductsInRedis - failed to marshal products data: %v", err)
-		return err
+		s.logger.ErrorContext(ctx, "failed to read affected rows", "method", "UpdateProduct", "err", err)
+		return nil, err
 	}
 
-	if err := s.redis.Set(ctx, key, string(data), expiration).Err(); err != nil {
-		log.Printf("repo::setProductsInRedis - failed to set products data in redis: %v", err)
-		return err
+	if affected == 0 {
+		s.logger.InfoContext(ctx, "no product found", "method", "UpdateProduct")
+		return nil, ErrProductNotFound
 	}
 
-	return nil
+	if req.Review != nil {
+		if err := s.submitForReview(ctx, tx, req.Id, req.Review); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to commit transaction", "method", "UpdateProduct", "err", err)
+		return nil, err
+	}
+
+	s.evictProductCache(ctx, req.Id)
+	s.evictProductsCache(ctx)
+
+	return s.getProductInDB(ctx, &model.GetProductReq{Id: req.Id})
 }
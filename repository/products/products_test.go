@@ -0,0 +1,139 @@
+package products
+
+import (
+	model "codebase-service/models"
+	"codebase-service/util/cache"
+	"codebase-service/util/logging"
+	"context"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestProductsStore(t *testing.T) {
+	suite.Run(t, new(ProductStoreTestSuite))
+}
+
+// ProductStoreTestSuite checks the queries of the store against a mocked db,
+// the expectations match the statements by their leading keywords.
+type ProductStoreTestSuite struct {
+	suite.Suite
+	ctx   context.Context
+	db    sqlmock.Sqlmock
+	redis *miniredis.Miniredis
+	store *store
+}
+
+func (s *ProductStoreTestSuite) SetupTest() {
+	db, mock, err := sqlmock.New()
+	s.Require().NoError(err)
+	s.T().Cleanup(func() { db.Close() })
+
+	s.ctx = context.Background()
+	s.db = mock
+	s.redis = miniredis.RunT(s.T())
+	s.store = NewStore(db, cache.NewClient(redis.NewClient(&redis.Options{Addr: s.redis.Addr()}), cache.Config{}), logging.Discard())
+}
+
+func (s *ProductStoreTestSuite) TearDownTest() {
+	s.NoError(s.db.ExpectationsWereMet())
+}
+
+func (s *ProductStoreTestSuite) expectLockProduct(productId, shopId string) {
+	s.db.ExpectQuery(`SELECT\s+shop_id\s+FROM\s+products`).
+		WithArgs(productId).
+		WillReturnRows(sqlmock.NewRows([]string{"shop_id"}).AddRow(shopId))
+}
+
+func (s *ProductStoreTestSuite) expectCheckVariant(hasVariants, found bool) {
+	s.db.ExpectQuery(`EXISTS \(SELECT 1 FROM product_variants`).
+		WillReturnRows(sqlmock.NewRows([]string{"has_variants", "found"}).AddRow(hasVariants, found))
+}
+
+// expectProductStock matches only the variant aware sum, a product with
+// variants does not count the stock left on its product level.
+func (s *ProductStoreTestSuite) expectProductStock(productId string, stock int64) {
+	s.db.ExpectQuery(`FROM\s+warehouse_stocks ws\s+WHERE\s+ws.product_id = \$1\s+AND \(\s+ws.variant_id IS NOT NULL\s+OR NOT EXISTS \(SELECT 1 FROM product_variants`).
+		WithArgs(productId).
+		WillReturnRows(sqlmock.NewRows([]string{"stock"}).AddRow(stock))
+}
+
+func (s *ProductStoreTestSuite) TestDeleteProduct_OtherSeller() {
+	req := &model.DeleteProductReq{UserId: "u2", Id: "p1"}
+
+	s.db.ExpectBegin()
+	s.db.ExpectQuery(`SELECT\s+s.user_id = \$1`).
+		WithArgs("u2", "p1").
+		WillReturnRows(sqlmock.NewRows([]string{"is_owner", "is_deleted"}).AddRow(false, false))
+	s.db.ExpectRollback()
+
+	err := s.store.DeleteProduct(s.ctx, req)
+
+	s.ErrorIs(err, ErrNotShopOwner)
+}
+
+func (s *ProductStoreTestSuite) TestDeleteProduct_Missing() {
+	req := &model.DeleteProductReq{UserId: "u1", Id: "p1"}
+
+	s.db.ExpectBegin()
+	s.db.ExpectQuery(`SELECT\s+s.user_id = \$1`).
+		WithArgs("u1", "p1").
+		WillReturnRows(sqlmock.NewRows([]string{"is_owner", "is_deleted"}))
+	s.db.ExpectRollback()
+
+	err := s.store.DeleteProduct(s.ctx, req)
+
+	s.ErrorIs(err, ErrProductNotFound)
+}
+
+func (s *ProductStoreTestSuite) TestUpdateProduct_KeepsSku() {
+	req := &model.UpdateProductReq{UserId: "u1", Id: "p1", CategoryId: "c1", Name: "product", Price: 100000}
+	sku := "A-1"
+
+	s.db.ExpectBegin()
+	s.db.ExpectExec(`UPDATE\s+products\s+SET\s+category_id = \$1,\s+sku = COALESCE\(\$2, sku\)`).
+		WithArgs("c1", nil, "product", "", []byte(`{}`), 100000.0, false, "p1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.db.ExpectCommit()
+	s.expectGetProductInDB("p1", &sku)
+
+	resp, err := s.store.UpdateProduct(s.ctx, req)
+
+	s.Require().NoError(err)
+	s.Equal(&sku, resp.Sku)
+}
+
+// expectGetProductInDB answers the reads of getProductInDB with a draft
+// product without images, options or variants.
+func (s *ProductStoreTestSuite) expectGetProductInDB(productId string, sku *string) {
+	var skuValue any
+	if sku != nil {
+		skuValue = *sku
+	}
+
+	s.db.ExpectQuery(`FROM\s+products p\s+JOIN\s+shops s`).
+		WithArgs(productId).
+		WillReturnRows(sqlmock.NewRows([]string{
+			"id", "shop_id", "category_id", "shop_name", "category_name", "sku", "name", "description", "attributes",
+			"status", "publish_at", "price", "sale_price", "ends_at", "stock", "image_url", "next_sale_starts_at",
+		}).AddRow(productId, "s1", "c1", "shop", "category", skuValue, "product", "", []byte(`{}`),
+			model.ProductStatusDraft, nil, 100000.0, nil, nil, 5, "", nil))
+	s.db.ExpectQuery(`FROM\s+product_images`).
+		WithArgs(productId).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "url", "alt_text", "position", "is_primary"}))
+	s.db.ExpectQuery(`FROM\s+product_options`).
+		WithArgs(productId).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "position", "values"}))
+	s.db.ExpectQuery(`FROM\s+product_variants v`).
+		WithArgs(productId).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "sku", "options", "price", "image_url", "stock"}))
+}
+
+func (s *ProductStoreTestSuite) mustGet(key string) string {
+	value, err := s.redis.Get(key)
+	s.NoError(err)
+	return value
+}
@@ -0,0 +1,382 @@
+package products
+
+import (
+	"codebase-service/helper"
+	model "codebase-service/models"
+	"codebase-service/util/cache"
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// claimFlashSaleScript takes ARGV[1] units from the remaining flash-sale
+// quota in one step, so concurrent buyers can never claim more than the quota.
+// It returns -1 when the quota is not loaded and -2 when not enough is left.
+var claimFlashSaleScript = redis.NewScript(`
+	local remaining = redis.call('GET', KEYS[1])
+	if not remaining then
+		return -1
+	end
+
+	local quantity = tonumber(ARGV[1])
+	if tonumber(remaining) < quantity then
+		return -2
+	end
+
+	return redis.call('DECRBY', KEYS[1], quantity)
+`)
+
+func (s *store) IsProductOwner(ctx context.Context, userId, productId string) error {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var isShopOwner bool
+
+	query := `
+		SELECT
+			s.user_id = ?
+		FROM
+			products p
+		JOIN
+			shops s ON p.shop_id = s.id
+		WHERE
+			p.id = ?
+			AND p.deleted_at IS NULL
+	`
+	query = helper.RebindQuery(query)
+
+	row := s.db.QueryRowContext(ctx, query, userId, productId)
+	if err := row.Scan(&isShopOwner); err != nil {
+		if err == sql.ErrNoRows {
+			s.logger.InfoContext(ctx, "no product found", "method", "IsProductOwner")
+			return ErrProductNotFound
+		}
+		s.logger.ErrorContext(ctx, "failed to check if user is product owner", "method", "IsProductOwner", "err", err)
+		return err
+	}
+
+	if !isShopOwner {
+		s.logger.InfoContext(ctx, "user is not shop owner", "method", "IsProductOwner")
+		return ErrNotShopOwner
+	}
+
+	return nil
+}
+
+func (s *store) CreatePromotion(ctx context.Context, req *model.CreatePromotionReq) (*model.Promotion, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var (
+		res      = new(model.Promotion)
+		price    float64
+		overlaps bool
+	)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "method", "CreatePromotion", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// lock the product so two promotions for the same window cannot race in
+	query := `
+		SELECT
+			price
+		FROM
+			products
+		WHERE
+			id = ?
+			AND deleted_at IS NULL
+		FOR UPDATE
+	`
+	query = helper.RebindQuery(query)
+
+	row := tx.QueryRowContext(ctx, query, req.ProductId)
+	if err := row.Scan(&price); err != nil {
+		if err == sql.ErrNoRows {
+			s.logger.InfoContext(ctx, "no product found", "method", "CreatePromotion")
+			return nil, ErrProductNotFound
+		}
+		s.logger.ErrorContext(ctx, "failed to lock product", "method", "CreatePromotion", "err", err)
+		return nil, err
+	}
+
+	if req.SalePrice >= price {
+		s.logger.InfoContext(ctx, "sale price is not lower than the original price", "method", "CreatePromotion")
+		return nil, ErrSalePriceTooHigh
+	}
+
+	query = `
+		SELECT EXISTS (
+			SELECT 1
+			FROM
+				product_promotions
+			WHERE
+				product_id = ?
+				AND deleted_at IS NULL
+				AND starts_at < ?
+				AND ends_at > ?
+		)
+	`
+	query = helper.RebindQuery(query)
+
+	row = tx.QueryRowContext(ctx, query, req.ProductId, req.EndsAt, req.StartsAt)
+	if err := row.Scan(&overlaps); err != nil {
+		s.logger.ErrorContext(ctx, "failed to check overlapping promotions", "method", "CreatePromotion", "err", err)
+		return nil, err
+	}
+
+	if overlaps {
+		s.logger.InfoContext(ctx, "promotion overlaps an existing promotion", "method", "CreatePromotion")
+		return nil, ErrPromotionOverlaps
+	}
+
+	query = `
+		INSERT INTO
+			product_promotions (product_id, type, sale_price, quantity, starts_at, ends_at)
+		VALUES
+			(?, ?, ?, ?, ?, ?)
+		RETURNING
+			id
+	`
+	query = helper.RebindQuery(query)
+
+	row = tx.QueryRowContext(ctx, query, req.ProductId, req.Type, req.SalePrice, req.Quantity, req.StartsAt, req.EndsAt)
+	if err := row.Scan(&res.Id); err != nil {
+		s.logger.ErrorContext(ctx, "failed to scan promotion id", "method", "CreatePromotion", "err", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to commit transaction", "method", "CreatePromotion", "err", err)
+		return nil, err
+	}
+
+	// cached entries were given a TTL without knowing about this promotion
+	s.evictProductCache(ctx, req.ProductId)
+	s.evictProductsCache(ctx)
+
+	res.ProductId = req.ProductId
+	res.Type = req.Type
+	res.SalePrice = req.SalePrice
+	res.Quantity = req.Quantity
+	res.StartsAt = req.StartsAt
+	res.EndsAt = req.EndsAt
+
+	return res, nil
+}
+
+func (s *store) getPromotion(ctx context.Context, id string) (*model.Promotion, error) {
+	var res = new(model.Promotion)
+
+	query := `
+		SELECT
+			id,
+			product_id,
+			type,
+			sale_price,
+			quantity,
+			claimed,
+			starts_at,
+			ends_at
+		FROM
+			product_promotions
+		WHERE
+			id = ?
+			AND deleted_at IS NULL
+	`
+	query = helper.RebindQuery(query)
+
+	row := s.db.QueryRowContext(ctx, query, id)
+	if err := row.Scan(
+		&res.Id,
+		&res.ProductId,
+		&res.Type,
+		&res.SalePrice,
+		&res.Quantity,
+		&res.Claimed,
+		&res.StartsAt,
+		&res.EndsAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			s.logger.InfoContext(ctx, "no promotion found", "method", "getPromotion")
+			return nil, ErrPromotionNotFound
+		}
+		s.logger.ErrorContext(ctx, "failed to fetch promotion data", "method", "getPromotion", "err", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ClaimFlashSale takes units from a flash sale's quota and holds them in a
+// reservation for the buyer's checkout, in one transaction, so claims never
+// exceed the quota nor the stock.
+func (s *store) ClaimFlashSale(ctx context.Context, req *model.ClaimFlashSaleReq) (*model.ClaimFlashSaleResp, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var (
+		key        = flashSaleQuotaKey(req.PromotionId)
+		remaining  int64
+		quotaTaken bool
+	)
+
+	promo, err := s.getPromotion(ctx, req.PromotionId)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	if promo.Type != model.PromotionTypeFlashSale || now.Before(promo.StartsAt) || !now.Before(promo.EndsAt) {
+		s.logger.InfoContext(ctx, "flash sale is not active", "method", "ClaimFlashSale")
+		return nil, ErrFlashSaleNotActive
+	}
+
+	// redis holds the quota so most claims past it are turned away without
+	// touching the db. The first claim loads the quota, later ones only
+	// decrement it. Without redis the conditional update below still keeps
+	// claims within the quantity.
+	err = s.cache.Do(ctx, func(ctx context.Context, rdb *redis.Client) error {
+		if err := rdb.SetNX(ctx, key, *promo.Quantity-promo.Claimed, time.Until(promo.EndsAt)).Err(); err != nil {
+			return err
+		}
+
+		var err error
+		remaining, err = claimFlashSaleScript.Run(ctx, rdb, []string{key}, req.Quantity).Int64()
+		return err
+	})
+	if err != nil {
+		if err != cache.ErrUnavailable {
+			s.logger.ErrorContext(ctx, "failed to claim flash sale quota, claiming in db only", "method", "ClaimFlashSale", "err", err)
+		}
+	} else {
+		switch remaining {
+		case -1:
+			s.logger.InfoContext(ctx, "flash sale quota expired", "method", "ClaimFlashSale")
+			return nil, ErrFlashSaleNotActive
+		case -2:
+			s.logger.InfoContext(ctx, "flash sale sold out", "method", "ClaimFlashSale")
+			return nil, ErrFlashSaleSoldOut
+		}
+		quotaTaken = true
+	}
+
+	res, err := s.claimFlashSale(ctx, promo, req)
+	if err != nil {
+		if quotaTaken {
+			rErr := s.cache.Do(ctx, func(ctx context.Context, rdb *redis.Client) error {
+				return rdb.IncrBy(ctx, key, req.Quantity).Err()
+			})
+			if rErr != nil {
+				s.logger.ErrorContext(ctx, "failed to release quota", "method", "ClaimFlashSale", "err", rErr)
+			}
+		}
+		return nil, err
+	}
+
+	// the reservation took stock, and a sold out flash sale ends the sale price
+	s.evictReservationCache(ctx, res.Reservation)
+
+	return res, nil
+}
+
+func (s *store) claimFlashSale(ctx context.Context, promo *model.Promotion, req *model.ClaimFlashSaleReq) (*model.ClaimFlashSaleResp, error) {
+	var res = &model.ClaimFlashSaleResp{
+		PromotionId: promo.Id,
+		ProductId:   promo.ProductId,
+		Quantity:    req.Quantity,
+		SalePrice:   promo.SalePrice,
+		Reservation: &model.Reservation{
+			UserId:      req.UserId,
+			Reference:   req.Reference,
+			Status:      model.ReservationStatusActive,
+			PromotionId: &promo.Id,
+			ExpiresAt:   req.ExpiresAt,
+			Items:       make([]*model.ReservationItem, 0),
+		},
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "method", "ClaimFlashSale", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		UPDATE product_promotions
+		SET claimed = claimed + ?, updated_at = NOW()
+		WHERE
+			id = ?
+			AND claimed + ? <= quantity
+		RETURNING
+			quantity - claimed
+	`
+	query = helper.RebindQuery(query)
+
+	row := tx.QueryRowContext(ctx, query, req.Quantity, req.PromotionId, req.Quantity)
+	if err := row.Scan(&res.Remaining); err != nil {
+		if err == sql.ErrNoRows {
+			s.logger.InfoContext(ctx, "flash sale sold out", "method", "ClaimFlashSale")
+			return nil, ErrFlashSaleSoldOut
+		}
+		s.logger.ErrorContext(ctx, "failed to record claim", "method", "ClaimFlashSale", "err", err)
+		return nil, err
+	}
+
+	if err := s.createReservation(ctx, tx, res.Reservation, []*model.ReservationItemReq{{
+		ProductId: promo.ProductId,
+		VariantId: req.VariantId,
+		Quantity:  req.Quantity,
+	}}); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to commit transaction", "method", "ClaimFlashSale", "err", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func flashSaleQuotaKey(promotionId string) string {
+	return fmt.Sprintf("flash_sale:%s:remaining", promotionId)
+}
+
+// resetFlashSaleQuota drops the quota redis holds for a flash sale, the next
+// claim loads it again from the db.
+func (s *store) resetFlashSaleQuota(ctx context.Context, promotionId string) {
+	err := s.cache.Do(ctx, func(ctx context.Context, rdb *redis.Client) error {
+		return rdb.Del(ctx, flashSaleQuotaKey(promotionId)).Err()
+	})
+	if err != nil && err != cache.ErrUnavailable {
+		s.logger.ErrorContext(ctx, "failed to reset flash sale quota", "method", "resetFlashSaleQuota", "err", err)
+	}
+}
+
+// cacheExpiration caps the cache TTL at the next time a promotion of the
+// cached products starts or ends, so cached prices never outlive the
+// promotion window. Without a boundary the namespace TTL applies.
+func cacheExpiration(now time.Time, boundaries ...*time.Time) time.Duration {
+	var expiration time.Duration
+
+	for _, boundary := range boundaries {
+		if boundary == nil || !boundary.After(now) {
+			continue
+		}
+
+		until := max(boundary.Sub(now), time.Second)
+		if expiration == 0 || until < expiration {
+			expiration = until
+		}
+	}
+
+	return expiration
+}
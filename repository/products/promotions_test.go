@@ -0,0 +1,132 @@
+package products
+
+import (
+	model "codebase-service/models"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func (s *ProductStoreTestSuite) TestCacheExpiration() {
+	now := time.Date(2024, 11, 20, 10, 0, 0, 0, time.UTC)
+	endsAt := now.Add(2 * time.Minute)
+	startsAt := now.Add(30 * time.Second)
+	past := now.Add(-time.Minute)
+	soon := now.Add(time.Millisecond)
+
+	s.Equal(time.Duration(0), cacheExpiration(now))
+	s.Equal(time.Duration(0), cacheExpiration(now, nil, &past))
+	s.Equal(2*time.Minute, cacheExpiration(now, &endsAt, nil))
+	s.Equal(30*time.Second, cacheExpiration(now, &endsAt, &startsAt))
+	s.Equal(time.Second, cacheExpiration(now, &soon))
+}
+
+func (s *ProductStoreTestSuite) expectFlashSale(quantity, claimed int64) {
+	s.db.ExpectQuery(`FROM\s+product_promotions\s+WHERE\s+id = `).
+		WithArgs("promo-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "product_id", "type", "sale_price", "quantity", "claimed", "starts_at", "ends_at"}).
+			AddRow("promo-1", "p1", model.PromotionTypeFlashSale, 50000.0, quantity, claimed, time.Now().Add(-time.Hour), time.Now().Add(time.Hour)))
+}
+
+// expectReserveProduct prices the claimed units at the sale price of the
+// claimed promotion.
+func (s *ProductStoreTestSuite) expectReserveProduct(stock int64) {
+	s.db.ExpectQuery(`SELECT\s+COALESCE\(\s+\(SELECT pp.sale_price FROM product_promotions pp WHERE pp.id = \$1::uuid`).
+		WithArgs("promo-1", nil, "p1").
+		WillReturnRows(sqlmock.NewRows([]string{"unit_price"}).AddRow(50000.0))
+	s.db.ExpectQuery(`EXISTS \(SELECT 1 FROM product_variants`).
+		WillReturnRows(sqlmock.NewRows([]string{"has_variants", "found"}).AddRow(false, false))
+	s.db.ExpectQuery(`FROM\s+warehouse_stocks\s+WHERE\s+product_id = `).
+		WillReturnRows(sqlmock.NewRows([]string{"warehouse_id", "quantity"}).AddRow("w1", stock))
+}
+
+func (s *ProductStoreTestSuite) claimReq() *model.ClaimFlashSaleReq {
+	return &model.ClaimFlashSaleReq{
+		UserId:      "u1",
+		PromotionId: "promo-1",
+		Reference:   "checkout-1",
+		Quantity:    2,
+		ExpiresAt:   time.Now().Add(15 * time.Minute),
+	}
+}
+
+func (s *ProductStoreTestSuite) TestClaimFlashSale_HoldsStock() {
+	req := s.claimReq()
+
+	s.expectFlashSale(10, 4)
+	s.db.ExpectBegin()
+	s.db.ExpectQuery(`UPDATE product_promotions\s+SET claimed = claimed \+`).
+		WithArgs(int64(2), "promo-1", int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"remaining"}).AddRow(4))
+	s.db.ExpectQuery(`INSERT INTO\s+stock_reservations`).
+		WithArgs("u1", "checkout-1", "promo-1", req.ExpiresAt).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("r1"))
+	s.expectReserveProduct(5)
+	s.db.ExpectQuery(`INSERT INTO\s+warehouse_stocks`).
+		WithArgs("w1", "p1", nil, int64(-2)).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity"}).AddRow(3))
+	s.db.ExpectQuery(`INSERT INTO\s+stock_movements`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("m1", time.Now()))
+	s.db.ExpectExec(`INSERT INTO\s+stock_reservation_items`).
+		WithArgs("r1", "p1", nil, "w1", int64(2), 50000.0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.db.ExpectCommit()
+
+	resp, err := s.store.ClaimFlashSale(s.ctx, req)
+
+	s.Require().NoError(err)
+	s.Equal(int64(4), resp.Remaining)
+	s.Equal("r1", resp.Reservation.Id)
+	s.Equal("promo-1", *resp.Reservation.PromotionId)
+	s.Equal([]*model.ReservationItem{{ProductId: "p1", WarehouseId: "w1", Quantity: 2, UnitPrice: 50000}}, resp.Reservation.Items)
+	s.Equal("4", s.mustGet(flashSaleQuotaKey("promo-1")))
+}
+
+func (s *ProductStoreTestSuite) TestClaimFlashSale_InsufficientStock() {
+	req := s.claimReq()
+
+	// the quota has 6 units left but only 1 is in stock
+	s.expectFlashSale(10, 4)
+	s.db.ExpectBegin()
+	s.db.ExpectQuery(`UPDATE product_promotions\s+SET claimed = claimed \+`).
+		WillReturnRows(sqlmock.NewRows([]string{"remaining"}).AddRow(4))
+	s.db.ExpectQuery(`INSERT INTO\s+stock_reservations`).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("r1"))
+	s.expectReserveProduct(1)
+	s.db.ExpectRollback()
+
+	resp, err := s.store.ClaimFlashSale(s.ctx, req)
+
+	s.ErrorIs(err, ErrInsufficientStock)
+	s.Nil(resp)
+	s.Equal("6", s.mustGet(flashSaleQuotaKey("promo-1")))
+}
+
+func (s *ProductStoreTestSuite) TestReleaseReservation_GivesClaimBack() {
+	s.redis.Set(flashSaleQuotaKey("promo-1"), "4")
+
+	s.db.ExpectBegin()
+	s.db.ExpectQuery(`FROM\s+stock_reservations\s+WHERE\s+id = `).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "reference", "status", "promotion_id", "expires_at"}).
+			AddRow("r1", "u1", "checkout-1", model.ReservationStatusActive, "promo-1", time.Now().Add(time.Minute)))
+	s.db.ExpectQuery(`FROM\s+stock_reservation_items`).
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "variant_id", "warehouse_id", "quantity", "unit_price"}).
+			AddRow("p1", nil, "w1", 2, 50000.0))
+	s.db.ExpectQuery(`INSERT INTO\s+warehouse_stocks`).
+		WithArgs("w1", "p1", nil, int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity"}).AddRow(5))
+	s.db.ExpectQuery(`INSERT INTO\s+stock_movements`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("m1", time.Now()))
+	s.db.ExpectExec(`UPDATE product_promotions\s+SET claimed = claimed -`).
+		WithArgs(int64(2), "promo-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.db.ExpectExec(`UPDATE stock_reservations`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.db.ExpectCommit()
+
+	res, err := s.store.ReleaseReservation(s.ctx, &model.ReservationReq{UserId: "u1", Id: "r1"})
+
+	s.Require().NoError(err)
+	s.Equal(model.ReservationStatusReleased, res.Status)
+	s.False(s.redis.Exists(flashSaleQuotaKey("promo-1")))
+}
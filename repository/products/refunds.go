@@ -0,0 +1,489 @@
+package products
+
+import (
+	"codebase-service/helper"
+	model "codebase-service/models"
+	"context"
+	"database/sql"
+	"errors"
+	"math"
+)
+
+// refundLine is a product or variant of a paid reservation, summed over the
+// warehouses it was taken from.
+type refundLine struct {
+	productId string
+	variantId *string
+	quantity  int64
+	refunded  int64
+	unitPrice float64
+	owned     bool
+}
+
+func (l *refundLine) matches(productId string, variantId *string) bool {
+	if l.productId != productId || (l.variantId == nil) != (variantId == nil) {
+		return false
+	}
+	return variantId == nil || *l.variantId == *variantId
+}
+
+// CreateRefund records a pending refund of a paid reservation. The units of
+// pending and succeeded refunds are not refundable again, so two refunds of
+// the same order cannot pay back more than it bought.
+func (s *store) CreateRefund(ctx context.Context, req *model.CreateRefundReq) (*model.Refund, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "method", "CreateRefund", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	// the lock serializes refunds of the reservation
+	reservation, err := s.lockReservation(ctx, tx, req.ReservationId, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if reservation.Status == model.ReservationStatusRefunded {
+		s.logger.InfoContext(ctx, "reservation already refunded", "method", "CreateRefund")
+		return nil, ErrReservationRefunded
+	}
+
+	if !reservation.Paid() {
+		s.logger.InfoContext(ctx, "reservation is not paid", "method", "CreateRefund")
+		return nil, ErrReservationNotPaid
+	}
+
+	lines, err := s.refundLines(ctx, tx, reservation.Id, req.SellerId)
+	if err != nil {
+		return nil, err
+	}
+
+	items, err := refundItems(lines, req.Items)
+	if err != nil {
+		s.logger.InfoContext(ctx, "refund rejected", "method", "CreateRefund", "err", err)
+		return nil, err
+	}
+
+	res := &model.Refund{
+		ReservationId: reservation.Id,
+		OrderId:       reservation.Reference,
+		Reason:        req.Reason,
+		Restock:       req.Restock,
+		CreatedBy:     req.UserId,
+		Items:         items,
+	}
+	for _, item := range items {
+		res.Amount += item.Amount
+	}
+	res.Amount = math.Round(res.Amount*100) / 100
+
+	query := `
+		INSERT INTO
+			refunds (reservation_id, order_id, amount, reason, restock, created_by)
+		VALUES
+			(?, ?, ?, ?, ?, ?)
+		RETURNING
+			id,
+			status,
+			created_at
+	`
+	query = helper.RebindQuery(query)
+
+	row := tx.QueryRowContext(ctx, query, res.ReservationId, res.OrderId, res.Amount, res.Reason, res.Restock, res.CreatedBy)
+	if err := row.Scan(&res.Id, &res.Status, &res.CreatedAt); err != nil {
+		s.logger.ErrorContext(ctx, "failed to insert refund", "method", "CreateRefund", "err", err)
+		return nil, err
+	}
+
+	for _, item := range items {
+		query = `
+			INSERT INTO
+				refund_items (refund_id, product_id, variant_id, quantity, unit_price, amount)
+			VALUES
+				(?, ?, ?, ?, ?, ?)
+		`
+		query = helper.RebindQuery(query)
+
+		if _, err := tx.ExecContext(ctx, query, res.Id, item.ProductId, item.VariantId, item.Quantity, item.UnitPrice, item.Amount); err != nil {
+			s.logger.ErrorContext(ctx, "failed to insert refund item", "method", "CreateRefund", "err", err)
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to commit transaction", "method", "CreateRefund", "err", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// refundLines lists the lines of a reservation with the units pending and
+// succeeded refunds already took. An empty sellerId owns every line, sellers
+// own the lines of products in their shops.
+func (s *store) refundLines(ctx context.Context, tx *sql.Tx, reservationId, sellerId string) ([]*refundLine, error) {
+	query := `
+		SELECT
+			i.product_id,
+			i.variant_id,
+			SUM(i.quantity),
+			MAX(i.unit_price),
+			COALESCE((
+				SELECT SUM(ri.quantity)
+				FROM refund_items ri
+				JOIN refunds rf ON rf.id = ri.refund_id
+				WHERE rf.reservation_id = i.reservation_id AND rf.status <> 'failed' AND ri.product_id = i.product_id AND ri.variant_id IS NOT DISTINCT FROM i.variant_id
+			), 0),
+			? = '' OR EXISTS (
+				SELECT 1
+				FROM products p
+				JOIN shops s ON s.id = p.shop_id
+				WHERE p.id = i.product_id AND s.user_id::text = ?
+			)
+		FROM
+			stock_reservation_items i
+		WHERE
+			i.reservation_id = ?
+		GROUP BY
+			i.reservation_id, i.product_id, i.variant_id
+	`
+	query = helper.RebindQuery(query)
+
+	rows, err := tx.QueryContext(ctx, query, sellerId, sellerId, reservationId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to fetch refund lines", "method", "refundLines", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make([]*refundLine, 0)
+	for rows.Next() {
+		var d refundLine
+		if err := rows.Scan(&d.productId, &d.variantId, &d.quantity, &d.unitPrice, &d.refunded, &d.owned); err != nil {
+			s.logger.ErrorContext(ctx, "failed to scan refund line", "method", "refundLines", "err", err)
+			return nil, err
+		}
+		res = append(res, &d)
+	}
+
+	if err := rows.Err(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to iterate refund lines", "method", "refundLines", "err", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// refundItems prices the requested units from the lines they were bought on.
+// Without requested units every unit of the owned lines not refunded yet is
+// refunded.
+func refundItems(lines []*refundLine, req []*model.RefundItemReq) ([]*model.RefundItem, error) {
+	var (
+		res   = make([]*model.RefundItem, 0)
+		owned bool
+	)
+
+	if len(req) == 0 {
+		for _, l := range lines {
+			owned = owned || l.owned
+			if l.owned && l.quantity > l.refunded {
+				req = append(req, &model.RefundItemReq{ProductId: l.productId, VariantId: l.variantId, Quantity: l.quantity - l.refunded})
+			}
+		}
+
+		if !owned {
+			return nil, ErrNotShopOwner
+		}
+
+		if len(req) == 0 {
+			return nil, ErrNothingToRefund
+		}
+	}
+
+	for _, item := range req {
+		i := -1
+		for j, l := range lines {
+			if l.matches(item.ProductId, item.VariantId) {
+				i = j
+				break
+			}
+		}
+
+		if i < 0 {
+			return nil, ErrOrderLineNotFound
+		}
+
+		l := lines[i]
+		if !l.owned {
+			return nil, ErrNotShopOwner
+		}
+
+		if item.Quantity > l.quantity-l.refunded {
+			return nil, ErrRefundExceedsOrder
+		}
+
+		res = append(res, &model.RefundItem{
+			ProductId: l.productId,
+			VariantId: l.variantId,
+			Quantity:  item.Quantity,
+			UnitPrice: l.unitPrice,
+			Amount:    math.Round(float64(item.Quantity)*l.unitPrice*100) / 100,
+		})
+	}
+
+	return res, nil
+}
+
+// CompleteRefund records the payment provider's answer to a pending refund.
+// A succeeded refund restocks its units when asked to and moves the
+// reservation to partially_refunded, or refunded once every unit it bought
+// is refunded. Completing a refund that is no longer pending is a no-op.
+func (s *store) CompleteRefund(ctx context.Context, req *model.CompleteRefundReq) (*model.Refund, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "method", "CompleteRefund", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := s.lockRefund(ctx, tx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Status != model.RefundStatusPending {
+		return res, nil
+	}
+
+	// the reservation lock orders completions of its refunds, so the last one
+	// sees every earlier refund when it sets the status
+	reservation, err := s.lockReservation(ctx, tx, res.ReservationId, "")
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		UPDATE refunds
+		SET status = ?, provider_reference = ?, failure_reason = ?, updated_at = NOW()
+		WHERE id = ?
+	`
+	query = helper.RebindQuery(query)
+
+	if _, err := tx.ExecContext(ctx, query, req.Status, req.ProviderReference, req.FailureReason, res.Id); err != nil {
+		s.logger.ErrorContext(ctx, "failed to update refund status", "method", "CompleteRefund", "err", err)
+		return nil, err
+	}
+	res.Status = req.Status
+	res.ProviderReference = req.ProviderReference
+	res.FailureReason = req.FailureReason
+
+	restocked := make([]string, 0)
+	if res.Status == model.RefundStatusSucceeded {
+		if res.Restock {
+			for _, item := range res.Items {
+				ok, err := s.restockRefundItem(ctx, tx, res, item, req.UserId)
+				if err != nil {
+					return nil, err
+				}
+				if ok {
+					restocked = append(restocked, item.ProductId)
+				}
+			}
+		}
+
+		if err := s.setRefundedStatus(ctx, tx, reservation); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to commit transaction", "method", "CompleteRefund", "err", err)
+		return nil, err
+	}
+
+	for _, productId := range restocked {
+		s.evictProductCache(ctx, productId)
+	}
+	if len(restocked) > 0 {
+		s.evictProductsCache(ctx)
+	}
+
+	return res, nil
+}
+
+// lockRefund loads a refund with its items and locks it for the rest of the
+// transaction.
+func (s *store) lockRefund(ctx context.Context, tx *sql.Tx, id string) (*model.Refund, error) {
+	var res = new(model.Refund)
+
+	query := `
+		SELECT
+			id,
+			reservation_id,
+			order_id,
+			status,
+			amount,
+			reason,
+			restock,
+			provider_reference,
+			failure_reason,
+			created_by,
+			created_at
+		FROM
+			refunds
+		WHERE
+			id = ?
+		FOR UPDATE
+	`
+	query = helper.RebindQuery(query)
+
+	row := tx.QueryRowContext(ctx, query, id)
+	if err := row.Scan(
+		&res.Id,
+		&res.ReservationId,
+		&res.OrderId,
+		&res.Status,
+		&res.Amount,
+		&res.Reason,
+		&res.Restock,
+		&res.ProviderReference,
+		&res.FailureReason,
+		&res.CreatedBy,
+		&res.CreatedAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			s.logger.InfoContext(ctx, "no refund found", "method", "lockRefund")
+			return nil, ErrRefundNotFound
+		}
+		s.logger.ErrorContext(ctx, "failed to lock refund", "method", "lockRefund", "err", err)
+		return nil, err
+	}
+
+	query = `
+		SELECT
+			product_id,
+			variant_id,
+			quantity,
+			unit_price,
+			amount
+		FROM
+			refund_items
+		WHERE
+			refund_id = ?
+	`
+	query = helper.RebindQuery(query)
+
+	rows, err := tx.QueryContext(ctx, query, id)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to fetch refund items", "method", "lockRefund", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	res.Items = make([]*model.RefundItem, 0)
+	for rows.Next() {
+		var d model.RefundItem
+		if err := rows.Scan(&d.ProductId, &d.VariantId, &d.Quantity, &d.UnitPrice, &d.Amount); err != nil {
+			s.logger.ErrorContext(ctx, "failed to scan refund item", "method", "lockRefund", "err", err)
+			return nil, err
+		}
+		res.Items = append(res.Items, &d)
+	}
+
+	if err := rows.Err(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to iterate refund items", "method", "lockRefund", "err", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// restockRefundItem puts refunded units back into the default warehouse of
+// the product's shop and reports whether it did. Products deleted since the
+// sale are not restocked.
+func (s *store) restockRefundItem(ctx context.Context, tx *sql.Tx, refund *model.Refund, item *model.RefundItem, userId string) (bool, error) {
+	shopId, err := s.lockProduct(ctx, tx, item.ProductId)
+	if errors.Is(err, ErrProductNotFound) {
+		s.logger.InfoContext(ctx, "refunded product is gone, not restocking", "method", "restockRefundItem", "product_id", item.ProductId)
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+
+	query := `
+		INSERT INTO
+			product_restocks (refund_id, order_id, product_id, variant_id, quantity, reason)
+		VALUES
+			(?, ?, ?, ?, ?, ?)
+	`
+	query = helper.RebindQuery(query)
+
+	if _, err := tx.ExecContext(ctx, query, refund.Id, refund.OrderId, item.ProductId, item.VariantId, item.Quantity, refund.Reason); err != nil {
+		s.logger.ErrorContext(ctx, "failed to record restock", "method", "restockRefundItem", "err", err)
+		return false, err
+	}
+
+	warehouseId, err := s.defaultWarehouse(ctx, tx, shopId)
+	if err != nil {
+		return false, err
+	}
+
+	if _, err := s.recordStockMovement(ctx, tx, &model.StockMovement{
+		ProductId:   item.ProductId,
+		VariantId:   item.VariantId,
+		WarehouseId: warehouseId,
+		Type:        model.StockMovementReturn,
+		Quantity:    item.Quantity,
+		Reason:      refund.Reason,
+		Reference:   &refund.Id,
+		CreatedBy:   &userId,
+	}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// setRefundedStatus moves a paid reservation to refunded once succeeded
+// refunds cover every unit it bought, and to partially_refunded before.
+func (s *store) setRefundedStatus(ctx context.Context, tx *sql.Tx, reservation *model.Reservation) error {
+	var bought, refunded int64
+
+	for _, item := range reservation.Items {
+		bought += item.Quantity
+	}
+
+	query := `
+		SELECT
+			COALESCE(SUM(ri.quantity), 0)
+		FROM
+			refund_items ri
+		JOIN
+			refunds rf ON rf.id = ri.refund_id
+		WHERE
+			rf.reservation_id = ?
+			AND rf.status = 'succeeded'
+	`
+	query = helper.RebindQuery(query)
+
+	row := tx.QueryRowContext(ctx, query, reservation.Id)
+	if err := row.Scan(&refunded); err != nil {
+		s.logger.ErrorContext(ctx, "failed to sum refunded units", "method", "setRefundedStatus", "err", err)
+		return err
+	}
+
+	status := model.ReservationStatusPartiallyRefunded
+	if refunded >= bought {
+		status = model.ReservationStatusRefunded
+	}
+
+	return s.setReservationStatus(ctx, tx, reservation, status)
+}
@@ -0,0 +1,244 @@
+package products
+
+import (
+	model "codebase-service/models"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+)
+
+func (s *ProductStoreTestSuite) expectLockReservation(status string) {
+	s.db.ExpectQuery(`FROM\s+stock_reservations\s+WHERE\s+id = `).
+		WithArgs("r1", "", "").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "user_id", "reference", "status", "promotion_id", "expires_at"}).
+			AddRow("r1", "u1", "order-1", status, nil, time.Now()))
+	s.db.ExpectQuery(`FROM\s+stock_reservation_items`).
+		WithArgs("r1").
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "variant_id", "warehouse_id", "quantity", "unit_price"}).
+			AddRow("p1", nil, "w1", 2, 100.0).
+			AddRow("p1", nil, "w2", 1, 100.0).
+			AddRow("p2", "v1", "w1", 1, 49.99))
+}
+
+// expectRefundLines answers with the order of expectLockReservation: 3 units
+// of p1, one already refunded, and one unit of p2's v1 from another shop.
+func (s *ProductStoreTestSuite) expectRefundLines(sellerId string) {
+	s.db.ExpectQuery(`FROM\s+stock_reservation_items i\s+WHERE\s+i.reservation_id = `).
+		WithArgs(sellerId, sellerId, "r1").
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "variant_id", "quantity", "unit_price", "refunded", "owned"}).
+			AddRow("p1", nil, 3, 100.0, 1, true).
+			AddRow("p2", "v1", 1, 49.99, 0, sellerId == ""))
+}
+
+func (s *ProductStoreTestSuite) refundReq() *model.CreateRefundReq {
+	return &model.CreateRefundReq{
+		UserId:        "seller-1",
+		ReservationId: "r1",
+		Reason:        "damaged",
+		Restock:       true,
+		SellerId:      "seller-1",
+	}
+}
+
+func (s *ProductStoreTestSuite) TestCreateRefund_WholeOrderOfSeller() {
+	req := s.refundReq()
+	createdAt := time.Now()
+
+	s.db.ExpectBegin()
+	s.expectLockReservation(model.ReservationStatusPartiallyRefunded)
+	s.expectRefundLines("seller-1")
+	s.db.ExpectQuery(`INSERT INTO\s+refunds`).
+		WithArgs("r1", "order-1", 200.0, "damaged", true, "seller-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "created_at"}).AddRow("refund-1", model.RefundStatusPending, createdAt))
+	s.db.ExpectExec(`INSERT INTO\s+refund_items`).
+		WithArgs("refund-1", "p1", nil, int64(2), 100.0, 200.0).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.db.ExpectCommit()
+
+	resp, err := s.store.CreateRefund(s.ctx, req)
+
+	s.Require().NoError(err)
+	s.Equal(&model.Refund{
+		Id:            "refund-1",
+		ReservationId: "r1",
+		OrderId:       "order-1",
+		Status:        model.RefundStatusPending,
+		Amount:        200,
+		Reason:        "damaged",
+		Restock:       true,
+		CreatedBy:     "seller-1",
+		CreatedAt:     createdAt,
+		Items:         []*model.RefundItem{{ProductId: "p1", Quantity: 2, UnitPrice: 100, Amount: 200}},
+	}, resp)
+}
+
+func (s *ProductStoreTestSuite) TestCreateRefund_LineOfAdmin() {
+	req := s.refundReq()
+	req.SellerId = ""
+	variantId := "v1"
+	req.Items = []*model.RefundItemReq{{ProductId: "p2", VariantId: &variantId, Quantity: 1}}
+
+	s.db.ExpectBegin()
+	s.expectLockReservation(model.ReservationStatusConfirmed)
+	s.expectRefundLines("")
+	s.db.ExpectQuery(`INSERT INTO\s+refunds`).
+		WithArgs("r1", "order-1", 49.99, "damaged", true, "seller-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "status", "created_at"}).AddRow("refund-1", model.RefundStatusPending, time.Now()))
+	s.db.ExpectExec(`INSERT INTO\s+refund_items`).
+		WithArgs("refund-1", "p2", "v1", int64(1), 49.99, 49.99).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.db.ExpectCommit()
+
+	resp, err := s.store.CreateRefund(s.ctx, req)
+
+	s.Require().NoError(err)
+	s.Equal(49.99, resp.Amount)
+}
+
+func (s *ProductStoreTestSuite) TestCreateRefund_Rejected() {
+	variantId := "v1"
+	cases := []struct {
+		name   string
+		status string
+		items  []*model.RefundItemReq
+		err    error
+	}{
+		{"not paid", model.ReservationStatusActive, nil, ErrReservationNotPaid},
+		{"refunded", model.ReservationStatusRefunded, nil, ErrReservationRefunded},
+		{"other shop", model.ReservationStatusConfirmed, []*model.RefundItemReq{{ProductId: "p2", VariantId: &variantId, Quantity: 1}}, ErrNotShopOwner},
+		{"not in order", model.ReservationStatusConfirmed, []*model.RefundItemReq{{ProductId: "p3", Quantity: 1}}, ErrOrderLineNotFound},
+		{"variant not in order", model.ReservationStatusConfirmed, []*model.RefundItemReq{{ProductId: "p1", VariantId: &variantId, Quantity: 1}}, ErrOrderLineNotFound},
+		{"already refunded", model.ReservationStatusConfirmed, []*model.RefundItemReq{{ProductId: "p1", Quantity: 3}}, ErrRefundExceedsOrder},
+	}
+
+	for _, c := range cases {
+		s.Run(c.name, func() {
+			req := s.refundReq()
+			req.Items = c.items
+
+			s.db.ExpectBegin()
+			s.expectLockReservation(c.status)
+			if c.status == model.ReservationStatusConfirmed {
+				s.expectRefundLines("seller-1")
+			}
+			s.db.ExpectRollback()
+
+			resp, err := s.store.CreateRefund(s.ctx, req)
+
+			s.ErrorIs(err, c.err)
+			s.Nil(resp)
+			s.NoError(s.db.ExpectationsWereMet())
+		})
+	}
+}
+
+func (s *ProductStoreTestSuite) expectLockRefund(status string) {
+	s.db.ExpectQuery(`FROM\s+refunds\s+WHERE\s+id = `).
+		WithArgs("refund-1").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "reservation_id", "order_id", "status", "amount", "reason", "restock", "provider_reference", "failure_reason", "created_by", "created_at"}).
+			AddRow("refund-1", "r1", "order-1", status, 200.0, "damaged", true, nil, nil, "seller-1", time.Now()))
+	s.db.ExpectQuery(`FROM\s+refund_items`).
+		WithArgs("refund-1").
+		WillReturnRows(sqlmock.NewRows([]string{"product_id", "variant_id", "quantity", "unit_price", "amount"}).
+			AddRow("p1", nil, 2, 100.0, 200.0))
+}
+
+func (s *ProductStoreTestSuite) TestCompleteRefund_RestocksAndRefundsOrder() {
+	s.redis.Set("cache:product:p1", "{}")
+	reference := "gw-1"
+	req := &model.CompleteRefundReq{Id: "refund-1", UserId: "seller-1", Status: model.RefundStatusSucceeded, ProviderReference: &reference}
+
+	s.db.ExpectBegin()
+	s.expectLockRefund(model.RefundStatusPending)
+	s.expectLockReservation(model.ReservationStatusPartiallyRefunded)
+	s.db.ExpectExec(`UPDATE refunds`).
+		WithArgs(model.RefundStatusSucceeded, "gw-1", nil, "refund-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.expectLockProduct("p1", "s1")
+	s.db.ExpectExec(`INSERT INTO\s+product_restocks`).
+		WithArgs("refund-1", "order-1", "p1", nil, int64(2), "damaged").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.db.ExpectExec(`INSERT INTO\s+warehouses`).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.db.ExpectQuery(`FROM\s+warehouses`).
+		WithArgs("s1").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("w1"))
+	s.db.ExpectQuery(`INSERT INTO\s+warehouse_stocks`).
+		WithArgs("w1", "p1", nil, int64(2)).
+		WillReturnRows(sqlmock.NewRows([]string{"quantity"}).AddRow(9))
+	s.db.ExpectQuery(`INSERT INTO\s+stock_movements`).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "created_at"}).AddRow("m1", time.Now()))
+	// the order bought 4 units, with this refund all of them are refunded
+	s.db.ExpectQuery(`SELECT\s+COALESCE\(SUM\(ri.quantity\), 0\)`).
+		WithArgs("r1").
+		WillReturnRows(sqlmock.NewRows([]string{"refunded"}).AddRow(4))
+	s.db.ExpectExec(`UPDATE stock_reservations`).
+		WithArgs(model.ReservationStatusRefunded, "r1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.db.ExpectCommit()
+
+	resp, err := s.store.CompleteRefund(s.ctx, req)
+
+	s.Require().NoError(err)
+	s.Equal(model.RefundStatusSucceeded, resp.Status)
+	s.Equal(&reference, resp.ProviderReference)
+	s.False(s.redis.Exists("cache:product:p1"))
+}
+
+func (s *ProductStoreTestSuite) TestCompleteRefund_PartOfOrder() {
+	req := &model.CompleteRefundReq{Id: "refund-1", UserId: "seller-1", Status: model.RefundStatusSucceeded}
+
+	s.db.ExpectBegin()
+	s.expectLockRefund(model.RefundStatusPending)
+	s.expectLockReservation(model.ReservationStatusConfirmed)
+	s.db.ExpectExec(`UPDATE refunds`).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	// the product was deleted since, its units are not restocked
+	s.db.ExpectQuery(`SELECT\s+shop_id\s+FROM\s+products`).
+		WithArgs("p1").
+		WillReturnRows(sqlmock.NewRows([]string{"shop_id"}))
+	s.db.ExpectQuery(`SELECT\s+COALESCE\(SUM\(ri.quantity\), 0\)`).
+		WillReturnRows(sqlmock.NewRows([]string{"refunded"}).AddRow(2))
+	s.db.ExpectExec(`UPDATE stock_reservations`).
+		WithArgs(model.ReservationStatusPartiallyRefunded, "r1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.db.ExpectCommit()
+
+	resp, err := s.store.CompleteRefund(s.ctx, req)
+
+	s.Require().NoError(err)
+	s.Equal(model.RefundStatusSucceeded, resp.Status)
+}
+
+func (s *ProductStoreTestSuite) TestCompleteRefund_Failed() {
+	reason := "gateway refused the refund: expired"
+	req := &model.CompleteRefundReq{Id: "refund-1", UserId: "seller-1", Status: model.RefundStatusFailed, FailureReason: &reason}
+
+	// a failed refund neither restocks nor moves the order
+	s.db.ExpectBegin()
+	s.expectLockRefund(model.RefundStatusPending)
+	s.expectLockReservation(model.ReservationStatusConfirmed)
+	s.db.ExpectExec(`UPDATE refunds`).
+		WithArgs(model.RefundStatusFailed, nil, reason, "refund-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.db.ExpectCommit()
+
+	resp, err := s.store.CompleteRefund(s.ctx, req)
+
+	s.Require().NoError(err)
+	s.Equal(model.RefundStatusFailed, resp.Status)
+	s.Equal(&reason, resp.FailureReason)
+}
+
+func (s *ProductStoreTestSuite) TestCompleteRefund_NotPending() {
+	req := &model.CompleteRefundReq{Id: "refund-1", Status: model.RefundStatusFailed}
+
+	s.db.ExpectBegin()
+	s.expectLockRefund(model.RefundStatusSucceeded)
+	s.db.ExpectRollback()
+
+	resp, err := s.store.CompleteRefund(s.ctx, req)
+
+	s.Require().NoError(err)
+	s.Equal(model.RefundStatusSucceeded, resp.Status)
+}
@@ -0,0 +1,539 @@
+package products
+
+import (
+	"codebase-service/helper"
+	model "codebase-service/models"
+	"context"
+	"database/sql"
+	"time"
+)
+
+// CreateReservation holds stock for a checkout. The held quantity is taken
+// out of the warehouses through the ledger, so it is no longer available to
+// other buyers until the reservation is released.
+func (s *store) CreateReservation(ctx context.Context, req *model.CreateReservationReq) (*model.Reservation, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var res = &model.Reservation{
+		UserId:    req.UserId,
+		Reference: req.Reference,
+		Status:    model.ReservationStatusActive,
+		ExpiresAt: req.ExpiresAt,
+		Items:     make([]*model.ReservationItem, 0, len(req.Items)),
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "method", "CreateReservation", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if err := s.createReservation(ctx, tx, res, req.Items); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to commit transaction", "method", "CreateReservation", "err", err)
+		return nil, err
+	}
+
+	s.evictReservationCache(ctx, res)
+
+	return res, nil
+}
+
+// createReservation inserts res and takes its items out of the warehouses,
+// filling res.Id and res.Items.
+func (s *store) createReservation(ctx context.Context, tx *sql.Tx, res *model.Reservation, items []*model.ReservationItemReq) error {
+	query := `
+		INSERT INTO
+			stock_reservations (user_id, reference, promotion_id, expires_at)
+		VALUES
+			(?, ?, ?, ?)
+		RETURNING
+			id
+	`
+	query = helper.RebindQuery(query)
+
+	row := tx.QueryRowContext(ctx, query, res.UserId, res.Reference, res.PromotionId, res.ExpiresAt)
+	if err := row.Scan(&res.Id); err != nil {
+		s.logger.ErrorContext(ctx, "failed to insert reservation", "method", "createReservation", "err", err)
+		return err
+	}
+
+	for _, item := range items {
+		reserved, err := s.reserveProduct(ctx, tx, res, item)
+		if err != nil {
+			return err
+		}
+		res.Items = append(res.Items, reserved...)
+	}
+
+	return nil
+}
+
+// reserveProduct spreads the requested quantity over the warehouses holding
+// the product, fullest warehouse first. The lines keep the price the buyer
+// pays, see unitPrice.
+func (s *store) reserveProduct(ctx context.Context, tx *sql.Tx, reservation *model.Reservation, item *model.ReservationItemReq) ([]*model.ReservationItem, error) {
+	var (
+		res       = make([]*model.ReservationItem, 0)
+		remaining = item.Quantity
+	)
+
+	unitPrice, err := s.unitPrice(ctx, tx, reservation.PromotionId, item)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.checkVariant(ctx, tx, item.ProductId, item.VariantId); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT
+			warehouse_id,
+			quantity
+		FROM
+			warehouse_stocks
+		WHERE
+			product_id = ?
+			AND variant_id IS NOT DISTINCT FROM ?::uuid
+			AND quantity > 0
+		ORDER BY
+			quantity DESC
+		FOR UPDATE
+	`
+	query = helper.RebindQuery(query)
+
+	rows, err := tx.QueryContext(ctx, query, item.ProductId, item.VariantId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to fetch warehouse stocks", "method", "reserveProduct", "err", err)
+		return nil, err
+	}
+
+	for remaining > 0 && rows.Next() {
+		var d model.ReservationItem
+		if err := rows.Scan(&d.WarehouseId, &d.Quantity); err != nil {
+			rows.Close()
+			s.logger.ErrorContext(ctx, "failed to scan warehouse stock", "method", "reserveProduct", "err", err)
+			return nil, err
+		}
+
+		d.ProductId = item.ProductId
+		d.VariantId = item.VariantId
+		d.UnitPrice = unitPrice
+		d.Quantity = min(d.Quantity, remaining)
+		remaining -= d.Quantity
+		res = append(res, &d)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to iterate warehouse stocks", "method", "reserveProduct", "err", err)
+		return nil, err
+	}
+
+	if remaining > 0 {
+		s.logger.InfoContext(ctx, "insufficient stock", "method", "reserveProduct")
+		return nil, ErrInsufficientStock
+	}
+
+	for _, d := range res {
+		if _, err := s.recordStockMovement(ctx, tx, &model.StockMovement{
+			ProductId:   d.ProductId,
+			VariantId:   d.VariantId,
+			WarehouseId: d.WarehouseId,
+			Type:        model.StockMovementReservation,
+			Quantity:    -d.Quantity,
+			Reason:      "checkout reservation",
+			Reference:   &reservation.Id,
+			CreatedBy:   &reservation.UserId,
+		}); err != nil {
+			return nil, err
+		}
+
+		query = `
+			INSERT INTO
+				stock_reservation_items (reservation_id, product_id, variant_id, warehouse_id, quantity, unit_price)
+			VALUES
+				(?, ?, ?, ?, ?, ?)
+		`
+		query = helper.RebindQuery(query)
+
+		if _, err := tx.ExecContext(ctx, query, reservation.Id, d.ProductId, d.VariantId, d.WarehouseId, d.Quantity, d.UnitPrice); err != nil {
+			s.logger.ErrorContext(ctx, "failed to insert reservation item", "method", "reserveProduct", "err", err)
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
+
+// unitPrice is what the buyer pays for one unit of a live product, priced
+// like GetProduct: the running promotion's sale price, or else the variant's
+// own price, or else the product price. Flash-sale claims pay the price of
+// the promotion they claimed, even when the claim sold it out.
+func (s *store) unitPrice(ctx context.Context, tx *sql.Tx, promotionId *string, item *model.ReservationItemReq) (float64, error) {
+	var price float64
+
+	query := `
+		SELECT
+			COALESCE(
+				(SELECT pp.sale_price FROM product_promotions pp WHERE pp.id = ?::uuid AND pp.product_id = p.id),
+				promo.sale_price,
+				(SELECT pv.price FROM product_variants pv WHERE pv.id = ?::uuid AND pv.product_id = p.id),
+				p.price
+			)
+		FROM
+			products p
+		LEFT JOIN LATERAL (
+			SELECT
+				pp.sale_price
+			FROM
+				product_promotions pp
+			WHERE
+				pp.product_id = p.id
+				AND pp.deleted_at IS NULL
+				AND pp.starts_at <= NOW()
+				AND pp.ends_at > NOW()
+				AND (pp.quantity IS NULL OR pp.claimed < pp.quantity)
+			LIMIT 1
+		) promo ON true
+		WHERE
+			p.id = ?
+			AND p.status = 'published'
+			AND p.deleted_at IS NULL
+	`
+	query = helper.RebindQuery(query)
+
+	row := tx.QueryRowContext(ctx, query, promotionId, item.VariantId, item.ProductId)
+	if err := row.Scan(&price); err != nil {
+		if err == sql.ErrNoRows {
+			s.logger.InfoContext(ctx, "no product found", "method", "unitPrice")
+			return 0, ErrProductNotFound
+		}
+		s.logger.ErrorContext(ctx, "failed to price product", "method", "unitPrice", "err", err)
+		return 0, err
+	}
+
+	return price, nil
+}
+
+// ConfirmReservation turns held stock into a sale once payment is confirmed.
+// It is called by the payment service rather than the buyer, so the
+// reservation is not looked up by owner. Confirming an already confirmed
+// reservation is a no-op.
+func (s *store) ConfirmReservation(ctx context.Context, req *model.ReservationReq) (*model.Reservation, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "method", "ConfirmReservation", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := s.lockReservation(ctx, tx, req.Id, "")
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Paid() {
+		return res, nil
+	}
+
+	if res.Status != model.ReservationStatusActive || !time.Now().Before(res.ExpiresAt) {
+		s.logger.InfoContext(ctx, "reservation is no longer active", "method", "ConfirmReservation")
+		return nil, ErrReservationNotActive
+	}
+
+	// the hold is given back and taken again as a sale, so the ledger shows
+	// both the reservation lifecycle and the final sale
+	for _, item := range res.Items {
+		for _, m := range []*model.StockMovement{
+			{Type: model.StockMovementReservation, Quantity: item.Quantity, Reason: "reservation confirmed"},
+			{Type: model.StockMovementSale, Quantity: -item.Quantity, Reason: "checkout paid"},
+		} {
+			m.ProductId = item.ProductId
+			m.VariantId = item.VariantId
+			m.WarehouseId = item.WarehouseId
+			m.Reference = &res.Id
+			m.CreatedBy = &req.UserId
+
+			if _, err := s.recordStockMovement(ctx, tx, m); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if err := s.setReservationStatus(ctx, tx, res, model.ReservationStatusConfirmed); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to commit transaction", "method", "ConfirmReservation", "err", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ReleaseReservation gives held stock back. Releasing an already released
+// reservation is a no-op.
+func (s *store) ReleaseReservation(ctx context.Context, req *model.ReservationReq) (*model.Reservation, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "method", "ReleaseReservation", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	res, err := s.lockReservation(ctx, tx, req.Id, req.UserId)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Status == model.ReservationStatusReleased {
+		return res, nil
+	}
+
+	if res.Paid() {
+		s.logger.InfoContext(ctx, "reservation is already confirmed", "method", "ReleaseReservation")
+		return nil, ErrReservationConfirmed
+	}
+
+	if err := s.releaseReservation(ctx, tx, res, "reservation released"); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to commit transaction", "method", "ReleaseReservation", "err", err)
+		return nil, err
+	}
+
+	s.evictReservationCache(ctx, res)
+
+	return res, nil
+}
+
+// ReleaseExpiredReservations releases up to limit active reservations whose
+// hold has expired and returns how many were released. Rows locked by another
+// instance's sweeper are skipped.
+func (s *store) ReleaseExpiredReservations(ctx context.Context, limit int) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "method", "ReleaseExpiredReservations", "err", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT
+			id
+		FROM
+			stock_reservations
+		WHERE
+			status = 'active'
+			AND expires_at <= NOW()
+		ORDER BY
+			expires_at
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED
+	`
+	query = helper.RebindQuery(query)
+
+	rows, err := tx.QueryContext(ctx, query, limit)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to fetch expired reservations", "method", "ReleaseExpiredReservations", "err", err)
+		return 0, err
+	}
+
+	ids := make([]string, 0)
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			s.logger.ErrorContext(ctx, "failed to scan reservation id", "method", "ReleaseExpiredReservations", "err", err)
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to iterate expired reservations", "method", "ReleaseExpiredReservations", "err", err)
+		return 0, err
+	}
+
+	released := make([]*model.Reservation, 0, len(ids))
+	for _, id := range ids {
+		res, err := s.lockReservation(ctx, tx, id, "")
+		if err != nil {
+			return 0, err
+		}
+
+		if err := s.releaseReservation(ctx, tx, res, "reservation expired"); err != nil {
+			return 0, err
+		}
+		released = append(released, res)
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to commit transaction", "method", "ReleaseExpiredReservations", "err", err)
+		return 0, err
+	}
+
+	for _, res := range released {
+		s.evictReservationCache(ctx, res)
+	}
+
+	return len(released), nil
+}
+
+// lockReservation loads a reservation with its items and locks it for the
+// rest of the transaction. An empty userId skips the ownership check.
+func (s *store) lockReservation(ctx context.Context, tx *sql.Tx, id, userId string) (*model.Reservation, error) {
+	var res = new(model.Reservation)
+
+	query := `
+		SELECT
+			id,
+			user_id,
+			reference,
+			status,
+			promotion_id,
+			expires_at
+		FROM
+			stock_reservations
+		WHERE
+			id = ?
+			AND (? = '' OR user_id::text = ?)
+		FOR UPDATE
+	`
+	query = helper.RebindQuery(query)
+
+	row := tx.QueryRowContext(ctx, query, id, userId, userId)
+	if err := row.Scan(&res.Id, &res.UserId, &res.Reference, &res.Status, &res.PromotionId, &res.ExpiresAt); err != nil {
+		if err == sql.ErrNoRows {
+			s.logger.InfoContext(ctx, "no reservation found", "method", "lockReservation")
+			return nil, ErrReservationNotFound
+		}
+		s.logger.ErrorContext(ctx, "failed to lock reservation", "method", "lockReservation", "err", err)
+		return nil, err
+	}
+
+	query = `
+		SELECT
+			product_id,
+			variant_id,
+			warehouse_id,
+			quantity,
+			unit_price
+		FROM
+			stock_reservation_items
+		WHERE
+			reservation_id = ?
+	`
+	query = helper.RebindQuery(query)
+
+	rows, err := tx.QueryContext(ctx, query, id)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to fetch reservation items", "method", "lockReservation", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	res.Items = make([]*model.ReservationItem, 0)
+	for rows.Next() {
+		var d model.ReservationItem
+		if err := rows.Scan(&d.ProductId, &d.VariantId, &d.WarehouseId, &d.Quantity, &d.UnitPrice); err != nil {
+			s.logger.ErrorContext(ctx, "failed to scan reservation item", "method", "lockReservation", "err", err)
+			return nil, err
+		}
+		res.Items = append(res.Items, &d)
+	}
+
+	if err := rows.Err(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to iterate reservation items", "method", "lockReservation", "err", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// releaseReservation gives the held stock back, and the claim to its
+// promotion when the reservation holds a flash-sale claim.
+func (s *store) releaseReservation(ctx context.Context, tx *sql.Tx, res *model.Reservation, reason string) error {
+	var quantity int64
+
+	for _, item := range res.Items {
+		quantity += item.Quantity
+
+		if _, err := s.recordStockMovement(ctx, tx, &model.StockMovement{
+			ProductId:   item.ProductId,
+			VariantId:   item.VariantId,
+			WarehouseId: item.WarehouseId,
+			Type:        model.StockMovementReservation,
+			Quantity:    item.Quantity,
+			Reason:      reason,
+			Reference:   &res.Id,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if res.PromotionId != nil {
+		query := `
+			UPDATE product_promotions
+			SET claimed = claimed - ?, updated_at = NOW()
+			WHERE id = ?
+		`
+		query = helper.RebindQuery(query)
+
+		if _, err := tx.ExecContext(ctx, query, quantity, *res.PromotionId); err != nil {
+			s.logger.ErrorContext(ctx, "failed to give back flash sale claim", "method", "releaseReservation", "err", err)
+			return err
+		}
+	}
+
+	return s.setReservationStatus(ctx, tx, res, model.ReservationStatusReleased)
+}
+
+func (s *store) setReservationStatus(ctx context.Context, tx *sql.Tx, res *model.Reservation, status string) error {
+	query := `
+		UPDATE stock_reservations
+		SET status = ?, updated_at = NOW()
+		WHERE id = ?
+	`
+	query = helper.RebindQuery(query)
+
+	if _, err := tx.ExecContext(ctx, query, status, res.Id); err != nil {
+		s.logger.ErrorContext(ctx, "failed to update reservation status", "method", "setReservationStatus", "err", err)
+		return err
+	}
+	res.Status = status
+
+	return nil
+}
+
+func (s *store) evictReservationCache(ctx context.Context, res *model.Reservation) {
+	for _, item := range res.Items {
+		s.evictProductCache(ctx, item.ProductId)
+	}
+	s.evictProductsCache(ctx)
+
+	// the next claim reloads the quota with the units given back
+	if res.PromotionId != nil && res.Status == model.ReservationStatusReleased {
+		s.resetFlashSaleQuota(ctx, *res.PromotionId)
+	}
+}
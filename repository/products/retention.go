@@ -0,0 +1,219 @@
+package products
+
+import (
+	"codebase-service/helper"
+	model "codebase-service/models"
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// RestoreProduct brings back a product deleted within the restore window. It
+// comes back as a draft, the seller publishes it again through moderation.
+func (s *store) RestoreProduct(ctx context.Context, req *model.RestoreProductReq) (*model.GetProductResp, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var (
+		deletedAt sql.NullTime
+		isOwner   bool
+	)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "method", "RestoreProduct", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT
+			p.deleted_at,
+			s.user_id = ?
+		FROM
+			products p
+		JOIN
+			shops s ON p.shop_id = s.id
+		WHERE
+			p.id = ?
+		FOR UPDATE OF p
+	`
+	query = helper.RebindQuery(query)
+
+	row := tx.QueryRowContext(ctx, query, req.UserId, req.Id)
+	if err := row.Scan(&deletedAt, &isOwner); err != nil {
+		if err == sql.ErrNoRows {
+			s.logger.InfoContext(ctx, "no product found", "method", "RestoreProduct")
+			return nil, ErrProductNotFound
+		}
+		s.logger.ErrorContext(ctx, "failed to lock product", "method", "RestoreProduct", "err", err)
+		return nil, err
+	}
+
+	if !isOwner {
+		s.logger.InfoContext(ctx, "user is not shop owner", "method", "RestoreProduct")
+		return nil, ErrNotShopOwner
+	}
+
+	if !deletedAt.Valid {
+		s.logger.InfoContext(ctx, "product is not deleted", "method", "RestoreProduct", "product_id", req.Id)
+		return nil, ErrProductNotDeleted
+	}
+
+	if deletedAt.Time.Before(req.DeletedAfter) {
+		s.logger.InfoContext(ctx, "product was deleted outside the restore window", "method", "RestoreProduct", "product_id", req.Id, "deleted_at", deletedAt.Time)
+		return nil, ErrRestoreWindowExpired
+	}
+
+	query = `
+		UPDATE
+			products
+		SET
+			deleted_at = NULL,
+			status = ?,
+			publish_at = NULL,
+			updated_at = NOW()
+		WHERE
+			id = ?
+	`
+	query = helper.RebindQuery(query)
+
+	if _, err := tx.ExecContext(ctx, query, model.ProductStatusDraft, req.Id); err != nil {
+		// another product of the shop took the sku while this one was deleted
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			s.logger.InfoContext(ctx, "sku already exists", "method", "RestoreProduct")
+			return nil, ErrSkuExists
+		}
+		s.logger.ErrorContext(ctx, "failed to restore product", "method", "RestoreProduct", "err", err)
+		return nil, err
+	}
+
+	if err := s.cancelReview(ctx, tx, req.Id); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to commit transaction", "method", "RestoreProduct", "err", err)
+		return nil, err
+	}
+
+	s.evictProductCache(ctx, req.Id)
+	s.evictProductsCache(ctx)
+
+	return s.getProductInDB(ctx, &model.GetProductReq{Id: req.Id})
+}
+
+// purgeStatements remove the catalog data of the locked products, children
+// first. Reservation lines, refund restocks and the stock ledger are order
+// history and are kept, flash-sale reservations keep the id of the promotion
+// they claimed.
+var purgeStatements = []string{
+	`DELETE FROM product_reviews WHERE product_id = ANY(?)`,
+	`DELETE FROM product_images WHERE product_id = ANY(?)`,
+	`DELETE FROM product_promotions WHERE product_id = ANY(?)`,
+	`DELETE FROM warehouse_stocks WHERE product_id = ANY(?)`,
+	`DELETE FROM product_variants WHERE product_id = ANY(?)`,
+	`DELETE FROM product_options WHERE product_id = ANY(?)`,
+	`DELETE FROM products WHERE id = ANY(?)`,
+}
+
+// PurgeDeletedProducts hard-deletes up to limit products soft-deleted before
+// deletedBefore and returns how many were purged. Each product leaves a
+// tombstone in purged_products.
+func (s *store) PurgeDeletedProducts(ctx context.Context, deletedBefore time.Time, limit int) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var ids = make([]string, 0)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "method", "PurgeDeletedProducts", "err", err)
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT
+			id
+		FROM
+			products
+		WHERE
+			deleted_at IS NOT NULL
+			AND deleted_at < ?
+		ORDER BY
+			deleted_at
+		LIMIT ?
+		FOR UPDATE SKIP LOCKED
+	`
+	query = helper.RebindQuery(query)
+
+	rows, err := tx.QueryContext(ctx, query, deletedBefore, limit)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to lock products", "method", "PurgeDeletedProducts", "err", err)
+		return 0, err
+	}
+
+	for rows.Next() {
+		var id string
+		if err := rows.Scan(&id); err != nil {
+			rows.Close()
+			s.logger.ErrorContext(ctx, "failed to scan product id", "method", "PurgeDeletedProducts", "err", err)
+			return 0, err
+		}
+		ids = append(ids, id)
+	}
+	rows.Close()
+
+	if err := rows.Err(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to iterate product ids", "method", "PurgeDeletedProducts", "err", err)
+		return 0, err
+	}
+
+	if len(ids) == 0 {
+		return 0, nil
+	}
+
+	query = `
+		INSERT INTO
+			purged_products (id, shop_id, category_id, name, variant_skus, deleted_at)
+		SELECT
+			p.id,
+			p.shop_id,
+			p.category_id,
+			p.name,
+			COALESCE((SELECT jsonb_object_agg(v.id, v.sku) FROM product_variants v WHERE v.product_id = p.id), '{}'),
+			p.deleted_at
+		FROM
+			products p
+		WHERE
+			p.id = ANY(?)
+		ON CONFLICT (id) DO NOTHING
+	`
+	query = helper.RebindQuery(query)
+
+	if _, err := tx.ExecContext(ctx, query, pq.Array(ids)); err != nil {
+		s.logger.ErrorContext(ctx, "failed to write tombstones", "method", "PurgeDeletedProducts", "err", err)
+		return 0, err
+	}
+
+	for _, statement := range purgeStatements {
+		if _, err := tx.ExecContext(ctx, helper.RebindQuery(statement), pq.Array(ids)); err != nil {
+			s.logger.ErrorContext(ctx, "failed to purge products", "method", "PurgeDeletedProducts", "err", err)
+			return 0, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to commit transaction", "method", "PurgeDeletedProducts", "err", err)
+		return 0, err
+	}
+
+	for _, id := range ids {
+		s.evictProductCache(ctx, id)
+	}
+
+	return len(ids), nil
+}
@@ -0,0 +1,97 @@
+package products
+
+import (
+	"codebase-service/helper"
+	model "codebase-service/models"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+)
+
+func (s *ProductStoreTestSuite) restoreReq() *model.RestoreProductReq {
+	return &model.RestoreProductReq{
+		UserId:       "u1",
+		Id:           "p1",
+		DeletedAfter: time.Now().Add(-24 * time.Hour),
+	}
+}
+
+func (s *ProductStoreTestSuite) expectLockDeletedProduct() {
+	s.db.ExpectQuery(`SELECT\s+p.deleted_at`).
+		WithArgs("u1", "p1").
+		WillReturnRows(sqlmock.NewRows([]string{"deleted_at", "is_owner"}).AddRow(time.Now().Add(-time.Hour), true))
+}
+
+func (s *ProductStoreTestSuite) TestRestoreProduct_AsDraft() {
+	req := s.restoreReq()
+
+	s.db.ExpectBegin()
+	s.expectLockDeletedProduct()
+	s.db.ExpectExec(`UPDATE\s+products\s+SET\s+deleted_at = NULL,\s+status = \$1,\s+publish_at = NULL`).
+		WithArgs(model.ProductStatusDraft, "p1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	s.db.ExpectExec(`UPDATE\s+product_reviews`).
+		WithArgs("p1").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	s.db.ExpectCommit()
+	s.expectGetProductInDB("p1", nil)
+
+	resp, err := s.store.RestoreProduct(s.ctx, req)
+
+	s.Require().NoError(err)
+	s.Equal(model.ProductStatusDraft, resp.Status)
+	s.Nil(resp.PublishAt)
+}
+
+func (s *ProductStoreTestSuite) TestRestoreProduct_SkuTaken() {
+	req := s.restoreReq()
+
+	s.db.ExpectBegin()
+	s.expectLockDeletedProduct()
+	s.db.ExpectExec(`UPDATE\s+products`).
+		WillReturnError(&pq.Error{Code: "23505"})
+	s.db.ExpectRollback()
+
+	resp, err := s.store.RestoreProduct(s.ctx, req)
+
+	s.ErrorIs(err, ErrSkuExists)
+	s.Nil(resp)
+}
+
+// TestPurgeDeletedProducts_WithPromotion purges a product whose flash sale
+// was claimed. The claiming reservation keeps the promotion id, nothing
+// references the promotion, so it is deleted with the product.
+func (s *ProductStoreTestSuite) TestPurgeDeletedProducts_WithPromotion() {
+	deletedBefore := time.Now().Add(-30 * 24 * time.Hour)
+	s.redis.Set("cache:product:p1", "{}")
+
+	s.db.ExpectBegin()
+	s.db.ExpectQuery(`SELECT\s+id\s+FROM\s+products\s+WHERE\s+deleted_at IS NOT NULL`).
+		WithArgs(deletedBefore, 100).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("p1"))
+	s.db.ExpectExec(`INSERT INTO\s+purged_products`).
+		WithArgs(pq.Array([]string{"p1"})).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	for _, statement := range purgeStatements {
+		result := sqlmock.NewResult(0, 0)
+		if strings.Contains(statement, "product_promotions") || strings.Contains(statement, "FROM products") {
+			result = sqlmock.NewResult(0, 1)
+		}
+		s.db.ExpectExec(regexp.QuoteMeta(helper.RebindQuery(statement))).
+			WithArgs(pq.Array([]string{"p1"})).
+			WillReturnResult(result)
+	}
+	s.db.ExpectCommit()
+
+	purged, err := s.store.PurgeDeletedProducts(s.ctx, deletedBefore, 100)
+
+	s.Require().NoError(err)
+	s.Equal(1, purged)
+	s.False(s.redis.Exists("cache:product:p1"))
+	for _, statement := range purgeStatements {
+		s.NotContains(statement, "stock_reservations")
+	}
+}
@@ -0,0 +1,346 @@
+package products
+
+import (
+	"codebase-service/helper"
+	model "codebase-service/models"
+	"context"
+	"database/sql"
+	"encoding/json"
+
+	"github.com/lib/pq"
+)
+
+func (s *store) SetProductOptions(ctx context.Context, req *model.SetProductOptionsReq) ([]*model.ProductOption, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var (
+		res         = make([]*model.ProductOption, 0, len(req.Options))
+		hasVariants bool
+	)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "method", "SetProductOptions", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	if _, err := s.lockProduct(ctx, tx, req.ProductId); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM
+				product_variants
+			WHERE
+				product_id = ?
+				AND deleted_at IS NULL
+		)
+	`
+	query = helper.RebindQuery(query)
+
+	row := tx.QueryRowContext(ctx, query, req.ProductId)
+	if err := row.Scan(&hasVariants); err != nil {
+		s.logger.ErrorContext(ctx, "failed to check product variants", "method", "SetProductOptions", "err", err)
+		return nil, err
+	}
+
+	if hasVariants {
+		s.logger.InfoContext(ctx, "product already has variants", "method", "SetProductOptions")
+		return nil, ErrOptionsLocked
+	}
+
+	query = `
+		DELETE FROM product_options
+		WHERE product_id = ?
+	`
+	query = helper.RebindQuery(query)
+
+	if _, err := tx.ExecContext(ctx, query, req.ProductId); err != nil {
+		s.logger.ErrorContext(ctx, "failed to delete product options", "method", "SetProductOptions", "err", err)
+		return nil, err
+	}
+
+	query = `
+		INSERT INTO
+			product_options (product_id, name, position, values)
+		VALUES
+			(?, ?, ?, ?)
+		RETURNING
+			id
+	`
+	query = helper.RebindQuery(query)
+
+	for i, option := range req.Options {
+		d := &model.ProductOption{Name: option.Name, Position: i, Values: option.Values}
+
+		row := tx.QueryRowContext(ctx, query, req.ProductId, d.Name, d.Position, pq.Array(d.Values))
+		if err := row.Scan(&d.Id); err != nil {
+			if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+				s.logger.InfoContext(ctx, "duplicate option name", "method", "SetProductOptions")
+				return nil, ErrOptionNamesNotUnique
+			}
+			s.logger.ErrorContext(ctx, "failed to insert product option", "method", "SetProductOptions", "err", err)
+			return nil, err
+		}
+		res = append(res, d)
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to commit transaction", "method", "SetProductOptions", "err", err)
+		return nil, err
+	}
+
+	s.evictProductCache(ctx, req.ProductId)
+
+	return res, nil
+}
+
+func (s *store) GetProductOptions(ctx context.Context, productId string) ([]*model.ProductOption, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var res = make([]*model.ProductOption, 0)
+
+	query := `
+		SELECT
+			id,
+			name,
+			position,
+			values
+		FROM
+			product_options
+		WHERE
+			product_id = ?
+		ORDER BY
+			position
+	`
+	query = helper.RebindQuery(query)
+
+	rows, err := s.db.QueryContext(ctx, query, productId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to fetch product options", "method", "GetProductOptions", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d model.ProductOption
+		if err := rows.Scan(&d.Id, &d.Name, &d.Position, pq.Array(&d.Values)); err != nil {
+			s.logger.ErrorContext(ctx, "failed to scan product option", "method", "GetProductOptions", "err", err)
+			return nil, err
+		}
+		res = append(res, &d)
+	}
+
+	if err := rows.Err(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to iterate product options", "method", "GetProductOptions", "err", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *store) CreateVariant(ctx context.Context, req *model.CreateVariantReq) (*model.ProductVariant, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var res = &model.ProductVariant{
+		Sku:      req.Sku,
+		Options:  req.Options,
+		Price:    req.Price,
+		ImageUrl: req.ImageUrl,
+	}
+
+	options, err := json.Marshal(req.Options)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to marshal variant options", "method", "CreateVariant", "err", err)
+		return nil, err
+	}
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "method", "CreateVariant", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	shopId, err := s.lockProduct(ctx, tx, req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+
+	query := `
+		INSERT INTO
+			product_variants (product_id, sku, options, price, image_url)
+		VALUES
+			(?, ?, ?, ?, ?)
+		RETURNING
+			id
+	`
+	query = helper.RebindQuery(query)
+
+	row := tx.QueryRowContext(ctx, query, req.ProductId, req.Sku, string(options), req.Price, req.ImageUrl)
+	if err := row.Scan(&res.Id); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			if pqErr.Constraint == "product_variants_sku_key" {
+				s.logger.InfoContext(ctx, "sku already exists", "method", "CreateVariant")
+				return nil, ErrSkuExists
+			}
+			s.logger.InfoContext(ctx, "variant already exists", "method", "CreateVariant")
+			return nil, ErrVariantExists
+		}
+		s.logger.ErrorContext(ctx, "failed to insert variant", "method", "CreateVariant", "err", err)
+		return nil, err
+	}
+
+	if req.Stock > 0 {
+		warehouseId, err := s.defaultWarehouse(ctx, tx, shopId)
+		if err != nil {
+			return nil, err
+		}
+
+		res.Stock, err = s.recordStockMovement(ctx, tx, &model.StockMovement{
+			ProductId:   req.ProductId,
+			VariantId:   &res.Id,
+			WarehouseId: warehouseId,
+			Type:        model.StockMovementReceipt,
+			Quantity:    req.Stock,
+			Reason:      "initial stock",
+			CreatedBy:   &req.UserId,
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to commit transaction", "method", "CreateVariant", "err", err)
+		return nil, err
+	}
+
+	s.evictProductCache(ctx, req.ProductId)
+	s.evictProductsCache(ctx)
+
+	return res, nil
+}
+
+func (s *store) getProductVariants(ctx context.Context, productId string) ([]*model.ProductVariant, error) {
+	var res = make([]*model.ProductVariant, 0)
+
+	query := `
+		SELECT
+			v.id,
+			v.sku,
+			v.options,
+			v.price,
+			v.image_url,
+			COALESCE((SELECT SUM(ws.quantity) FROM warehouse_stocks ws WHERE ws.variant_id = v.id), 0) AS stock
+		FROM
+			product_variants v
+		WHERE
+			v.product_id = ?
+			AND v.deleted_at IS NULL
+		ORDER BY
+			v.created_at
+	`
+	query = helper.RebindQuery(query)
+
+	rows, err := s.db.QueryContext(ctx, query, productId)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to fetch product variants", "method", "getProductVariants", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var (
+			d       model.ProductVariant
+			options []byte
+		)
+		if err := rows.Scan(&d.Id, &d.Sku, &options, &d.Price, &d.ImageUrl, &d.Stock); err != nil {
+			s.logger.ErrorContext(ctx, "failed to scan product variant", "method", "getProductVariants", "err", err)
+			return nil, err
+		}
+
+		if err := json.Unmarshal(options, &d.Options); err != nil {
+			s.logger.ErrorContext(ctx, "failed to unmarshal variant options", "method", "getProductVariants", "err", err)
+			return nil, err
+		}
+		res = append(res, &d)
+	}
+
+	if err := rows.Err(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to iterate product variants", "method", "getProductVariants", "err", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// lockProduct locks a live product for the rest of the transaction and
+// returns the shop it belongs to.
+func (s *store) lockProduct(ctx context.Context, tx *sql.Tx, productId string) (string, error) {
+	var shopId string
+
+	query := `
+		SELECT
+			shop_id
+		FROM
+			products
+		WHERE
+			id = ?
+			AND deleted_at IS NULL
+		FOR UPDATE
+	`
+	query = helper.RebindQuery(query)
+
+	row := tx.QueryRowContext(ctx, query, productId)
+	if err := row.Scan(&shopId); err != nil {
+		if err == sql.ErrNoRows {
+			s.logger.InfoContext(ctx, "no product found", "method", "lockProduct")
+			return "", ErrProductNotFound
+		}
+		s.logger.ErrorContext(ctx, "failed to lock product", "method", "lockProduct", "err", err)
+		return "", err
+	}
+
+	return shopId, nil
+}
+
+// checkVariant makes sure stock of a product is addressed at the right level:
+// products with variants need a variant of their own, others need none.
+func (s *store) checkVariant(ctx context.Context, tx *sql.Tx, productId string, variantId *string) error {
+	var (
+		hasVariants bool
+		found       bool
+	)
+
+	query := `
+		SELECT
+			EXISTS (SELECT 1 FROM product_variants WHERE product_id = ? AND deleted_at IS NULL),
+			EXISTS (SELECT 1 FROM product_variants WHERE id = ?::uuid AND product_id = ? AND deleted_at IS NULL)
+	`
+	query = helper.RebindQuery(query)
+
+	row := tx.QueryRowContext(ctx, query, productId, variantId, productId)
+	if err := row.Scan(&hasVariants, &found); err != nil {
+		s.logger.ErrorContext(ctx, "failed to check product variant", "method", "checkVariant", "err", err)
+		return err
+	}
+
+	if variantId == nil && hasVariants {
+		s.logger.InfoContext(ctx, "variant is required", "method", "checkVariant")
+		return ErrVariantRequired
+	}
+
+	if variantId != nil && !found {
+		s.logger.InfoContext(ctx, "no variant found", "method", "checkVariant")
+		return ErrVariantNotFound
+	}
+
+	return nil
+}
@@ -2,31 +2,42 @@ package users
 
 import (
 	model "codebase-service/models"
+	"context"
 	"database/sql"
 	"fmt"
+	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/google/uuid"
 	"github.com/lib/pq"
 )
 
+// queryTimeout bounds each repository operation, see the products store.
+const queryTimeout = 5 * time.Second
+
 type store struct {
-	db *sql.DB
+	db     *sql.DB
+	logger *slog.Logger
 }
 
-func NewStore(db *sql.DB) *store {
+func NewStore(db *sql.DB, logger *slog.Logger) *store {
 	return &store{
-		db: db,
+		db:     db,
+		logger: logger.With("component", "repo.users"),
 	}
 }
 
 type UserRepository interface {
-	UserRegister(req model.Users) (*uuid.UUID, error)
-	GetUserDetail(req model.Users) (*model.Users, error)
+	UserRegister(ctx context.Context, req model.Users) (*uuid.UUID, error)
+	GetUserDetail(ctx context.Context, req model.Users) (*model.Users, error)
 }
 
-func (s *store) UserRegister(req model.Users) (*uuid.UUID, error) {
-	tx, err := s.db.Begin()
+func (s *store) UserRegister(ctx context.Context, req model.Users) (*uuid.UUID, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	tx, err := s.db.BeginTx(ctx, nil)
 	if err != nil {
 		return nil, err
 	}
@@ -53,7 +64,7 @@ func (s *store) UserRegister(req model.Users) (*uuid.UUID, error) {
 		) RETURNING id
 	`
 
-	if err := tx.QueryRow(
+	if err := tx.QueryRowContext(ctx,
 		queryArgs,
 		req.Email,
 		req.Username,
@@ -74,7 +85,10 @@ func (s *store) UserRegister(req model.Users) (*uuid.UUID, error) {
 	return &userID, nil
 }
 
-func (s *store) GetUserDetail(req model.Users) (*model.Users, error) {
+func (s *store) GetUserDetail(ctx context.Context, req model.Users) (*model.Users, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
 	queryArgs := `
 		SELECT
 			*
@@ -104,7 +118,7 @@ func (s *store) GetUserDetail(req model.Users) (*model.Users, error) {
 	`
 
 	var response model.Users
-	rows, err := s.db.Query(queryArgs)
+	rows, err := s.db.QueryContext(ctx, queryArgs)
 	if err != nil {
 		return nil, fmt.Errorf("failed to execute query: %v", err)
 	}
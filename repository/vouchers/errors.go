@@ -0,0 +1,14 @@
+package vouchers
+
+import "codebase-service/util/apperror"
+
+// Errors callers may need to tell apart with errors.Is. Their kind decides
+// the status code the handlers answer with.
+var (
+	ErrVoucherNotFound       = apperror.New(apperror.NotFound, "voucher_not_found", "voucher not found")
+	ErrNotShopOwner          = apperror.New(apperror.Forbidden, "not_shop_owner", "user is not shop owner")
+	ErrVoucherCodeExists     = apperror.New(apperror.Conflict, "voucher_code_exists", "voucher code already exists")
+	ErrUsageLimitReached     = apperror.New(apperror.Conflict, "usage_limit_reached", "voucher usage limit reached")
+	ErrUserUsageLimitReached = apperror.New(apperror.Conflict, "user_usage_limit_reached", "voucher usage limit per user reached")
+	ErrOrderAlreadyRedeemed  = apperror.New(apperror.Conflict, "order_already_redeemed", "order already redeemed this voucher")
+)
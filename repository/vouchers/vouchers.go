@@ -0,0 +1,437 @@
+package vouchers
+
+import (
+	"codebase-service/helper"
+	model "codebase-service/models"
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+var _ VoucherRepository = &store{}
+
+// queryTimeout bounds each repository operation, see the products store.
+const queryTimeout = 5 * time.Second
+
+type store struct {
+	db     *sql.DB
+	logger *slog.Logger
+}
+
+func NewStore(db *sql.DB, logger *slog.Logger) *store {
+	return &store{
+		db:     db,
+		logger: logger.With("component", "repo.vouchers"),
+	}
+}
+
+type VoucherRepository interface {
+	IsShopOwner(ctx context.Context, userId, shopId string) error
+	CreateVoucher(ctx context.Context, req *model.CreateVoucherReq) (*model.Voucher, error)
+	GetVoucherByCode(ctx context.Context, code string) (*model.Voucher, error)
+	GetVoucherProducts(ctx context.Context, items []*model.VoucherItemReq) ([]*model.VoucherProduct, error)
+	CountUserRedemptions(ctx context.Context, voucherId, userId string) (int64, error)
+	RedeemVoucher(ctx context.Context, voucherId string, req *model.RedeemVoucherReq, discount float64) (*model.RedeemVoucherResp, error)
+}
+
+func (s *store) IsShopOwner(ctx context.Context, userId, shopId string) error {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var isShopOwner bool
+
+	query := `
+		SELECT EXISTS (
+			SELECT 1
+			FROM
+				shops
+			WHERE
+				user_id = ?
+				AND id = ?
+		)
+	`
+	query = helper.RebindQuery(query)
+
+	row := s.db.QueryRowContext(ctx, query, userId, shopId)
+	if err := row.Scan(&isShopOwner); err != nil {
+		s.logger.ErrorContext(ctx, "failed to check if user is shop owner", "method", "IsShopOwner", "err", err)
+		return err
+	}
+
+	if !isShopOwner {
+		s.logger.InfoContext(ctx, "user is not shop owner", "method", "IsShopOwner")
+		return ErrNotShopOwner
+	}
+
+	return nil
+}
+
+func (s *store) CreateVoucher(ctx context.Context, req *model.CreateVoucherReq) (*model.Voucher, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var (
+		res  = new(model.Voucher)
+		args = make([]interface{}, 0)
+	)
+
+	query := `
+		INSERT INTO
+			vouchers (
+				code, shop_id, discount_type, discount_value, min_spend, max_discount,
+				usage_limit, usage_limit_per_user, category_ids, product_ids,
+				starts_at, ends_at, created_by
+			)
+		VALUES
+			(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)
+		RETURNING
+			id
+	`
+	args = append(
+		args, req.Code, req.ShopId, req.DiscountType, req.DiscountValue, req.MinSpend, req.MaxDiscount,
+		req.UsageLimit, req.UsageLimitPerUser, pq.Array(req.CategoryIds), pq.Array(req.ProductIds),
+		req.StartsAt, req.EndsAt, req.UserId,
+	)
+
+	query = helper.RebindQuery(query)
+
+	row := s.db.QueryRowContext(ctx, query, args...)
+	if err := row.Scan(&res.Id); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			s.logger.InfoContext(ctx, "voucher code already exists", "method", "CreateVoucher")
+			return nil, ErrVoucherCodeExists
+		}
+		s.logger.ErrorContext(ctx, "failed to scan voucher id", "method", "CreateVoucher", "err", err)
+		return nil, err
+	}
+
+	res.Code = req.Code
+	res.ShopId = req.ShopId
+	res.DiscountType = req.DiscountType
+	res.DiscountValue = req.DiscountValue
+	res.MinSpend = req.MinSpend
+	res.MaxDiscount = req.MaxDiscount
+	res.UsageLimit = req.UsageLimit
+	res.UsageLimitPerUser = req.UsageLimitPerUser
+	res.CategoryIds = req.CategoryIds
+	res.ProductIds = req.ProductIds
+	res.StartsAt = req.StartsAt
+	res.EndsAt = req.EndsAt
+
+	return res, nil
+}
+
+func (s *store) GetVoucherByCode(ctx context.Context, code string) (*model.Voucher, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var res = new(model.Voucher)
+
+	query := `
+		SELECT
+			id,
+			code,
+			shop_id,
+			discount_type,
+			discount_value,
+			min_spend,
+			max_discount,
+			usage_limit,
+			usage_limit_per_user,
+			used_count,
+			category_ids,
+			product_ids,
+			starts_at,
+			ends_at
+		FROM
+			vouchers
+		WHERE
+			code = ?
+			AND deleted_at IS NULL
+	`
+	query = helper.RebindQuery(query)
+
+	row := s.db.QueryRowContext(ctx, query, code)
+	if err := row.Scan(
+		&res.Id,
+		&res.Code,
+		&res.ShopId,
+		&res.DiscountType,
+		&res.DiscountValue,
+		&res.MinSpend,
+		&res.MaxDiscount,
+		&res.UsageLimit,
+		&res.UsageLimitPerUser,
+		&res.UsedCount,
+		pq.Array(&res.CategoryIds),
+		pq.Array(&res.ProductIds),
+		&res.StartsAt,
+		&res.EndsAt,
+	); err != nil {
+		if err == sql.ErrNoRows {
+			s.logger.InfoContext(ctx, "no voucher found", "method", "GetVoucherByCode")
+			return nil, ErrVoucherNotFound
+		}
+		s.logger.ErrorContext(ctx, "failed to fetch voucher data", "method", "GetVoucherByCode", "err", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// GetVoucherProducts returns a row for each item that is a published product,
+// or a live variant of one, priced like the products store prices a
+// reservation line: the running promotion's sale price, or else the variant's
+// own price, or else the product price.
+func (s *store) GetVoucherProducts(ctx context.Context, items []*model.VoucherItemReq) ([]*model.VoucherProduct, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var (
+		res        = make([]*model.VoucherProduct, 0, len(items))
+		productIds = make([]string, 0, len(items))
+		variantIds = make([]sql.NullString, 0, len(items))
+	)
+
+	for _, item := range items {
+		productIds = append(productIds, item.ProductId)
+		if item.VariantId != nil {
+			variantIds = append(variantIds, sql.NullString{String: *item.VariantId, Valid: true})
+		} else {
+			variantIds = append(variantIds, sql.NullString{})
+		}
+	}
+
+	// keep the pricing in step with unitPrice in the products store
+	query := `
+		SELECT DISTINCT
+			p.id,
+			pv.id,
+			p.shop_id,
+			p.category_id,
+			COALESCE(promo.sale_price, pv.price, p.price)
+		FROM
+			unnest(?::uuid[], ?::uuid[]) AS i(product_id, variant_id)
+		JOIN
+			products p ON p.id = i.product_id
+		LEFT JOIN
+			product_variants pv ON pv.id = i.variant_id AND pv.product_id = p.id AND pv.deleted_at IS NULL
+		LEFT JOIN LATERAL (
+			SELECT
+				pp.sale_price
+			FROM
+				product_promotions pp
+			WHERE
+				pp.product_id = p.id
+				AND pp.deleted_at IS NULL
+				AND pp.starts_at <= NOW()
+				AND pp.ends_at > NOW()
+				AND (pp.quantity IS NULL OR pp.claimed < pp.quantity)
+			LIMIT 1
+		) promo ON true
+		WHERE
+			p.status = 'published'
+			AND p.deleted_at IS NULL
+			AND (i.variant_id IS NULL OR pv.id IS NOT NULL)
+	`
+	query = helper.RebindQuery(query)
+
+	rows, err := s.db.QueryContext(ctx, query, pq.Array(productIds), pq.Array(variantIds))
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to fetch products data", "method", "GetVoucherProducts", "err", err)
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var d model.VoucherProduct
+		if err := rows.Scan(&d.Id, &d.VariantId, &d.ShopId, &d.CategoryId, &d.Price); err != nil {
+			s.logger.ErrorContext(ctx, "failed to scan product data", "method", "GetVoucherProducts", "err", err)
+			return nil, err
+		}
+		res = append(res, &d)
+	}
+
+	if err := rows.Err(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to iterate products data", "method", "GetVoucherProducts", "err", err)
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *store) CountUserRedemptions(ctx context.Context, voucherId, userId string) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var count int64
+
+	query := `
+		SELECT
+			COUNT(*)
+		FROM
+			voucher_redemptions
+		WHERE
+			voucher_id = ?
+			AND user_id = ?
+	`
+	query = helper.RebindQuery(query)
+
+	row := s.db.QueryRowContext(ctx, query, voucherId, userId)
+	if err := row.Scan(&count); err != nil {
+		s.logger.ErrorContext(ctx, "failed to count redemptions", "method", "CountUserRedemptions", "err", err)
+		return 0, err
+	}
+
+	return count, nil
+}
+
+// RedeemVoucher records a redemption while holding a row lock on the voucher,
+// so concurrent checkouts are serialized and usage limits cannot be overrun.
+// Redeeming the same order twice returns the original redemption, an order
+// another user already redeemed the voucher for is a conflict.
+func (s *store) RedeemVoucher(ctx context.Context, voucherId string, req *model.RedeemVoucherReq, discount float64) (*model.RedeemVoucherResp, error) {
+	ctx, cancel := context.WithTimeout(ctx, queryTimeout)
+	defer cancel()
+
+	var (
+		res               = new(model.RedeemVoucherResp)
+		usedCount         int64
+		usageLimit        *int64
+		usageLimitPerUser *int64
+	)
+
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to begin transaction", "method", "RedeemVoucher", "err", err)
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	query := `
+		SELECT
+			used_count,
+			usage_limit,
+			usage_limit_per_user
+		FROM
+			vouchers
+		WHERE
+			id = ?
+			AND deleted_at IS NULL
+		FOR UPDATE
+	`
+	query = helper.RebindQuery(query)
+
+	row := tx.QueryRowContext(ctx, query, voucherId)
+	if err := row.Scan(&usedCount, &usageLimit, &usageLimitPerUser); err != nil {
+		if err == sql.ErrNoRows {
+			s.logger.InfoContext(ctx, "no voucher found", "method", "RedeemVoucher")
+			return nil, ErrVoucherNotFound
+		}
+		s.logger.ErrorContext(ctx, "failed to lock voucher", "method", "RedeemVoucher", "err", err)
+		return nil, err
+	}
+
+	query = `
+		SELECT
+			id,
+			discount_amount
+		FROM
+			voucher_redemptions
+		WHERE
+			voucher_id = ?
+			AND order_id = ?
+			AND user_id = ?
+	`
+	query = helper.RebindQuery(query)
+
+	row = tx.QueryRowContext(ctx, query, voucherId, req.OrderId, req.UserId)
+	err = row.Scan(&res.RedemptionId, &res.DiscountAmount)
+	if err == nil {
+		s.logger.InfoContext(ctx, "order already redeemed this voucher", "method", "RedeemVoucher", "order_id", req.OrderId)
+		res.VoucherId = voucherId
+		res.OrderId = req.OrderId
+		return res, nil
+	}
+	if err != sql.ErrNoRows {
+		s.logger.ErrorContext(ctx, "failed to check existing redemption", "method", "RedeemVoucher", "err", err)
+		return nil, err
+	}
+
+	if usageLimit != nil && usedCount >= *usageLimit {
+		s.logger.InfoContext(ctx, "voucher usage limit reached", "method", "RedeemVoucher")
+		return nil, ErrUsageLimitReached
+	}
+
+	if usageLimitPerUser != nil {
+		var userCount int64
+
+		query = `
+			SELECT
+				COUNT(*)
+			FROM
+				voucher_redemptions
+			WHERE
+				voucher_id = ?
+				AND user_id = ?
+		`
+		query = helper.RebindQuery(query)
+
+		row = tx.QueryRowContext(ctx, query, voucherId, req.UserId)
+		if err := row.Scan(&userCount); err != nil {
+			s.logger.ErrorContext(ctx, "failed to count user redemptions", "method", "RedeemVoucher", "err", err)
+			return nil, err
+		}
+
+		if userCount >= *usageLimitPerUser {
+			s.logger.InfoContext(ctx, "voucher usage limit per user reached", "method", "RedeemVoucher")
+			return nil, ErrUserUsageLimitReached
+		}
+	}
+
+	query = `
+		INSERT INTO
+			voucher_redemptions (voucher_id, user_id, order_id, discount_amount)
+		VALUES
+			(?, ?, ?, ?)
+		RETURNING
+			id
+	`
+	query = helper.RebindQuery(query)
+
+	row = tx.QueryRowContext(ctx, query, voucherId, req.UserId, req.OrderId, discount)
+	if err := row.Scan(&res.RedemptionId); err != nil {
+		if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+			s.logger.InfoContext(ctx, "order redeemed by another user", "method", "RedeemVoucher", "order_id", req.OrderId)
+			return nil, ErrOrderAlreadyRedeemed
+		}
+		s.logger.ErrorContext(ctx, "failed to insert redemption", "method", "RedeemVoucher", "err", err)
+		return nil, err
+	}
+
+	query = `
+		UPDATE vouchers
+		SET used_count = used_count + 1, updated_at = NOW()
+		WHERE id = ?
+	`
+	query = helper.RebindQuery(query)
+
+	if _, err := tx.ExecContext(ctx, query, voucherId); err != nil {
+		s.logger.ErrorContext(ctx, "failed to increment used count", "method", "RedeemVoucher", "err", err)
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		s.logger.ErrorContext(ctx, "failed to commit transaction", "method", "RedeemVoucher", "err", err)
+		return nil, err
+	}
+
+	res.VoucherId = voucherId
+	res.OrderId = req.OrderId
+	res.DiscountAmount = discount
+
+	return res, nil
+}
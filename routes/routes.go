@@ -3,21 +3,37 @@ package routes
 import (
 	"codebase-service/config"
 	"codebase-service/util/middleware"
-	"log"
+	"expvar"
+	"log/slog"
 	"net/http"
+	"os"
 	"strings"
 	"time"
 
+	cache "codebase-service/handlers/caches"
+	moderation "codebase-service/handlers/moderation"
 	product "codebase-service/handlers/products"
+	reservation "codebase-service/handlers/reservations"
+	upload "codebase-service/handlers/uploads"
 	user "codebase-service/handlers/users"
+	voucher "codebase-service/handlers/vouchers"
 
 	"github.com/spf13/viper"
 )
 
 type Routes struct {
-	Router  *http.ServeMux
-	User    *user.Handler
-	Product *product.Handler
+	Router      *http.ServeMux
+	User        *user.Handler
+	Product     *product.Handler
+	Voucher     *voucher.Handler
+	Reservation *reservation.Handler
+	Upload      *upload.Handler
+	Moderation  *moderation.Handler
+	Cache       *cache.Handler
+	Logger      *slog.Logger
+
+	// UploadFiles serves uploaded files when they are kept on local disk
+	UploadFiles http.Handler
 }
 
 func URLRewriter(baseURLPath string, next http.Handler) http.HandlerFunc {
@@ -40,31 +56,102 @@ func (r *Routes) SetupRouter() {
 	r.SetupBaseURL()
 	r.userRoutes()
 	r.productRoutes()
+	r.voucherRoutes()
+	r.reservationRoutes()
+	r.uploadRoutes()
+	r.adminRoutes()
+	r.debugRoutes()
 }
 
 func (r *Routes) userRoutes() {
-	r.Router.HandleFunc("POST /signup", middleware.ApplyMiddleware(r.User.SignUpByEmail, middleware.EnabledCors, middleware.LoggerMiddleware()))
-	r.Router.Handle("POST /signin", middleware.ApplyMiddleware(r.User.SignInByEmail, middleware.EnabledCors, middleware.LoggerMiddleware()))
+	r.Router.HandleFunc("POST /signup", middleware.ApplyMiddleware(r.User.SignUpByEmail, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.Handle("POST /signin", middleware.ApplyMiddleware(r.User.SignInByEmail, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
 }
 
 func (r *Routes) productRoutes() {
-	r.Router.HandleFunc("GET /products/{id}", middleware.ApplyMiddleware(r.Product.GetProduct, middleware.EnabledCors, middleware.LoggerMiddleware()))
-	r.Router.HandleFunc("GET /products", middleware.ApplyMiddleware(r.Product.GetProducts, middleware.EnabledCors, middleware.LoggerMiddleware()))
+	r.Router.HandleFunc("GET /products/{id}", middleware.ApplyMiddleware(r.Product.GetProduct, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("GET /products", middleware.ApplyMiddleware(r.Product.GetProducts, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+
+	r.Router.HandleFunc("POST /products", middleware.ApplyMiddleware(r.Product.CreateProduct, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("PUT /products/{id}", middleware.ApplyMiddleware(r.Product.UpdateProduct, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("DELETE /products/{id}", middleware.ApplyMiddleware(r.Product.DeleteProduct, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+
+	r.Router.HandleFunc("POST /products/{id}/restore", middleware.ApplyMiddleware(r.Product.RestoreProduct, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("PUT /products/{id}/status", middleware.ApplyMiddleware(r.Product.SetProductStatus, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("POST /products/{id}/promotions", middleware.ApplyMiddleware(r.Product.CreatePromotion, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("PUT /products/{id}/options", middleware.ApplyMiddleware(r.Product.SetProductOptions, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("POST /products/{id}/variants", middleware.ApplyMiddleware(r.Product.CreateVariant, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("POST /products/{id}/images", middleware.ApplyMiddleware(r.Product.AddProductImage, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("PUT /products/{id}/images/order", middleware.ApplyMiddleware(r.Product.ReorderProductImages, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("DELETE /products/{id}/images/{imageId}", middleware.ApplyMiddleware(r.Product.DeleteProductImage, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("POST /products/{id}/stock-adjustments", middleware.ApplyMiddleware(r.Product.CreateStockAdjustment, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("POST /shops/{id}/imports", middleware.ApplyMiddleware(r.Product.CreateImport, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("GET /imports/{id}", middleware.ApplyMiddleware(r.Product.GetImport, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("GET /shops/{id}/products/export", middleware.ApplyMiddleware(r.Product.ExportProducts, middleware.GetUserRole, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("GET /shops/{id}/products", middleware.ApplyMiddleware(r.Product.GetShopProducts, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("POST /shops/{id}/warehouses", middleware.ApplyMiddleware(r.Product.CreateWarehouse, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("GET /shops/{id}/warehouses", middleware.ApplyMiddleware(r.Product.GetWarehouses, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+
+	r.Router.HandleFunc("POST /promotions/{id}/claim", middleware.ApplyMiddleware(r.Product.ClaimFlashSale, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+
+	r.Router.HandleFunc("GET /categories/{id}/attributes", middleware.ApplyMiddleware(r.Product.GetCategoryAttributes, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("PUT /categories/{id}/attributes", middleware.ApplyMiddleware(r.Product.SetCategoryAttributes, middleware.GetUserRole, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+}
+
+func (r *Routes) voucherRoutes() {
+	r.Router.HandleFunc("POST /vouchers", middleware.ApplyMiddleware(r.Voucher.CreateVoucher, middleware.GetUserRole, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("POST /vouchers/apply", middleware.ApplyMiddleware(r.Voucher.ApplyVoucher, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("POST /vouchers/redeem", middleware.ApplyMiddleware(r.Voucher.RedeemVoucher, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+}
+
+func (r *Routes) reservationRoutes() {
+	r.Router.HandleFunc("POST /reservations", middleware.ApplyMiddleware(r.Reservation.CreateReservation, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("POST /reservations/{id}/confirm", middleware.ApplyMiddleware(r.Reservation.ConfirmReservation, middleware.GetUserRole, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("DELETE /reservations/{id}", middleware.ApplyMiddleware(r.Reservation.ReleaseReservation, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("POST /reservations/{id}/refunds", middleware.ApplyMiddleware(r.Reservation.RefundReservation, middleware.GetUserRole, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+}
+
+func (r *Routes) adminRoutes() {
+	r.Router.HandleFunc("GET /admin/moderation", middleware.ApplyMiddleware(r.Moderation.GetModerationQueue, middleware.GetUserRole, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("POST /admin/moderation/{id}/approve", middleware.ApplyMiddleware(r.Moderation.ApproveProduct, middleware.GetUserRole, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("POST /admin/moderation/{id}/reject", middleware.ApplyMiddleware(r.Moderation.RejectProduct, middleware.GetUserRole, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
 
-	r.Router.HandleFunc("POST /products", middleware.ApplyMiddleware(r.Product.CreateProduct, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware()))
-	r.Router.HandleFunc("DELETE /products/{id}", middleware.ApplyMiddleware(r.Product.DeleteProduct, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware()))
+	r.Router.HandleFunc("GET /admin/cache/namespaces", middleware.ApplyMiddleware(r.Cache.GetNamespaces, middleware.GetUserRole, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	// keys may hold slashes, the key wildcard takes the rest of the path
+	r.Router.HandleFunc("GET /admin/cache/namespaces/{namespace}/keys/{key...}", middleware.ApplyMiddleware(r.Cache.InspectCache, middleware.GetUserRole, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("DELETE /admin/cache/namespaces/{namespace}", middleware.ApplyMiddleware(r.Cache.PurgeNamespace, middleware.GetUserRole, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("DELETE /admin/cache/products/{id}", middleware.ApplyMiddleware(r.Cache.PurgeProductCache, middleware.GetUserRole, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("DELETE /admin/cache/shops/{id}", middleware.ApplyMiddleware(r.Cache.PurgeShopCache, middleware.GetUserRole, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	r.Router.HandleFunc("POST /admin/cache/warm-up", middleware.ApplyMiddleware(r.Cache.WarmCache, middleware.GetUserRole, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+}
+
+// debugRoutes publishes the expvar metrics, cache hits, misses and
+// degradation events included, to admins only.
+func (r *Routes) debugRoutes() {
+	r.Router.HandleFunc("GET /debug/vars", middleware.ApplyMiddleware(expvar.Handler().ServeHTTP, middleware.RequireAdmin, middleware.GetUserRole, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+}
+
+func (r *Routes) uploadRoutes() {
+	r.Router.HandleFunc("POST /uploads/images", middleware.ApplyMiddleware(r.Upload.UploadImage, middleware.GetUserId, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+
+	if r.UploadFiles != nil {
+		r.Router.HandleFunc("GET /uploads/files/", middleware.ApplyMiddleware(http.StripPrefix("/uploads/files/", r.UploadFiles).ServeHTTP, middleware.EnabledCors, middleware.LoggerMiddleware(r.Logger)))
+	}
 }
 
 func (r *Routes) Run(port string) {
 	r.SetupRouter()
 
-	log.Printf("[Running-Success] clients on localhost on port :%s", port)
+	r.Logger.Info("server listening", "addr", "localhost:"+port)
 	srv := &http.Server{
-		Handler:      r.Router,
+		Handler:      middleware.RequestID(r.Router),
 		Addr:         "localhost:" + port,
 		WriteTimeout: config.WriteTimeout() * time.Second,
 		ReadTimeout:  config.ReadTimeout() * time.Second,
 	}
 
-	log.Panic(srv.ListenAndServe())
+	if err := srv.ListenAndServe(); err != nil {
+		r.Logger.Error("server stopped", "err", err)
+		os.Exit(1)
+	}
 }
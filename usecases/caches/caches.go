@@ -0,0 +1,250 @@
+package caches
+
+import (
+	model "codebase-service/models"
+	"codebase-service/repository/products"
+	"codebase-service/util/cache"
+	"context"
+	"errors"
+	"log/slog"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	roleAdmin = "admin"
+
+	// warm-up ranks products by what sold in the last topProductsWindow and
+	// loads warmUpWorkers of them at a time, easy on the db after a deploy
+	topProductsWindow = 30 * 24 * time.Hour
+	warmUpWorkers     = 4
+)
+
+var _ CacheSvc = &svc{}
+
+type svc struct {
+	store  products.ProductRepository
+	cache  *cache.Client
+	now    func() time.Time
+	logger *slog.Logger
+}
+
+func NewCacheSvc(store products.ProductRepository, cacheClient *cache.Client, logger *slog.Logger) *svc {
+	return &svc{
+		store:  store,
+		cache:  cacheClient,
+		now:    time.Now,
+		logger: logger.With("component", "svc.caches"),
+	}
+}
+
+type CacheSvc interface {
+	GetNamespaces(ctx context.Context, req *model.GetCacheNamespacesReq) ([]*model.CacheNamespace, error)
+	InspectCache(ctx context.Context, req *model.InspectCacheReq) (*model.CacheEntry, error)
+	PurgeCache(ctx context.Context, req *model.PurgeCacheReq) (*model.PurgeCacheResp, error)
+	WarmCache(ctx context.Context, req *model.WarmCacheReq) (*model.WarmCacheResp, error)
+}
+
+func isAdmin(role string) error {
+	if !strings.EqualFold(role, roleAdmin) {
+		return ErrNotAdmin
+	}
+
+	return nil
+}
+
+func (s *svc) GetNamespaces(ctx context.Context, req *model.GetCacheNamespacesReq) ([]*model.CacheNamespace, error) {
+	if err := isAdmin(req.Role); err != nil {
+		return nil, err
+	}
+
+	res := make([]*model.CacheNamespace, 0)
+	for _, ns := range s.cache.Namespaces() {
+		res = append(res, &model.CacheNamespace{
+			Name:      ns.Name,
+			Versioned: ns.Versioned,
+			TTL:       ns.TTL.String(),
+		})
+	}
+
+	return res, nil
+}
+
+func (s *svc) InspectCache(ctx context.Context, req *model.InspectCacheReq) (*model.CacheEntry, error) {
+	if err := isAdmin(req.Role); err != nil {
+		return nil, err
+	}
+
+	entry, err := s.cache.Inspect(ctx, req.Namespace, req.Key)
+	if err != nil {
+		return nil, s.cacheError(ctx, "InspectCache", err)
+	}
+
+	return &model.CacheEntry{
+		Namespace: entry.Namespace,
+		Key:       entry.Key,
+		RedisKey:  entry.RedisKey,
+		InL1:      entry.InL1,
+		InL2:      entry.InL2,
+		TTL:       entry.TTL.String(),
+		Size:      entry.Size,
+		NotFound:  entry.NotFound,
+		Value:     entry.Value,
+	}, nil
+}
+
+// PurgeCache drops the cached entries of a product, of every product of a
+// shop, or a whole namespace. Purges that cannot reach redis are replayed
+// once it is back.
+func (s *svc) PurgeCache(ctx context.Context, req *model.PurgeCacheReq) (*model.PurgeCacheResp, error) {
+	if err := isAdmin(req.Role); err != nil {
+		return nil, err
+	}
+
+	if !s.cache.Enabled() {
+		return nil, ErrCacheUnavailable
+	}
+
+	res := &model.PurgeCacheResp{Namespaces: make([]string, 0)}
+
+	switch {
+	case req.Namespace != "":
+		if err := s.cache.Flush(ctx, req.Namespace); err != nil {
+			return nil, s.cacheError(ctx, "PurgeCache", err)
+		}
+		res.Namespaces = append(res.Namespaces, req.Namespace)
+	case req.ShopId != "":
+		ids, err := s.store.GetShopProductIds(ctx, req.ShopId)
+		if err != nil {
+			return nil, err
+		}
+
+		if err := s.store.PurgeProductCache(ctx, ids...); err != nil {
+			return nil, s.cacheError(ctx, "PurgeCache", err)
+		}
+		res.Products = len(ids)
+	case req.ProductId != "":
+		if err := s.store.PurgeProductCache(ctx, req.ProductId); err != nil {
+			return nil, s.cacheError(ctx, "PurgeCache", err)
+		}
+		res.Products = 1
+	default:
+		return nil, ErrNothingToPurge
+	}
+
+	s.logger.InfoContext(ctx, "purged cache", "method", "PurgeCache", "products", res.Products, "namespaces", res.Namespaces)
+	return res, nil
+}
+
+func (s *svc) WarmCache(ctx context.Context, req *model.WarmCacheReq) (*model.WarmCacheResp, error) {
+	if err := isAdmin(req.Role); err != nil {
+		return nil, err
+	}
+
+	return s.warmUp(ctx, req)
+}
+
+// RunWarmUp warms the cache once, at startup. The service serves requests
+// meanwhile, the first ones just miss as they would have anyway.
+func (s *svc) RunWarmUp(ctx context.Context, req *model.WarmCacheReq) {
+	started := s.now()
+
+	res, err := s.warmUp(ctx, req)
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to warm cache", "method", "RunWarmUp", "err", err)
+		return
+	}
+
+	s.logger.InfoContext(ctx, "warmed cache", "method", "RunWarmUp", "products", res.Products, "pages", res.Pages, "failed", res.Failed, "took", s.now().Sub(started))
+}
+
+// warmUp loads the best selling products and the first listing pages
+// through the store, which caches them on the way. A failed load is
+// counted and skipped, the rest of the warm-up goes on.
+func (s *svc) warmUp(ctx context.Context, req *model.WarmCacheReq) (*model.WarmCacheResp, error) {
+	if !s.cache.Enabled() {
+		return nil, ErrCacheUnavailable
+	}
+
+	req.SetDefault()
+
+	ids, err := s.store.GetTopProductIds(ctx, s.now().Add(-topProductsWindow), req.Products)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		warmed, failed atomic.Int32
+		pages          int
+	)
+
+	g, gCtx := errgroup.WithContext(ctx)
+	g.SetLimit(warmUpWorkers)
+	for _, id := range ids {
+		if gCtx.Err() != nil {
+			break
+		}
+
+		g.Go(func() error {
+			_, err := s.store.GetProduct(ctx, &model.GetProductReq{Id: id})
+			switch {
+			case err == nil:
+				warmed.Add(1)
+			case !errors.Is(err, products.ErrProductNotFound):
+				// a product unpublished since it was ranked is not a failure
+				s.logger.ErrorContext(ctx, "failed to load product", "method", "warmUp", "product_id", id, "err", err)
+				failed.Add(1)
+			}
+			return nil
+		})
+	}
+	g.Wait()
+
+	// pages are loaded in order, past the last one there is nothing to warm
+	for page := 1; page <= req.Pages && ctx.Err() == nil; page++ {
+		res, err := s.store.GetProducts(ctx, &model.GetProductsReq{Page: page, Limit: req.Limit})
+		if err != nil {
+			s.logger.ErrorContext(ctx, "failed to load page", "method", "warmUp", "page", page, "err", err)
+			failed.Add(1)
+			continue
+		}
+
+		pages++
+		if res.Meta == nil || page >= res.Meta.TotalPage {
+			break
+		}
+	}
+
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	return &model.WarmCacheResp{
+		Products: int(warmed.Load()),
+		Pages:    pages,
+		Failed:   int(failed.Load()),
+	}, nil
+}
+
+// cacheError turns cache errors into the errors handlers map to a status,
+// anything else is logged and returned as is.
+func (s *svc) cacheError(ctx context.Context, method string, err error) error {
+	switch {
+	case errors.Is(err, cache.ErrMiss):
+		return ErrKeyNotFound
+	case errors.Is(err, cache.ErrUnknownNamespace):
+		return ErrUnknownNamespace
+	case errors.Is(err, cache.ErrFlushPending), errors.Is(err, cache.ErrDeletePending):
+		s.logger.WarnContext(ctx, "cache purge queued", "method", method, "err", err)
+		return ErrPurgePending
+	case errors.Is(err, cache.ErrUnavailable):
+		s.logger.WarnContext(ctx, "cache unavailable", "method", method, "err", err)
+		return ErrCacheUnavailable
+	default:
+		s.logger.ErrorContext(ctx, "failed to read cache", "method", method, "err", err)
+		return err
+	}
+}
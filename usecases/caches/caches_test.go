@@ -0,0 +1,164 @@
+package caches
+
+import (
+	mock_products "codebase-service/mock/repository/products"
+	model "codebase-service/models"
+	"codebase-service/repository/products"
+	"codebase-service/util/cache"
+	"codebase-service/util/logging"
+	"context"
+	"errors"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestCacheService(t *testing.T) {
+	suite.Run(t, new(CacheServiceTestSuite))
+}
+
+type CacheServiceTestSuite struct {
+	suite.Suite
+	productRepo *mock_products.MockProductRepo
+	redis       *miniredis.Miniredis
+	client      *cache.Client
+	service     *svc
+}
+
+func (s *CacheServiceTestSuite) SetupTest() {
+	s.productRepo = mock_products.NewMockProductRepo()
+	s.redis = miniredis.RunT(s.T())
+	s.client = cache.NewClient(redis.NewClient(&redis.Options{Addr: s.redis.Addr()}), cache.Config{})
+	s.service = NewCacheSvc(s.productRepo, s.client, logging.Discard())
+	s.service.now = func() time.Time { return time.Date(2024, 11, 20, 10, 0, 0, 0, time.UTC) }
+}
+
+func (s *CacheServiceTestSuite) TestWarmCache_NotAdmin() {
+	resp, err := s.service.WarmCache(context.Background(), &model.WarmCacheReq{Role: "seller"})
+
+	s.EqualError(err, "user is not admin")
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *CacheServiceTestSuite) TestWarmCache() {
+	since := time.Date(2024, 10, 21, 10, 0, 0, 0, time.UTC)
+	s.productRepo.On("GetTopProductIds", mock.Anything, since, 3).Return([]string{"p1", "p2", "p3"}, nil)
+	s.productRepo.On("GetProduct", mock.Anything, &model.GetProductReq{Id: "p1"}).Return(&model.GetProductResp{Id: "p1"}, nil)
+	s.productRepo.On("GetProduct", mock.Anything, &model.GetProductReq{Id: "p2"}).Return(nil, products.ErrProductNotFound)
+	s.productRepo.On("GetProduct", mock.Anything, &model.GetProductReq{Id: "p3"}).Return(nil, errors.New("connection refused"))
+	s.productRepo.On("GetProducts", mock.Anything, &model.GetProductsReq{Page: 1, Limit: 10}).
+		Return(&model.GetProductsResp{Meta: &model.Meta{Page: 1, TotalPage: 2}}, nil)
+	s.productRepo.On("GetProducts", mock.Anything, &model.GetProductsReq{Page: 2, Limit: 10}).
+		Return(&model.GetProductsResp{Meta: &model.Meta{Page: 2, TotalPage: 2}}, nil)
+
+	// a third page is asked for but the listing only has two
+	resp, err := s.service.WarmCache(context.Background(), &model.WarmCacheReq{Role: "admin", Products: 3, Pages: 3})
+
+	s.NoError(err)
+	s.Equal(&model.WarmCacheResp{Products: 1, Pages: 2, Failed: 1}, resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *CacheServiceTestSuite) TestWarmCache_WithoutCache() {
+	s.service = NewCacheSvc(s.productRepo, cache.NewClient(nil, cache.Config{}), logging.Discard())
+
+	resp, err := s.service.WarmCache(context.Background(), &model.WarmCacheReq{Role: "admin"})
+
+	s.EqualError(err, "cache unavailable")
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *CacheServiceTestSuite) TestPurgeCache_Shop() {
+	s.productRepo.On("GetShopProductIds", mock.Anything, "s1").Return([]string{"p1", "p2"}, nil)
+	s.productRepo.On("PurgeProductCache", mock.Anything, []string{"p1", "p2"}).Return(nil)
+
+	resp, err := s.service.PurgeCache(context.Background(), &model.PurgeCacheReq{Role: "admin", ShopId: "s1"})
+
+	s.NoError(err)
+	s.Equal(2, resp.Products)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *CacheServiceTestSuite) TestPurgeCache_Product() {
+	s.productRepo.On("PurgeProductCache", mock.Anything, []string{"p1"}).Return(nil)
+
+	resp, err := s.service.PurgeCache(context.Background(), &model.PurgeCacheReq{Role: "admin", ProductId: "p1"})
+
+	s.NoError(err)
+	s.Equal(1, resp.Products)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *CacheServiceTestSuite) TestPurgeCache_ProductPending() {
+	s.productRepo.On("PurgeProductCache", mock.Anything, []string{"p1"}).Return(errors.Join(cache.ErrDeletePending, cache.ErrFlushPending))
+
+	resp, err := s.service.PurgeCache(context.Background(), &model.PurgeCacheReq{Role: "admin", ProductId: "p1"})
+
+	s.ErrorIs(err, ErrPurgePending)
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *CacheServiceTestSuite) TestPurgeCache_Namespace() {
+	items := cache.New[string](s.client, "item")
+	s.NoError(items.Set(context.Background(), "a", "value", time.Minute))
+
+	resp, err := s.service.PurgeCache(context.Background(), &model.PurgeCacheReq{Role: "admin", Namespace: "item"})
+
+	s.NoError(err)
+	s.Equal([]string{"item"}, resp.Namespaces)
+	_, err = items.Get(context.Background(), "a")
+	s.ErrorIs(err, cache.ErrMiss)
+}
+
+func (s *CacheServiceTestSuite) TestPurgeCache_NamespacePending() {
+	cache.New[string](s.client, "item")
+	s.redis.Close()
+
+	resp, err := s.service.PurgeCache(context.Background(), &model.PurgeCacheReq{Role: "admin", Namespace: "item"})
+
+	s.ErrorIs(err, ErrPurgePending)
+	s.Nil(resp)
+}
+
+func (s *CacheServiceTestSuite) TestPurgeCache_UnknownNamespace() {
+	resp, err := s.service.PurgeCache(context.Background(), &model.PurgeCacheReq{Role: "admin", Namespace: "nope"})
+
+	s.EqualError(err, "unknown cache namespace")
+	s.Nil(resp)
+}
+
+func (s *CacheServiceTestSuite) TestInspectCache() {
+	items := cache.New[*model.GetProductResp](s.client, "item")
+	s.NoError(items.Set(context.Background(), "p1", &model.GetProductResp{Id: "p1", Name: "Kemeja"}, time.Minute))
+
+	resp, err := s.service.InspectCache(context.Background(), &model.InspectCacheReq{Role: "admin", Namespace: "item", Key: "p1"})
+
+	s.NoError(err)
+	s.Equal("cache:item:p1", resp.RedisKey)
+	s.True(resp.InL2)
+	s.Equal("1m0s", resp.TTL)
+	s.Equal("Kemeja", resp.Value.(map[string]interface{})["name"])
+
+	_, err = s.service.InspectCache(context.Background(), &model.InspectCacheReq{Role: "admin", Namespace: "item", Key: "p2"})
+	s.EqualError(err, "cache key not found")
+}
+
+func (s *CacheServiceTestSuite) TestGetNamespaces() {
+	cache.NewVersioned[string](s.client, "pages")
+	cache.New[string](s.client, "item")
+
+	resp, err := s.service.GetNamespaces(context.Background(), &model.GetCacheNamespacesReq{Role: "Admin"})
+
+	s.NoError(err)
+	s.Equal([]*model.CacheNamespace{
+		{Name: "item", TTL: "5m0s"},
+		{Name: "pages", Versioned: true, TTL: "5m0s"},
+	}, resp)
+}
@@ -0,0 +1,13 @@
+package caches
+
+import "codebase-service/util/apperror"
+
+// Errors the service reports to its callers.
+var (
+	ErrNotAdmin         = apperror.New(apperror.Forbidden, "not_admin", "user is not admin")
+	ErrKeyNotFound      = apperror.New(apperror.NotFound, "cache_key_not_found", "cache key not found")
+	ErrUnknownNamespace = apperror.New(apperror.NotFound, "unknown_cache_namespace", "unknown cache namespace")
+	ErrNothingToPurge   = apperror.New(apperror.Validation, "nothing_to_purge", "nothing to purge")
+	ErrCacheUnavailable = apperror.New(apperror.Unavailable, "cache_unavailable", "cache unavailable")
+	ErrPurgePending     = apperror.New(apperror.Unavailable, "cache_purge_pending", "cache unavailable, the purge runs once it is back")
+)
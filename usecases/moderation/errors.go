@@ -0,0 +1,9 @@
+package moderation
+
+import "codebase-service/util/apperror"
+
+// Errors the service reports to its callers.
+var (
+	ErrNotAdmin       = apperror.New(apperror.Forbidden, "not_admin", "user is not admin")
+	ErrReasonRequired = apperror.New(apperror.Validation, "reason_required", "reason is required to reject a product")
+)
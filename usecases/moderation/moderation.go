@@ -0,0 +1,105 @@
+package moderation
+
+import (
+	"codebase-service/helper"
+	model "codebase-service/models"
+	"codebase-service/repository/products"
+	"context"
+	"log/slog"
+	"net/http"
+	"strings"
+)
+
+const roleAdmin = "admin"
+
+var _ ModerationSvc = &svc{}
+
+type svc struct {
+	store           products.ProductRepository
+	notificationUrl string
+	client          *http.Client
+	logger          *slog.Logger
+}
+
+// NewModerationSvc notifies sellers of review decisions at notificationUrl,
+// notifications are skipped when it is empty.
+func NewModerationSvc(store products.ProductRepository, notificationUrl string, logger *slog.Logger) *svc {
+	return &svc{
+		store:           store,
+		notificationUrl: notificationUrl,
+		client:          helper.DefaultNetClient,
+		logger:          logger.With("component", "svc.moderation"),
+	}
+}
+
+type ModerationSvc interface {
+	GetModerationQueue(ctx context.Context, req *model.GetModerationQueueReq) (*model.GetModerationQueueResp, error)
+	DecideReview(ctx context.Context, req *model.ReviewDecisionReq) (*model.ProductReview, error)
+}
+
+func (s *svc) GetModerationQueue(ctx context.Context, req *model.GetModerationQueueReq) (*model.GetModerationQueueResp, error) {
+	if !strings.EqualFold(req.Role, roleAdmin) {
+		return nil, ErrNotAdmin
+	}
+
+	res, err := s.store.GetModerationQueue(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *svc) DecideReview(ctx context.Context, req *model.ReviewDecisionReq) (*model.ProductReview, error) {
+	if !strings.EqualFold(req.Role, roleAdmin) {
+		return nil, ErrNotAdmin
+	}
+
+	// sellers are told why, a rejection without a reason is not actionable
+	req.Reason = strings.TrimSpace(req.Reason)
+	if req.Status == model.ReviewStatusRejected && req.Reason == "" {
+		return nil, ErrReasonRequired
+	}
+
+	res, err := s.store.DecideReview(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	s.notifySeller(res)
+
+	return res, nil
+}
+
+// notifySeller sends the decision in the background, a failed notification
+// does not undo the decision.
+func (s *svc) notifySeller(review *model.ProductReview) {
+	if s.notificationUrl == "" {
+		return
+	}
+
+	var (
+		channel = make(chan helper.Response, 1)
+		load    = &model.ModerationNotification{
+			UserId:      review.SellerId,
+			ProductId:   review.ProductId,
+			ProductName: review.ProductName,
+			Decision:    review.Status,
+			Reason:      review.Reason,
+		}
+	)
+
+	helper.NewNetClientRequest(s.notificationUrl, s.client).Post(load, channel)
+
+	go func() {
+		res := <-channel
+		if res.Err != nil {
+			s.logger.Error("failed to notify seller", "method", "notifySeller", "review_id", review.Id, "err", res.Err)
+			return
+		}
+
+		if res.StatusCode >= http.StatusMultipleChoices {
+			s.logger.Warn("notification service rejected the notification", "method", "notifySeller", "review_id", review.Id, "status", res.StatusCode)
+		}
+	}()
+}
@@ -0,0 +1,96 @@
+package moderation
+
+import (
+	mock_products "codebase-service/mock/repository/products"
+	model "codebase-service/models"
+	"codebase-service/repository/products"
+	"codebase-service/util/logging"
+	"context"
+	"encoding/json"
+	"github.com/stretchr/testify/mock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestModerationService(t *testing.T) {
+	suite.Run(t, new(ModerationServiceTestSuite))
+}
+
+type ModerationServiceTestSuite struct {
+	suite.Suite
+	productRepo *mock_products.MockProductRepo
+	service     *svc
+}
+
+func (s *ModerationServiceTestSuite) SetupTest() {
+	s.productRepo = mock_products.NewMockProductRepo()
+	s.service = NewModerationSvc(s.productRepo, "", logging.Discard())
+}
+
+func (s *ModerationServiceTestSuite) TestGetModerationQueue_NotAdmin() {
+	req := &model.GetModerationQueueReq{Role: "seller"}
+
+	resp, err := s.service.GetModerationQueue(context.Background(), req)
+
+	s.EqualError(err, "user is not admin")
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ModerationServiceTestSuite) TestDecideReview_RejectWithoutReason() {
+	req := &model.ReviewDecisionReq{Role: "Admin", Status: model.ReviewStatusRejected, Reason: "  "}
+
+	resp, err := s.service.DecideReview(context.Background(), req)
+
+	s.EqualError(err, "reason is required to reject a product")
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ModerationServiceTestSuite) TestDecideReview_AlreadyDecided() {
+	req := &model.ReviewDecisionReq{Role: "admin", Status: model.ReviewStatusApproved}
+
+	s.productRepo.On("DecideReview", mock.Anything, req).Return(nil, products.ErrReviewDecided)
+
+	resp, err := s.service.DecideReview(context.Background(), req)
+
+	s.EqualError(err, "review already decided")
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ModerationServiceTestSuite) TestDecideReview_NotifiesSeller() {
+	received := make(chan *model.ModerationNotification, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var n model.ModerationNotification
+		_ = json.NewDecoder(r.Body).Decode(&n)
+		received <- &n
+	}))
+	defer server.Close()
+
+	s.service.notificationUrl = server.URL
+	reason := "blurry photos"
+	req := &model.ReviewDecisionReq{Role: "admin", Status: model.ReviewStatusRejected, Reason: reason}
+	res := &model.ProductReview{Id: "review-1", ProductId: "product-1", ProductName: "Lamp", SellerId: "seller-1", Status: model.ReviewStatusRejected, Reason: &reason}
+
+	s.productRepo.On("DecideReview", mock.Anything, req).Return(res, nil)
+
+	resp, err := s.service.DecideReview(context.Background(), req)
+
+	s.NoError(err)
+	s.Equal(res, resp)
+
+	select {
+	case n := <-received:
+		s.Equal("seller-1", n.UserId)
+		s.Equal(model.ReviewStatusRejected, n.Decision)
+		s.Equal(reason, *n.Reason)
+	case <-time.After(2 * time.Second):
+		s.Fail("seller was not notified")
+	}
+	s.productRepo.AssertExpectations(s.T())
+}
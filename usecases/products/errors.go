@@ -0,0 +1,19 @@
+package products
+
+import "codebase-service/util/apperror"
+
+// Errors the service reports to its callers.
+var (
+	ErrNotAdmin                  = apperror.New(apperror.Forbidden, "not_admin", "user is not admin")
+	ErrFlashSaleQuantityRequired = apperror.New(apperror.Validation, "flash_sale_quantity_required", "flash sale quantity is required")
+	ErrReceiptQuantity           = apperror.New(apperror.Validation, "receipt_quantity", "receipt quantity must be positive")
+	ErrAttributeNamesNotUnique   = apperror.New(apperror.Validation, "attribute_names_not_unique", "attribute names must be unique")
+	ErrEnumValuesRequired        = apperror.New(apperror.Validation, "enum_values_required", "enum attributes need values")
+	ErrPublishAtRequired         = apperror.New(apperror.Validation, "publish_at_required", "publish_at is required for scheduled products")
+	ErrUnsupportedImportFormat   = apperror.New(apperror.Validation, "unsupported_import_format", "unsupported import format")
+	ErrUnsupportedExportFormat   = apperror.New(apperror.Validation, "unsupported_export_format", "unsupported export format")
+	ErrNoOptions                 = apperror.New(apperror.Unprocessable, "no_options", "product has no options")
+	ErrVariantOptionsMismatch    = apperror.New(apperror.Unprocessable, "variant_options_mismatch", "variant options do not match the product options")
+	ErrPublishAtInPast           = apperror.New(apperror.Unprocessable, "publish_at_in_past", "publish_at must be in the future")
+	ErrEmptyImport               = apperror.New(apperror.Unprocessable, "empty_import", "import file has no rows")
+)
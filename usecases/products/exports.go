@@ -0,0 +1,237 @@
+package products
+
+import (
+	"bufio"
+	model "codebase-service/models"
+	"codebase-service/util/xlsx"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// exportColumns keeps the import column names so an exported file can be
+// edited and imported back.
+var exportColumns = []string{
+	"id", "sku", "name", "description", "category_id", "category_name", "status", "price",
+	"sale_price", "stock", "image_url", "attributes", "publish_at", "created_at", "updated_at",
+}
+
+// productExporter encodes exported products in one file format.
+type productExporter interface {
+	Write(row *model.ExportProductRow) error
+	Close() error
+}
+
+// ExportProducts writes the products of a shop to w as they are read, in
+// the requested format. Sellers export their own shops, admins any shop.
+func (s *svc) ExportProducts(ctx context.Context, req *model.ExportProductsReq, w io.Writer) error {
+	if !strings.EqualFold(req.Role, roleAdmin) {
+		if err := s.store.IsShopOwner(ctx, req.UserId, req.ShopId); err != nil {
+			return err
+		}
+	}
+
+	exporter, err := newProductExporter(req.Format, w)
+	if err != nil {
+		return err
+	}
+
+	if err := s.store.ExportProducts(ctx, req, exporter.Write); err != nil {
+		return err
+	}
+
+	return exporter.Close()
+}
+
+func newProductExporter(format string, w io.Writer) (productExporter, error) {
+	switch format {
+	case model.ExportFormatCSV:
+		return newCSVExporter(w)
+	case model.ExportFormatNDJSON:
+		return newNDJSONExporter(w), nil
+	case model.ExportFormatXLSX:
+		return newXLSXExporter(w)
+	default:
+		return nil, ErrUnsupportedExportFormat
+	}
+}
+
+type csvExporter struct {
+	w *csv.Writer
+}
+
+func newCSVExporter(w io.Writer) (*csvExporter, error) {
+	e := &csvExporter{w: csv.NewWriter(w)}
+	if err := e.w.Write(exportColumns); err != nil {
+		return nil, err
+	}
+
+	return e, nil
+}
+
+func (e *csvExporter) Write(row *model.ExportProductRow) error {
+	attributes, err := exportAttributes(row)
+	if err != nil {
+		return err
+	}
+
+	return e.w.Write([]string{
+		row.Id,
+		csvText(deref(row.Sku)),
+		csvText(row.Name),
+		csvText(row.Description),
+		row.CategoryId,
+		csvText(row.CategoryName),
+		row.Status,
+		strconv.FormatFloat(row.Price, 'f', -1, 64),
+		formatOptionalFloat(row.SalePrice),
+		strconv.FormatInt(row.Stock, 10),
+		csvText(row.ImageUrl),
+		attributes,
+		formatOptionalTime(row.PublishAt),
+		row.CreatedAt.Format(time.RFC3339),
+		row.UpdatedAt.Format(time.RFC3339),
+	})
+}
+
+// csvFormulaPrefixes start a formula when a spreadsheet opens the file.
+const csvFormulaPrefixes = "=+-@\t\r"
+
+// csvText keeps seller text from being run as a formula by spreadsheets, the
+// quote is dropped again when the file is imported.
+func csvText(value string) string {
+	if value != "" && strings.ContainsRune(csvFormulaPrefixes, rune(value[0])) {
+		return "'" + value
+	}
+	return value
+}
+
+// csvImportText undoes csvText.
+func csvImportText(value string) string {
+	if len(value) > 1 && value[0] == '\'' && strings.ContainsRune(csvFormulaPrefixes, rune(value[1])) {
+		return value[1:]
+	}
+	return value
+}
+
+func (e *csvExporter) Close() error {
+	e.w.Flush()
+	return e.w.Error()
+}
+
+type ndjsonExporter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func newNDJSONExporter(w io.Writer) *ndjsonExporter {
+	bw := bufio.NewWriter(w)
+	return &ndjsonExporter{w: bw, enc: json.NewEncoder(bw)}
+}
+
+func (e *ndjsonExporter) Write(row *model.ExportProductRow) error {
+	return e.enc.Encode(row)
+}
+
+func (e *ndjsonExporter) Close() error {
+	return e.w.Flush()
+}
+
+type xlsxExporter struct {
+	w *xlsx.Writer
+}
+
+func newXLSXExporter(w io.Writer) (*xlsxExporter, error) {
+	xw, err := xlsx.NewWriter(w, "Products")
+	if err != nil {
+		return nil, err
+	}
+
+	header := make([]interface{}, len(exportColumns))
+	for i, column := range exportColumns {
+		header[i] = column
+	}
+
+	if err := xw.WriteRow(header...); err != nil {
+		return nil, err
+	}
+
+	return &xlsxExporter{w: xw}, nil
+}
+
+// Write keeps prices and stock as numbers so they can be summed in the
+// spreadsheet.
+func (e *xlsxExporter) Write(row *model.ExportProductRow) error {
+	attributes, err := exportAttributes(row)
+	if err != nil {
+		return err
+	}
+
+	var salePrice interface{}
+	if row.SalePrice != nil {
+		salePrice = *row.SalePrice
+	}
+
+	return e.w.WriteRow(
+		row.Id,
+		deref(row.Sku),
+		row.Name,
+		row.Description,
+		row.CategoryId,
+		row.CategoryName,
+		row.Status,
+		row.Price,
+		salePrice,
+		row.Stock,
+		row.ImageUrl,
+		attributes,
+		formatOptionalTime(row.PublishAt),
+		row.CreatedAt,
+		row.UpdatedAt,
+	)
+}
+
+func (e *xlsxExporter) Close() error {
+	return e.w.Close()
+}
+
+func exportAttributes(row *model.ExportProductRow) (string, error) {
+	if len(row.Attributes) == 0 {
+		return "{}", nil
+	}
+
+	data, err := json.Marshal(row.Attributes)
+	if err != nil {
+		return "", err
+	}
+
+	return string(data), nil
+}
+
+func deref(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
+
+func formatOptionalFloat(f *float64) string {
+	if f == nil {
+		return ""
+	}
+
+	return strconv.FormatFloat(*f, 'f', -1, 64)
+}
+
+func formatOptionalTime(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+
+	return t.Format(time.RFC3339)
+}
@@ -0,0 +1,162 @@
+package products
+
+import (
+	"archive/zip"
+	"bytes"
+	model "codebase-service/models"
+	repo "codebase-service/repository/products"
+	"context"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+)
+
+func (s *ProductServiceTestSuite) exportRows() []interface{} {
+	sku := "A-1"
+	salePrice := 8000.0
+	createdAt := time.Date(2024, 11, 20, 10, 0, 0, 0, time.UTC)
+
+	return []interface{}{
+		&model.ExportProductRow{
+			Id: "p1", Sku: &sku, Name: "Kaos, putih", CategoryId: "c1", CategoryName: "Fashion", Status: model.ProductStatusPublished,
+			Price: 10000, SalePrice: &salePrice, Stock: 5, Attributes: map[string]interface{}{"size": "M"},
+			CreatedAt: createdAt, UpdatedAt: createdAt,
+		},
+		&model.ExportProductRow{
+			Id: "p2", Name: "Topi", Description: `=HYPERLINK("http://example.com")`, CategoryId: "c1", CategoryName: "Fashion", Status: model.ProductStatusDraft,
+			Price: 5000, CreatedAt: createdAt, UpdatedAt: createdAt,
+		},
+	}
+}
+
+func (s *ProductServiceTestSuite) TestExportProducts_CSV() {
+	req := &model.ExportProductsReq{UserId: "user", ShopId: "shop", Format: model.ExportFormatCSV}
+	var buf bytes.Buffer
+
+	s.productRepo.On("IsShopOwner", mock.Anything, req.UserId, req.ShopId).Return(nil)
+	s.productRepo.On("ExportProducts", mock.Anything, req, mock.Anything).Return(append([]interface{}{nil}, s.exportRows()...)...)
+
+	err := s.service.ExportProducts(context.Background(), req, &buf)
+
+	s.NoError(err)
+	records, err := csv.NewReader(&buf).ReadAll()
+	s.NoError(err)
+	s.Len(records, 3)
+	s.Equal(exportColumns, records[0])
+	s.Equal([]string{
+		"p1", "A-1", "Kaos, putih", "", "c1", "Fashion", "published", "10000", "8000", "5", "", `{"size":"M"}`,
+		"", "2024-11-20T10:00:00Z", "2024-11-20T10:00:00Z",
+	}, records[1])
+	s.Equal("{}", records[2][11])
+	s.Equal(`'=HYPERLINK("http://example.com")`, records[2][3])
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestExportProducts_CSVImportsBack() {
+	req := &model.ExportProductsReq{UserId: "user", ShopId: "shop", Format: model.ExportFormatCSV}
+	var buf bytes.Buffer
+
+	s.productRepo.On("IsShopOwner", mock.Anything, req.UserId, req.ShopId).Return(nil)
+	s.productRepo.On("ExportProducts", mock.Anything, req, mock.Anything).Return(append([]interface{}{nil}, s.exportRows()...)...)
+
+	s.NoError(s.service.ExportProducts(context.Background(), req, &buf))
+
+	rows, rowErrors, err := parseCSVImport(buf.Bytes())
+
+	s.NoError(err)
+	s.Empty(rowErrors)
+	s.Len(rows, 2)
+	s.Equal("A-1", rows[0].Sku)
+	s.Equal(int64(5), rows[0].Stock)
+	s.Equal("M", rows[0].Attributes["size"])
+	s.Equal(`=HYPERLINK("http://example.com")`, rows[1].Description)
+}
+
+func (s *ProductServiceTestSuite) TestExportProducts_NDJSON() {
+	req := &model.ExportProductsReq{UserId: "user", ShopId: "shop", Format: model.ExportFormatNDJSON}
+	var buf bytes.Buffer
+
+	s.productRepo.On("IsShopOwner", mock.Anything, req.UserId, req.ShopId).Return(nil)
+	s.productRepo.On("ExportProducts", mock.Anything, req, mock.Anything).Return(append([]interface{}{nil}, s.exportRows()...)...)
+
+	err := s.service.ExportProducts(context.Background(), req, &buf)
+
+	s.NoError(err)
+	dec := json.NewDecoder(&buf)
+	var rows []*model.ExportProductRow
+	for dec.More() {
+		row := new(model.ExportProductRow)
+		s.NoError(dec.Decode(row))
+		rows = append(rows, row)
+	}
+	s.Len(rows, 2)
+	s.Equal("p2", rows[1].Id)
+}
+
+func (s *ProductServiceTestSuite) TestExportProducts_XLSX() {
+	req := &model.ExportProductsReq{UserId: "user", ShopId: "shop", Format: model.ExportFormatXLSX}
+	var buf bytes.Buffer
+
+	s.productRepo.On("IsShopOwner", mock.Anything, req.UserId, req.ShopId).Return(nil)
+	s.productRepo.On("ExportProducts", mock.Anything, req, mock.Anything).Return(append([]interface{}{nil}, s.exportRows()...)...)
+
+	err := s.service.ExportProducts(context.Background(), req, &buf)
+
+	s.NoError(err)
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	s.NoError(err)
+
+	var sheet []byte
+	for _, f := range zr.File {
+		if f.Name == "xl/worksheets/sheet1.xml" {
+			rc, err := f.Open()
+			s.NoError(err)
+			sheet, _ = io.ReadAll(rc)
+			rc.Close()
+		}
+	}
+	s.Contains(string(sheet), `<t xml:space="preserve">Kaos, putih</t>`)
+	s.Contains(string(sheet), `<c><v>10000</v></c>`)
+	s.Equal(3, bytes.Count(sheet, []byte("<row>")))
+}
+
+func (s *ProductServiceTestSuite) TestExportProducts_Failed() {
+	req := &model.ExportProductsReq{UserId: "user", ShopId: "shop", Format: model.ExportFormatCSV}
+
+	s.productRepo.On("IsShopOwner", mock.Anything, req.UserId, req.ShopId).Return(nil)
+	s.productRepo.On("ExportProducts", mock.Anything, req, mock.Anything).Return(sql.ErrConnDone)
+
+	err := s.service.ExportProducts(context.Background(), req, io.Discard)
+
+	s.ErrorIs(err, sql.ErrConnDone)
+}
+
+func (s *ProductServiceTestSuite) TestExportProducts_NotShopOwner() {
+	req := &model.ExportProductsReq{UserId: "user", ShopId: "shop", Format: model.ExportFormatCSV}
+	var buf bytes.Buffer
+
+	s.productRepo.On("IsShopOwner", mock.Anything, req.UserId, req.ShopId).Return(repo.ErrNotShopOwner)
+
+	err := s.service.ExportProducts(context.Background(), req, &buf)
+
+	s.ErrorIs(err, repo.ErrNotShopOwner)
+	s.Zero(buf.Len())
+	s.productRepo.AssertNotCalled(s.T(), "ExportProducts", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func (s *ProductServiceTestSuite) TestExportProducts_AdminExportsAnyShop() {
+	req := &model.ExportProductsReq{UserId: "admin", Role: "Admin", ShopId: "shop", Format: model.ExportFormatNDJSON}
+	var buf bytes.Buffer
+
+	s.productRepo.On("ExportProducts", mock.Anything, req, mock.Anything).Return(append([]interface{}{nil}, s.exportRows()...)...)
+
+	err := s.service.ExportProducts(context.Background(), req, &buf)
+
+	s.NoError(err)
+	s.Equal(2, bytes.Count(buf.Bytes(), []byte("\n")))
+	s.productRepo.AssertNotCalled(s.T(), "IsShopOwner", mock.Anything, mock.Anything, mock.Anything)
+}
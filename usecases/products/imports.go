@@ -0,0 +1,382 @@
+package products
+
+import (
+	"bufio"
+	"bytes"
+	model "codebase-service/models"
+	"codebase-service/repository/products"
+	"codebase-service/util/apperror"
+	"codebase-service/util/i18n"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/validator"
+)
+
+const (
+	importBatchSize = 100
+
+	// importWorkers is how many imports may run at the same time
+	importWorkers = 2
+
+	// maxImportErrors caps the row errors kept on an import, the failed
+	// count still covers every row
+	maxImportErrors = 1000
+
+	maxImportLineSize = 1 << 20
+)
+
+// importColumns are the CSV columns an import file may have, the first four
+// are required.
+var importColumns = []string{"sku", "name", "category_id", "price", "description", "stock", "image_url", "status", "attributes"}
+
+// CreateImport parses the file and starts importing it in the background.
+// The returned job is pending, its progress is read with GetImport.
+func (s *svc) CreateImport(ctx context.Context, req *model.CreateImportReq) (*model.ProductImport, error) {
+	err := s.store.IsShopOwner(ctx, req.UserId, req.ShopId)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		rows      []*model.ImportProductRow
+		rowErrors []*model.ImportRowError
+	)
+
+	switch req.Format {
+	case model.ImportFormatCSV:
+		rows, rowErrors, err = parseCSVImport(req.Data)
+	case model.ImportFormatNDJSON:
+		rows, rowErrors, err = parseNDJSONImport(req.Data)
+	default:
+		err = ErrUnsupportedImportFormat
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if len(rows)+len(rowErrors) == 0 {
+		return nil, ErrEmptyImport
+	}
+
+	job, err := s.store.CreateImport(ctx, &model.ProductImport{
+		ShopId:    req.ShopId,
+		UserId:    req.UserId,
+		Format:    req.Format,
+		TotalRows: len(rows) + len(rowErrors),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// the background run works on its own copy so the response is not
+	// raced by progress updates. It outlives the request, so it is not
+	// cancelled with it.
+	run := *job
+	go s.runImport(context.WithoutCancel(ctx), &run, rows, rowErrors)
+
+	return job, nil
+}
+
+func (s *svc) GetImport(ctx context.Context, req *model.GetImportReq) (*model.ProductImport, error) {
+	res, err := s.store.GetImport(ctx, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.store.IsShopOwner(ctx, req.UserId, res.ShopId)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// runImport validates and upserts the rows batch by batch, saving progress
+// after each batch. Only importWorkers imports run at once, the others wait.
+func (s *svc) runImport(ctx context.Context, job *model.ProductImport, rows []*model.ImportProductRow, rowErrors []*model.ImportRowError) {
+	s.imports <- struct{}{}
+	defer func() { <-s.imports }()
+
+	started := s.now()
+	job.Status = model.ImportStatusRunning
+	job.StartedAt = &started
+	job.Errors = make([]*model.ImportRowError, 0)
+
+	for _, rowErr := range rowErrors {
+		addImportError(job, rowErr)
+	}
+	job.ProcessedRows = len(rowErrors)
+
+	if err := s.store.UpdateImport(ctx, job); err != nil {
+		s.logger.ErrorContext(ctx, "failed to start import", "method", "runImport", "import_id", job.Id, "err", err)
+	}
+
+	schemas := make(map[string][]*model.AttributeDefinition)
+	for start := 0; start < len(rows); start += importBatchSize {
+		end := min(start+importBatchSize, len(rows))
+
+		if err := s.importBatch(ctx, job, rows[start:end], schemas); err != nil {
+			s.logger.ErrorContext(ctx, "import failed", "method", "runImport", "import_id", job.Id, "err", err)
+			s.finishImport(ctx, job, err)
+			return
+		}
+
+		job.ProcessedRows += end - start
+		if err := s.store.UpdateImport(ctx, job); err != nil {
+			s.logger.ErrorContext(ctx, "failed to save import progress", "method", "runImport", "import_id", job.Id, "err", err)
+		}
+	}
+
+	s.finishImport(ctx, job, nil)
+}
+
+func (s *svc) importBatch(ctx context.Context, job *model.ProductImport, batch []*model.ImportProductRow, schemas map[string][]*model.AttributeDefinition) error {
+	valid := make([]*model.ImportProductRow, 0, len(batch))
+	for _, row := range batch {
+		message, err := s.validateImportRow(ctx, row, schemas)
+		if err != nil {
+			return err
+		}
+
+		if message != "" {
+			addImportError(job, &model.ImportRowError{Row: row.Row, Sku: row.Sku, Message: message})
+			continue
+		}
+
+		row.FlaggedWords = bannedWords(s.moderation.BannedWords, row.Name, row.Description)
+		row.NeedsReview = s.moderation.Enabled || len(row.FlaggedWords) > 0
+		valid = append(valid, row)
+	}
+
+	if len(valid) == 0 {
+		return nil
+	}
+
+	results, err := s.store.UpsertProducts(ctx, &model.UpsertProductsReq{
+		ShopId: job.ShopId,
+		UserId: job.UserId,
+		Rows:   valid,
+	})
+	if err != nil {
+		return err
+	}
+
+	for _, result := range results {
+		switch {
+		case result.Error != "":
+			addImportError(job, &model.ImportRowError{Row: result.Row, Sku: result.Sku, Message: result.Error})
+		case result.Created:
+			job.CreatedCount++
+		default:
+			job.UpdatedCount++
+		}
+	}
+
+	return nil
+}
+
+// validateImportRow returns why a row cannot be imported, or an empty
+// message when it can. Category schemas are looked up once per import.
+func (s *svc) validateImportRow(ctx context.Context, row *model.ImportProductRow, schemas map[string][]*model.AttributeDefinition) (string, error) {
+	if err := s.validator.Struct(row); err != nil {
+		var fieldErrs validator.ValidationErrors
+		if !errors.As(err, &fieldErrs) {
+			return "", err
+		}
+		return importRowMessage(fieldErrs), nil
+	}
+
+	schema, ok := schemas[row.CategoryId]
+	if !ok {
+		var err error
+		schema, err = s.store.GetCategoryAttributes(ctx, row.CategoryId)
+		if err != nil {
+			if errors.Is(err, products.ErrCategoryNotFound) {
+				return err.Error(), nil
+			}
+			return "", err
+		}
+		schemas[row.CategoryId] = schema
+	}
+
+	if err := validateAttributes(schema, row.Attributes); err != nil {
+		return err.Error(), nil
+	}
+
+	return "", nil
+}
+
+func (s *svc) finishImport(ctx context.Context, job *model.ProductImport, err error) {
+	finished := s.now()
+	job.FinishedAt = &finished
+	job.Status = model.ImportStatusCompleted
+
+	if err != nil {
+		message := err.Error()
+		job.Status = model.ImportStatusFailed
+		job.Error = &message
+	}
+
+	if err := s.store.UpdateImport(ctx, job); err != nil {
+		s.logger.ErrorContext(ctx, "failed to finish import", "method", "finishImport", "import_id", job.Id, "err", err)
+	}
+}
+
+func addImportError(job *model.ProductImport, rowErr *model.ImportRowError) {
+	job.FailedCount++
+	if len(job.Errors) < maxImportErrors {
+		job.Errors = append(job.Errors, rowErr)
+	}
+}
+
+// parseCSVImport reads a CSV file with a header row. Attributes are given as
+// a JSON object in the attributes column. Rows are numbered from 1 after the
+// header.
+func parseCSVImport(data []byte) ([]*model.ImportProductRow, []*model.ImportRowError, error) {
+	var (
+		rows      = make([]*model.ImportProductRow, 0)
+		rowErrors = make([]*model.ImportRowError, 0)
+		reader    = csv.NewReader(bytes.NewReader(data))
+	)
+
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		if err == io.EOF {
+			return rows, rowErrors, nil
+		}
+		return nil, nil, apperror.Newf(apperror.Unprocessable, "import_file_invalid", "invalid import file: %v", err)
+	}
+
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		columns[name] = i
+	}
+
+	for _, name := range importColumns[:4] {
+		if _, ok := columns[name]; !ok {
+			return nil, nil, apperror.Newf(apperror.Unprocessable, "import_column_missing", "invalid import file: missing column %s", name)
+		}
+	}
+
+	for n := 1; ; n++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+
+		if err != nil {
+			var parseErr *csv.ParseError
+			if !errors.As(err, &parseErr) {
+				return nil, nil, apperror.Newf(apperror.Unprocessable, "import_file_invalid", "invalid import file: %v", err)
+			}
+			rowErrors = append(rowErrors, &model.ImportRowError{Row: n, Message: parseErr.Err.Error()})
+			continue
+		}
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return csvImportText(strings.TrimSpace(record[i]))
+		}
+
+		row, err := csvImportRow(field)
+		if err != nil {
+			rowErrors = append(rowErrors, &model.ImportRowError{Row: n, Sku: field("sku"), Message: err.Error()})
+			continue
+		}
+
+		row.Row = n
+		rows = append(rows, row)
+	}
+
+	return rows, rowErrors, nil
+}
+
+func csvImportRow(field func(name string) string) (*model.ImportProductRow, error) {
+	row := &model.ImportProductRow{
+		Sku:         field("sku"),
+		Name:        field("name"),
+		Description: field("description"),
+		CategoryId:  field("category_id"),
+		ImageUrl:    field("image_url"),
+		Status:      field("status"),
+	}
+
+	price, err := strconv.ParseFloat(field("price"), 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid price")
+	}
+	row.Price = price
+
+	if value := field("stock"); value != "" {
+		row.Stock, err = strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid stock")
+		}
+	}
+
+	if value := field("attributes"); value != "" {
+		if err := json.Unmarshal([]byte(value), &row.Attributes); err != nil {
+			return nil, fmt.Errorf("invalid attributes")
+		}
+	}
+
+	return row, nil
+}
+
+// parseNDJSONImport reads one JSON product per line, blank lines are
+// skipped. Rows are numbered by line.
+func parseNDJSONImport(data []byte) ([]*model.ImportProductRow, []*model.ImportRowError, error) {
+	var (
+		rows      = make([]*model.ImportProductRow, 0)
+		rowErrors = make([]*model.ImportRowError, 0)
+		scanner   = bufio.NewScanner(bytes.NewReader(data))
+	)
+
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportLineSize)
+
+	for n := 1; scanner.Scan(); n++ {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+
+		row := new(model.ImportProductRow)
+		if err := json.Unmarshal(line, row); err != nil {
+			rowErrors = append(rowErrors, &model.ImportRowError{Row: n, Message: "invalid json"})
+			continue
+		}
+
+		row.Row = n
+		rows = append(rows, row)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, nil, apperror.Newf(apperror.Unprocessable, "import_file_invalid", "invalid import file: %v", err)
+	}
+
+	return rows, rowErrors, nil
+}
+
+// importRowMessage lists the failed rules of a row. Row errors are kept with
+// the job, so they are written in English like the other row messages.
+func importRowMessage(errs validator.ValidationErrors) string {
+	messages := make([]string, 0, len(errs))
+	for _, fe := range errs {
+		messages = append(messages, i18n.ValidationMessage(i18n.EN, fe))
+	}
+	return strings.Join(messages, "; ")
+}
@@ -0,0 +1,165 @@
+package products
+
+import (
+	model "codebase-service/models"
+	repo "codebase-service/repository/products"
+	"context"
+	"database/sql"
+
+	"github.com/stretchr/testify/mock"
+)
+
+const importCategoryId = "2f0c8a4e-6b1d-4c55-9a7e-3d2b1f0e9c11"
+
+func (s *ProductServiceTestSuite) TestParseCSVImport() {
+	data := "sku,name,category_id,price,stock,attributes\n" +
+		"A-1,Kaos,cat,10000,5,\"{\"\"size\"\":\"\"M\"\"}\"\n" +
+		"A-2,Topi,cat,abc,1,\n"
+
+	rows, rowErrors, err := parseCSVImport([]byte(data))
+
+	s.NoError(err)
+	s.Len(rows, 1)
+	s.Equal(1, rows[0].Row)
+	s.Equal("A-1", rows[0].Sku)
+	s.Equal(10000.0, rows[0].Price)
+	s.Equal(int64(5), rows[0].Stock)
+	s.Equal("M", rows[0].Attributes["size"])
+	s.Equal([]*model.ImportRowError{{Row: 2, Sku: "A-2", Message: "invalid price"}}, rowErrors)
+}
+
+func (s *ProductServiceTestSuite) TestParseCSVImport_MissingColumn() {
+	_, _, err := parseCSVImport([]byte("sku,name,category_id\nA-1,Kaos,cat\n"))
+
+	s.EqualError(err, "invalid import file: missing column price")
+}
+
+func (s *ProductServiceTestSuite) TestParseNDJSONImport() {
+	data := `{"sku":"A-1","name":"Kaos","category_id":"cat","price":10000}` + "\n\n" + `{"sku":` + "\n"
+
+	rows, rowErrors, err := parseNDJSONImport([]byte(data))
+
+	s.NoError(err)
+	s.Len(rows, 1)
+	s.Equal(1, rows[0].Row)
+	s.Equal([]*model.ImportRowError{{Row: 3, Message: "invalid json"}}, rowErrors)
+}
+
+func (s *ProductServiceTestSuite) TestRunImport_Completed() {
+	job := &model.ProductImport{Id: "job", ShopId: "shop", UserId: "user", TotalRows: 4}
+	rows := []*model.ImportProductRow{
+		{Row: 1, Sku: "A-1", Name: "Kaos", CategoryId: importCategoryId, Price: 10000},
+		{Row: 2, Sku: "A-2", Name: "Kaos replica", CategoryId: importCategoryId, Price: 10000},
+		{Row: 3, Sku: "A-3", Name: "Topi", CategoryId: importCategoryId},
+	}
+	parseErrors := []*model.ImportRowError{{Row: 4, Message: "invalid json"}}
+
+	s.productRepo.On("UpdateImport", mock.Anything, mock.Anything).Return(nil)
+	s.productRepo.On("GetCategoryAttributes", mock.Anything, importCategoryId).Return([]*model.AttributeDefinition{}, nil).Once()
+	s.productRepo.On("UpsertProducts", mock.Anything, mock.MatchedBy(func(req *model.UpsertProductsReq) bool {
+		return req.ShopId == "shop" && len(req.Rows) == 2 && !req.Rows[0].NeedsReview && req.Rows[1].NeedsReview
+	})).Return([]*model.ImportRowResult{
+		{Row: 1, Sku: "A-1", ProductId: "p1", Created: true},
+		{Row: 2, Sku: "A-2", ProductId: "p2"},
+	}, nil)
+
+	s.service.runImport(context.Background(), job, rows, parseErrors)
+
+	s.Equal(model.ImportStatusCompleted, job.Status)
+	s.Equal(4, job.ProcessedRows)
+	s.Equal(1, job.CreatedCount)
+	s.Equal(1, job.UpdatedCount)
+	s.Equal(2, job.FailedCount)
+	s.Len(job.Errors, 2)
+	s.Equal(3, job.Errors[1].Row)
+	s.Equal([]string{"replica"}, rows[1].FlaggedWords)
+	s.NotNil(job.FinishedAt)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestRunImport_Failed() {
+	job := &model.ProductImport{Id: "job", ShopId: "shop", UserId: "user", TotalRows: 1}
+	rows := []*model.ImportProductRow{
+		{Row: 1, Sku: "A-1", Name: "Kaos", CategoryId: importCategoryId, Price: 10000},
+	}
+
+	s.productRepo.On("UpdateImport", mock.Anything, mock.Anything).Return(nil)
+	s.productRepo.On("GetCategoryAttributes", mock.Anything, importCategoryId).Return([]*model.AttributeDefinition{}, nil)
+	s.productRepo.On("UpsertProducts", mock.Anything, mock.Anything).Return(nil, sql.ErrConnDone)
+
+	s.service.runImport(context.Background(), job, rows, nil)
+
+	s.Equal(model.ImportStatusFailed, job.Status)
+	s.Equal(0, job.ProcessedRows)
+	s.Equal(sql.ErrConnDone.Error(), *job.Error)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestRunImport_UnknownCategory() {
+	job := &model.ProductImport{Id: "job", ShopId: "shop", UserId: "user", TotalRows: 1}
+	rows := []*model.ImportProductRow{
+		{Row: 1, Sku: "A-1", Name: "Kaos", CategoryId: importCategoryId, Price: 10000},
+	}
+
+	s.productRepo.On("UpdateImport", mock.Anything, mock.Anything).Return(nil)
+	s.productRepo.On("GetCategoryAttributes", mock.Anything, importCategoryId).Return(nil, repo.ErrCategoryNotFound)
+
+	s.service.runImport(context.Background(), job, rows, nil)
+
+	s.Equal(model.ImportStatusCompleted, job.Status)
+	s.Equal(1, job.FailedCount)
+	s.Equal("no category found", job.Errors[0].Message)
+	s.productRepo.AssertNotCalled(s.T(), "UpsertProducts", mock.Anything, mock.Anything)
+}
+
+func (s *ProductServiceTestSuite) TestCreateImport_NotShopOwner() {
+	req := &model.CreateImportReq{UserId: "user", ShopId: "shop", Format: model.ImportFormatCSV, Data: []byte("sku")}
+
+	s.productRepo.On("IsShopOwner", mock.Anything, req.UserId, req.ShopId).Return(repo.ErrNotShopOwner)
+
+	resp, err := s.service.CreateImport(context.Background(), req)
+
+	s.ErrorIs(err, repo.ErrNotShopOwner)
+	s.Nil(resp)
+	s.productRepo.AssertNotCalled(s.T(), "CreateImport", mock.Anything, mock.Anything)
+}
+
+func (s *ProductServiceTestSuite) TestCreateImport_NoRows() {
+	req := &model.CreateImportReq{UserId: "user", ShopId: "shop", Format: model.ImportFormatCSV, Data: []byte("sku,name,category_id,price\n")}
+
+	s.productRepo.On("IsShopOwner", mock.Anything, req.UserId, req.ShopId).Return(nil)
+
+	resp, err := s.service.CreateImport(context.Background(), req)
+
+	s.EqualError(err, "import file has no rows")
+	s.Nil(resp)
+}
+
+func (s *ProductServiceTestSuite) TestGetImport_NotShopOwner() {
+	req := &model.GetImportReq{UserId: "user", Id: "job"}
+
+	s.productRepo.On("GetImport", mock.Anything, req.Id).Return(&model.ProductImport{Id: "job", ShopId: "shop"}, nil)
+	s.productRepo.On("IsShopOwner", mock.Anything, req.UserId, "shop").Return(repo.ErrNotShopOwner)
+
+	resp, err := s.service.GetImport(context.Background(), req)
+
+	s.ErrorIs(err, repo.ErrNotShopOwner)
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestRunImport_InvalidRow() {
+	job := &model.ProductImport{Id: "job", ShopId: "shop", UserId: "user", TotalRows: 1}
+	rows := []*model.ImportProductRow{
+		{Row: 1, Sku: "A-1", Name: "Kaos", CategoryId: "cat", Price: 0},
+	}
+
+	s.productRepo.On("UpdateImport", mock.Anything, mock.Anything).Return(nil)
+
+	s.service.runImport(context.Background(), job, rows, nil)
+
+	s.Equal(model.ImportStatusCompleted, job.Status)
+	s.Equal(1, job.FailedCount)
+	s.Equal("category_id must be a valid UUID; price must be greater than 0", job.Errors[0].Message)
+	s.productRepo.AssertNotCalled(s.T(), "UpsertProducts", mock.Anything, mock.Anything)
+}
@@ -3,29 +3,144 @@ package products
 import (
 	model "codebase-service/models"
 	"codebase-service/repository/products"
+	"codebase-service/util/apperror"
+	"context"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode"
+
+	"github.com/go-playground/validator"
+)
+
+const (
+	roleAdmin = "admin"
+
+	publishBatchSize = 100
+	purgeBatchSize   = 100
 )
 
 var _ ProductSvc = &svc{}
 
+// Moderation decides which products need an admin review before they go
+// public. Products whose name or description contain a banned word are
+// reviewed even when moderation is not enabled.
+type Moderation struct {
+	Enabled     bool
+	BannedWords []string
+}
+
+// Retention controls soft-deleted products. Sellers may restore a product
+// within RestoreWindow of deleting it, the purge job removes it for good once
+// it has been deleted for PurgeAfter.
+type Retention struct {
+	RestoreWindow time.Duration
+	PurgeAfter    time.Duration
+}
+
 type svc struct {
-	store products.ProductRepository
+	store      products.ProductRepository
+	validator  *validator.Validate
+	moderation Moderation
+	retention  Retention
+	now        func() time.Time
+	logger     *slog.Logger
+
+	// reservationTTL is how long flash-sale claims are held unpaid
+	reservationTTL time.Duration
+
+	// imports limits how many imports run at once
+	imports chan struct{}
 }
 
-func NewProductSvc(store products.ProductRepository) *svc {
+func NewProductSvc(store products.ProductRepository, validator *validator.Validate, moderation Moderation, retention Retention, reservationTTL time.Duration, logger *slog.Logger) *svc {
 	return &svc{
-		store: store,
+		store:          store,
+		validator:      validator,
+		moderation:     moderation,
+		retention:      retention,
+		reservationTTL: reservationTTL,
+		now:            time.Now,
+		imports:        make(chan struct{}, importWorkers),
+		logger:         logger.With("component", "svc.products"),
 	}
 }
 
 type ProductSvc interface {
-	GetProduct(req *model.GetProductReq) (*model.GetProductResp, error)
-	GetProducts(req *model.GetProductsReq) (*model.GetProductsResp, error)
-	CreateProduct(req *model.CreateProductReq) (*model.GetProductResp, error)
-	DeleteProduct(req *model.DeleteProductReq) error
+	GetProduct(ctx context.Context, req *model.GetProductReq) (*model.GetProductResp, error)
+	GetProducts(ctx context.Context, req *model.GetProductsReq) (*model.GetProductsResp, error)
+	CreateProduct(ctx context.Context, req *model.CreateProductReq) (*model.GetProductResp, error)
+	DeleteProduct(ctx context.Context, req *model.DeleteProductReq) error
+	CreatePromotion(ctx context.Context, req *model.CreatePromotionReq) (*model.Promotion, error)
+	ClaimFlashSale(ctx context.Context, req *model.ClaimFlashSaleReq) (*model.ClaimFlashSaleResp, error)
+	CreateWarehouse(ctx context.Context, req *model.CreateWarehouseReq) (*model.Warehouse, error)
+	GetWarehouses(ctx context.Context, req *model.GetWarehousesReq) ([]*model.Warehouse, error)
+	CreateStockAdjustment(ctx context.Context, req *model.CreateStockAdjustmentReq) (*model.StockAdjustmentResp, error)
+	SetProductOptions(ctx context.Context, req *model.SetProductOptionsReq) ([]*model.ProductOption, error)
+	CreateVariant(ctx context.Context, req *model.CreateVariantReq) (*model.ProductVariant, error)
+	AddProductImage(ctx context.Context, req *model.AddProductImageReq) (*model.ProductImage, error)
+	ReorderProductImages(ctx context.Context, req *model.ReorderProductImagesReq) ([]*model.ProductImage, error)
+	DeleteProductImage(ctx context.Context, req *model.DeleteProductImageReq) error
+	UpdateProduct(ctx context.Context, req *model.UpdateProductReq) (*model.GetProductResp, error)
+	GetCategoryAttributes(ctx context.Context, req *model.GetCategoryAttributesReq) ([]*model.AttributeDefinition, error)
+	SetCategoryAttributes(ctx context.Context, req *model.SetCategoryAttributesReq) ([]*model.AttributeDefinition, error)
+	SetProductStatus(ctx context.Context, req *model.SetProductStatusReq) (*model.GetProductResp, error)
+	GetShopProducts(ctx context.Context, req *model.GetShopProductsReq) (*model.GetProductsResp, error)
+	PublishScheduled(ctx context.Context) (int, error)
+	RestoreProduct(ctx context.Context, req *model.RestoreProductReq) (*model.GetProductResp, error)
+	PurgeDeleted(ctx context.Context) (int, error)
+	CreateImport(ctx context.Context, req *model.CreateImportReq) (*model.ProductImport, error)
+	GetImport(ctx context.Context, req *model.GetImportReq) (*model.ProductImport, error)
+	ExportProducts(ctx context.Context, req *model.ExportProductsReq, w io.Writer) error
+}
+
+func (s *svc) GetProduct(ctx context.Context, req *model.GetProductReq) (*model.GetProductResp, error) {
+	res, err := s.store.GetProduct(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// drafts, archived and scheduled products are not visible to buyers
+	if res.Status != model.ProductStatusPublished {
+		return nil, products.ErrProductNotFound
+	}
+
+	return res, nil
 }
 
-func (s *svc) GetProduct(req *model.GetProductReq) (*model.GetProductResp, error) {
-	res, err := s.store.GetProduct(req)
+func (s *svc) CreateProduct(ctx context.Context, req *model.CreateProductReq) (*model.GetProductResp, error) {
+	err := s.store.IsShopOwner(ctx, req.UserId, req.ShopId)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Status == "" {
+		req.Status = model.ProductStatusDraft
+	}
+
+	req.PublishAt, err = s.publishAt(req.Status, req.PublishAt)
+	if err != nil {
+		return nil, err
+	}
+
+	req.Review = s.reviewRequest(req.Status, req.PublishAt, req.Name, req.Description)
+	if req.Review != nil {
+		req.Status = model.ProductStatusPendingReview
+		req.PublishAt = nil
+	}
+
+	schema, err := s.store.GetCategoryAttributes(ctx, req.CategoryId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := validateAttributes(schema, req.Attributes); err != nil {
+		return nil, err
+	}
+
+	res, err := s.store.CreateProduct(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -33,13 +148,36 @@ func (s *svc) GetProduct(req *model.GetProductReq) (*model.GetProductResp, error
 	return res, nil
 }
 
-func (s *svc) CreateProduct(req *model.CreateProductReq) (*model.GetProductResp, error) {
-	err := s.store.IsShopOwner(req.UserId, req.ShopId)
+func (s *svc) UpdateProduct(ctx context.Context, req *model.UpdateProductReq) (*model.GetProductResp, error) {
+	err := s.store.IsProductOwner(ctx, req.UserId, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	current, err := s.store.GetProduct(ctx, &model.GetProductReq{Id: req.Id})
+	if err != nil {
+		return nil, err
+	}
+
+	// edits to a live or scheduled product go back through review, edits to a
+	// product already in review refresh its flagged words
+	switch current.Status {
+	case model.ProductStatusPublished, model.ProductStatusScheduled:
+		req.Review = s.reviewRequest(current.Status, current.PublishAt, req.Name, req.Description)
+	case model.ProductStatusPendingReview:
+		req.Review = &model.ReviewRequest{FlaggedWords: bannedWords(s.moderation.BannedWords, req.Name, req.Description)}
+	}
+
+	schema, err := s.store.GetCategoryAttributes(ctx, req.CategoryId)
 	if err != nil {
 		return nil, err
 	}
 
-	res, err := s.store.CreateProduct(req)
+	if err := validateAttributes(schema, req.Attributes); err != nil {
+		return nil, err
+	}
+
+	res, err := s.store.UpdateProduct(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -47,8 +185,8 @@ func (s *svc) CreateProduct(req *model.CreateProductReq) (*model.GetProductResp,
 	return res, nil
 }
 
-func (s *svc) GetProducts(req *model.GetProductsReq) (*model.GetProductsResp, error) {
-	res, err := s.store.GetProducts(req)
+func (s *svc) GetProducts(ctx context.Context, req *model.GetProductsReq) (*model.GetProductsResp, error) {
+	res, err := s.store.GetProducts(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -56,11 +194,494 @@ func (s *svc) GetProducts(req *model.GetProductsReq) (*model.GetProductsResp, er
 	return res, nil
 }
 
-func (s *svc) DeleteProduct(req *model.DeleteProductReq) error {
-	err := s.store.DeleteProduct(req)
+func (s *svc) DeleteProduct(ctx context.Context, req *model.DeleteProductReq) error {
+	err := s.store.DeleteProduct(ctx, req)
 	if err != nil {
 		return err
 	}
 
 	return nil
 }
+
+func (s *svc) CreatePromotion(ctx context.Context, req *model.CreatePromotionReq) (*model.Promotion, error) {
+	switch req.Type {
+	case model.PromotionTypeFlashSale:
+		if req.Quantity == nil {
+			return nil, ErrFlashSaleQuantityRequired
+		}
+	case model.PromotionTypeSale:
+		req.Quantity = nil
+	}
+
+	err := s.store.IsProductOwner(ctx, req.UserId, req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.store.CreatePromotion(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *svc) ClaimFlashSale(ctx context.Context, req *model.ClaimFlashSaleReq) (*model.ClaimFlashSaleResp, error) {
+	req.ExpiresAt = s.now().Add(s.reservationTTL)
+
+	res, err := s.store.ClaimFlashSale(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *svc) CreateWarehouse(ctx context.Context, req *model.CreateWarehouseReq) (*model.Warehouse, error) {
+	err := s.store.IsShopOwner(ctx, req.UserId, req.ShopId)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.store.CreateWarehouse(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *svc) GetWarehouses(ctx context.Context, req *model.GetWarehousesReq) ([]*model.Warehouse, error) {
+	err := s.store.IsShopOwner(ctx, req.UserId, req.ShopId)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.store.GetWarehouses(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *svc) CreateStockAdjustment(ctx context.Context, req *model.CreateStockAdjustmentReq) (*model.StockAdjustmentResp, error) {
+	if req.Type == model.StockMovementReceipt && req.Quantity < 0 {
+		return nil, ErrReceiptQuantity
+	}
+
+	err := s.store.IsProductOwner(ctx, req.UserId, req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.store.CreateStockAdjustment(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *svc) SetProductOptions(ctx context.Context, req *model.SetProductOptionsReq) ([]*model.ProductOption, error) {
+	err := s.store.IsProductOwner(ctx, req.UserId, req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.store.SetProductOptions(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *svc) CreateVariant(ctx context.Context, req *model.CreateVariantReq) (*model.ProductVariant, error) {
+	err := s.store.IsProductOwner(ctx, req.UserId, req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+
+	options, err := s.store.GetProductOptions(ctx, req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := matchOptions(options, req.Options); err != nil {
+		return nil, err
+	}
+
+	res, err := s.store.CreateVariant(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// matchOptions checks that a variant picks exactly one allowed value for
+// every option of its product.
+func matchOptions(options []*model.ProductOption, values map[string]string) error {
+	if len(options) == 0 {
+		return ErrNoOptions
+	}
+
+	if len(values) != len(options) {
+		return ErrVariantOptionsMismatch
+	}
+
+	for _, option := range options {
+		value, ok := values[option.Name]
+		if !ok || !slices.Contains(option.Values, value) {
+			return ErrVariantOptionsMismatch
+		}
+	}
+
+	return nil
+}
+
+func (s *svc) AddProductImage(ctx context.Context, req *model.AddProductImageReq) (*model.ProductImage, error) {
+	err := s.store.IsProductOwner(ctx, req.UserId, req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.store.AddProductImage(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *svc) ReorderProductImages(ctx context.Context, req *model.ReorderProductImagesReq) ([]*model.ProductImage, error) {
+	err := s.store.IsProductOwner(ctx, req.UserId, req.ProductId)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.store.ReorderProductImages(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *svc) DeleteProductImage(ctx context.Context, req *model.DeleteProductImageReq) error {
+	err := s.store.IsProductOwner(ctx, req.UserId, req.ProductId)
+	if err != nil {
+		return err
+	}
+
+	err = s.store.DeleteProductImage(ctx, req)
+	if err != nil {
+		return err
+	}
+
+	return nil
+}
+
+func (s *svc) GetCategoryAttributes(ctx context.Context, req *model.GetCategoryAttributesReq) ([]*model.AttributeDefinition, error) {
+	res, err := s.store.GetCategoryAttributes(ctx, req.CategoryId)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *svc) SetCategoryAttributes(ctx context.Context, req *model.SetCategoryAttributesReq) ([]*model.AttributeDefinition, error) {
+	// categories are shared by every shop, only admins may change their schema
+	if !strings.EqualFold(req.Role, roleAdmin) {
+		return nil, ErrNotAdmin
+	}
+
+	names := make(map[string]bool, len(req.Attributes))
+	for _, attribute := range req.Attributes {
+		if names[attribute.Name] {
+			return nil, ErrAttributeNamesNotUnique
+		}
+		names[attribute.Name] = true
+
+		if attribute.Type == model.AttributeTypeEnum && len(attribute.Values) == 0 {
+			return nil, ErrEnumValuesRequired
+		}
+	}
+
+	if req.Attributes == nil {
+		req.Attributes = make([]*model.AttributeDefinition, 0)
+	}
+
+	res, err := s.store.SetCategoryAttributes(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// validateAttributes checks product attributes against the category schema.
+// A category without a schema accepts any scalar attributes.
+func validateAttributes(schema []*model.AttributeDefinition, attributes map[string]interface{}) error {
+	if len(schema) == 0 {
+		for name, value := range attributes {
+			switch value.(type) {
+			case string, float64, bool:
+			default:
+				return apperror.Newf(apperror.Unprocessable, "attribute_not_scalar", "invalid attribute %q: must be a string, number or boolean", name)
+			}
+		}
+		return nil
+	}
+
+	defined := make(map[string]*model.AttributeDefinition, len(schema))
+	for _, definition := range schema {
+		defined[definition.Name] = definition
+	}
+
+	for name := range attributes {
+		if _, ok := defined[name]; !ok {
+			return apperror.Newf(apperror.Unprocessable, "attribute_not_defined", "invalid attribute %q: not defined for the category", name)
+		}
+	}
+
+	for _, definition := range schema {
+		value, ok := attributes[definition.Name]
+		if !ok || value == nil {
+			if definition.Required {
+				return apperror.Newf(apperror.Unprocessable, "attribute_required", "invalid attribute %q: is required", definition.Name)
+			}
+			continue
+		}
+
+		switch definition.Type {
+		case model.AttributeTypeString:
+			if _, ok := value.(string); !ok {
+				return apperror.Newf(apperror.Unprocessable, "attribute_not_string", "invalid attribute %q: must be a string", definition.Name)
+			}
+		case model.AttributeTypeNumber:
+			if _, ok := value.(float64); !ok {
+				return apperror.Newf(apperror.Unprocessable, "attribute_not_number", "invalid attribute %q: must be a number", definition.Name)
+			}
+		case model.AttributeTypeBoolean:
+			if _, ok := value.(bool); !ok {
+				return apperror.Newf(apperror.Unprocessable, "attribute_not_boolean", "invalid attribute %q: must be a boolean", definition.Name)
+			}
+		case model.AttributeTypeEnum:
+			if v, ok := value.(string); !ok || !slices.Contains(definition.Values, v) {
+				return apperror.Newf(apperror.Unprocessable, "attribute_not_allowed", "invalid attribute %q: must be one of %s", definition.Name, strings.Join(definition.Values, ", "))
+			}
+		}
+	}
+
+	return nil
+}
+
+func (s *svc) SetProductStatus(ctx context.Context, req *model.SetProductStatusReq) (*model.GetProductResp, error) {
+	var err error
+	req.PublishAt, err = s.publishAt(req.Status, req.PublishAt)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.store.IsProductOwner(ctx, req.UserId, req.Id)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Status == model.ProductStatusPublished || req.Status == model.ProductStatusScheduled {
+		current, err := s.store.GetProduct(ctx, &model.GetProductReq{Id: req.Id})
+		if err != nil {
+			return nil, err
+		}
+
+		req.Review = s.reviewRequest(req.Status, req.PublishAt, current.Name, current.Description)
+		if req.Review != nil {
+			req.PublishAt = nil
+		}
+	}
+
+	res, err := s.store.SetProductStatus(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// publishAt returns the publish_at to store for a status. Scheduled products
+// need a future publish_at, published products go live now and the other
+// statuses have none.
+func (s *svc) publishAt(status string, publishAt *time.Time) (*time.Time, error) {
+	now := s.now()
+
+	switch status {
+	case model.ProductStatusScheduled:
+		if publishAt == nil {
+			return nil, ErrPublishAtRequired
+		}
+		if !publishAt.After(now) {
+			return nil, ErrPublishAtInPast
+		}
+		return publishAt, nil
+	case model.ProductStatusPublished:
+		return &now, nil
+	default:
+		return nil, nil
+	}
+}
+
+// reviewRequest returns the review a product needs before it becomes status,
+// or nil when it can go straight through.
+func (s *svc) reviewRequest(status string, publishAt *time.Time, texts ...string) *model.ReviewRequest {
+	if status != model.ProductStatusPublished && status != model.ProductStatusScheduled {
+		return nil
+	}
+
+	flagged := bannedWords(s.moderation.BannedWords, texts...)
+	if !s.moderation.Enabled && len(flagged) == 0 {
+		return nil
+	}
+
+	return &model.ReviewRequest{
+		TargetStatus: status,
+		PublishAt:    publishAt,
+		FlaggedWords: flagged,
+	}
+}
+
+// bannedWords returns the banned words found in texts. Matching ignores case
+// and punctuation and only hits whole words, so a banned phrase matches
+// across spaces but "ass" does not match "glass".
+func bannedWords(banned []string, texts ...string) []string {
+	var (
+		found = make([]string, 0)
+		text  = " " + normalizeWords(strings.Join(texts, " ")) + " "
+	)
+
+	for _, word := range banned {
+		word = normalizeWords(word)
+		if word != "" && strings.Contains(text, " "+word+" ") && !slices.Contains(found, word) {
+			found = append(found, word)
+		}
+	}
+
+	return found
+}
+
+func normalizeWords(s string) string {
+	return strings.Join(strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}), " ")
+}
+
+func (s *svc) GetShopProducts(ctx context.Context, req *model.GetShopProductsReq) (*model.GetProductsResp, error) {
+	err := s.store.IsShopOwner(ctx, req.UserId, req.ShopId)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.store.GetShopProducts(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// PublishScheduled publishes due scheduled products batch by batch until
+// none are left and returns how many were published.
+func (s *svc) PublishScheduled(ctx context.Context) (int, error) {
+	var total int
+	for {
+		published, err := s.store.PublishScheduledProducts(ctx, publishBatchSize)
+		total += published
+		if err != nil {
+			return total, err
+		}
+
+		if published < publishBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// RunScheduler publishes due scheduled products every interval until ctx is
+// done.
+func (s *svc) RunScheduler(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			published, err := s.PublishScheduled(ctx)
+			if err != nil {
+				s.logger.ErrorContext(ctx, "failed to publish scheduled products", "method", "RunScheduler", "err", err)
+			}
+
+			if published > 0 {
+				s.logger.InfoContext(ctx, "published scheduled products", "method", "RunScheduler", "published", published)
+			}
+		}
+	}
+}
+
+func (s *svc) RestoreProduct(ctx context.Context, req *model.RestoreProductReq) (*model.GetProductResp, error) {
+	req.DeletedAfter = s.now().Add(-s.retention.RestoreWindow)
+
+	res, err := s.store.RestoreProduct(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// PurgeDeleted hard-deletes products deleted longer than the purge period,
+// batch by batch, and returns how many were purged.
+func (s *svc) PurgeDeleted(ctx context.Context) (int, error) {
+	var (
+		total         int
+		deletedBefore = s.now().Add(-s.retention.PurgeAfter)
+	)
+
+	for {
+		purged, err := s.store.PurgeDeletedProducts(ctx, deletedBefore, purgeBatchSize)
+		total += purged
+		if err != nil {
+			return total, err
+		}
+
+		if purged < purgeBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// RunPurger purges expired soft-deleted products every interval until ctx is
+// done.
+func (s *svc) RunPurger(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			purged, err := s.PurgeDeleted(ctx)
+			if err != nil {
+				s.logger.ErrorContext(ctx, "failed to purge deleted products", "method", "RunPurger", "err", err)
+			}
+
+			if purged > 0 {
+				s.logger.InfoContext(ctx, "purged deleted products", "method", "RunPurger", "purged", purged)
+			}
+		}
+	}
+}
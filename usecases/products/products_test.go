@@ -1,10 +1,16 @@
 package products
 
 import (
+	"codebase-service/helper"
 	mock_products "codebase-service/mock/repository/products"
 	model "codebase-service/models"
+	repo "codebase-service/repository/products"
+	"codebase-service/util/logging"
+	"context"
 	"database/sql"
+	"github.com/stretchr/testify/mock"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/suite"
 )
@@ -16,21 +22,30 @@ func TestProductsService(t *testing.T) {
 type ProductServiceTestSuite struct {
 	suite.Suite
 	productRepo *mock_products.MockProductRepo
-	service     ProductSvc
+	service     *svc
+	now         time.Time
 }
 
 func (s *ProductServiceTestSuite) SetupTest() {
+	validator, err := helper.NewValidator()
+	s.Require().NoError(err)
+
 	s.productRepo = mock_products.NewMockProductRepo()
-	s.service = NewProductSvc(s.productRepo)
+	s.service = NewProductSvc(s.productRepo, validator, Moderation{BannedWords: []string{"replica"}}, Retention{
+		RestoreWindow: 30 * 24 * time.Hour,
+		PurgeAfter:    90 * 24 * time.Hour,
+	}, 15*time.Minute, logging.Discard())
+	s.now = time.Date(2024, 11, 20, 10, 0, 0, 0, time.UTC)
+	s.service.now = func() time.Time { return s.now }
 }
 
 func (s *ProductServiceTestSuite) TestGetProducts_Success() {
 	req := new(model.GetProductsReq)
 	res := new(model.GetProductsResp)
 
-	s.productRepo.On("GetProducts", req).Return(res, nil)
+	s.productRepo.On("GetProducts", mock.Anything, req).Return(res, nil)
 
-	resp, err := s.service.GetProducts(req)
+	resp, err := s.service.GetProducts(context.Background(), req)
 
 	s.NoError(err)
 	s.NotNil(resp)
@@ -40,9 +55,9 @@ func (s *ProductServiceTestSuite) TestGetProducts_Success() {
 func (s *ProductServiceTestSuite) TestGetProducts_Failed() {
 	req := new(model.GetProductsReq)
 
-	s.productRepo.On("GetProducts", req).Return(nil, sql.ErrConnDone)
+	s.productRepo.On("GetProducts", mock.Anything, req).Return(nil, sql.ErrConnDone)
 
-	resp, err := s.service.GetProducts(req)
+	resp, err := s.service.GetProducts(context.Background(), req)
 
 	s.Error(err)
 	s.Nil(resp)
@@ -51,23 +66,36 @@ func (s *ProductServiceTestSuite) TestGetProducts_Failed() {
 
 func (s *ProductServiceTestSuite) TestGetProduct_Success() {
 	req := new(model.GetProductReq)
-	res := new(model.GetProductResp)
+	res := &model.GetProductResp{Status: model.ProductStatusPublished}
 
-	s.productRepo.On("GetProduct", req).Return(res, nil)
+	s.productRepo.On("GetProduct", mock.Anything, req).Return(res, nil)
 
-	resp, err := s.service.GetProduct(req)
+	resp, err := s.service.GetProduct(context.Background(), req)
 
 	s.NoError(err)
 	s.NotNil(resp)
 	s.productRepo.AssertExpectations(s.T())
 }
 
+func (s *ProductServiceTestSuite) TestGetProduct_Draft() {
+	req := new(model.GetProductReq)
+	res := &model.GetProductResp{Status: model.ProductStatusDraft}
+
+	s.productRepo.On("GetProduct", mock.Anything, req).Return(res, nil)
+
+	resp, err := s.service.GetProduct(context.Background(), req)
+
+	s.EqualError(err, "no product found")
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
 func (s *ProductServiceTestSuite) TestGetProduct_NotFound() {
 	req := new(model.GetProductReq)
 
-	s.productRepo.On("GetProduct", req).Return(nil, sql.ErrNoRows)
+	s.productRepo.On("GetProduct", mock.Anything, req).Return(nil, sql.ErrNoRows)
 
-	resp, err := s.service.GetProduct(req)
+	resp, err := s.service.GetProduct(context.Background(), req)
 
 	s.Equal(sql.ErrNoRows, err)
 	s.Nil(resp)
@@ -77,9 +105,9 @@ func (s *ProductServiceTestSuite) TestGetProduct_NotFound() {
 func (s *ProductServiceTestSuite) TestGetProduct_Failed() {
 	req := new(model.GetProductReq)
 
-	s.productRepo.On("GetProduct", req).Return(nil, sql.ErrConnDone)
+	s.productRepo.On("GetProduct", mock.Anything, req).Return(nil, sql.ErrConnDone)
 
-	resp, err := s.service.GetProduct(req)
+	resp, err := s.service.GetProduct(context.Background(), req)
 
 	s.Error(err)
 	s.Nil(resp)
@@ -89,9 +117,9 @@ func (s *ProductServiceTestSuite) TestGetProduct_Failed() {
 func (s *ProductServiceTestSuite) TestDeleteProduct_Success() {
 	req := new(model.DeleteProductReq)
 
-	s.productRepo.On("DeleteProduct", req).Return(nil)
+	s.productRepo.On("DeleteProduct", mock.Anything, req).Return(nil)
 
-	err := s.service.DeleteProduct(req)
+	err := s.service.DeleteProduct(context.Background(), req)
 
 	s.NoError(err)
 	s.productRepo.AssertExpectations(s.T())
@@ -100,22 +128,37 @@ func (s *ProductServiceTestSuite) TestDeleteProduct_Success() {
 func (s *ProductServiceTestSuite) TestDeleteProduct_Failed() {
 	req := new(model.DeleteProductReq)
 
-	s.productRepo.On("DeleteProduct", req).Return(sql.ErrConnDone)
+	s.productRepo.On("DeleteProduct", mock.Anything, req).Return(sql.ErrConnDone)
 
-	err := s.service.DeleteProduct(req)
+	err := s.service.DeleteProduct(context.Background(), req)
 
 	s.Error(err)
 	s.productRepo.AssertExpectations(s.T())
 }
 
+func (s *ProductServiceTestSuite) TestDeleteProduct_TypedErrors() {
+	for _, want := range []error{repo.ErrProductNotFound, repo.ErrProductAlreadyDeleted, repo.ErrNotShopOwner} {
+		s.SetupTest()
+		req := new(model.DeleteProductReq)
+
+		s.productRepo.On("DeleteProduct", mock.Anything, req).Return(want)
+
+		err := s.service.DeleteProduct(context.Background(), req)
+
+		s.ErrorIs(err, want)
+		s.productRepo.AssertExpectations(s.T())
+	}
+}
+
 func (s *ProductServiceTestSuite) TestCreateProduct_Success() {
 	req := new(model.CreateProductReq)
 	res := new(model.GetProductResp)
 
-	s.productRepo.On("CreateProduct", req).Return(res, nil)
-	s.productRepo.On("IsShopOwner", req.UserId, req.ShopId).Return(nil)
+	s.productRepo.On("CreateProduct", mock.Anything, req).Return(res, nil)
+	s.productRepo.On("IsShopOwner", mock.Anything, req.UserId, req.ShopId).Return(nil)
+	s.productRepo.On("GetCategoryAttributes", mock.Anything, req.CategoryId).Return([]*model.AttributeDefinition{}, nil)
 
-	resp, err := s.service.CreateProduct(req)
+	resp, err := s.service.CreateProduct(context.Background(), req)
 
 	s.NoError(err)
 	s.NotNil(resp)
@@ -125,10 +168,11 @@ func (s *ProductServiceTestSuite) TestCreateProduct_Success() {
 func (s *ProductServiceTestSuite) TestCreateProduct_Failed() {
 	req := new(model.CreateProductReq)
 
-	s.productRepo.On("CreateProduct", req).Return(nil, sql.ErrConnDone)
-	s.productRepo.On("IsShopOwner", req.UserId, req.ShopId).Return(nil)
+	s.productRepo.On("CreateProduct", mock.Anything, req).Return(nil, sql.ErrConnDone)
+	s.productRepo.On("IsShopOwner", mock.Anything, req.UserId, req.ShopId).Return(nil)
+	s.productRepo.On("GetCategoryAttributes", mock.Anything, req.CategoryId).Return([]*model.AttributeDefinition{}, nil)
 
-	resp, err := s.service.CreateProduct(req)
+	resp, err := s.service.CreateProduct(context.Background(), req)
 
 	s.Error(err)
 	s.Nil(resp)
@@ -138,12 +182,426 @@ func (s *ProductServiceTestSuite) TestCreateProduct_Failed() {
 func (s *ProductServiceTestSuite) TestCreateProduct_NotFound() {
 	req := new(model.CreateProductReq)
 
-	s.productRepo.On("CreateProduct", req).Return(nil, sql.ErrNoRows)
-	s.productRepo.On("IsShopOwner", req.UserId, req.ShopId).Return(nil)
+	s.productRepo.On("CreateProduct", mock.Anything, req).Return(nil, sql.ErrNoRows)
+	s.productRepo.On("IsShopOwner", mock.Anything, req.UserId, req.ShopId).Return(nil)
+	s.productRepo.On("GetCategoryAttributes", mock.Anything, req.CategoryId).Return([]*model.AttributeDefinition{}, nil)
+
+	resp, err := s.service.CreateProduct(context.Background(), req)
+
+	s.Error(err)
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestCreateProduct_DefaultsToDraft() {
+	req := new(model.CreateProductReq)
+	res := new(model.GetProductResp)
+
+	s.productRepo.On("IsShopOwner", mock.Anything, req.UserId, req.ShopId).Return(nil)
+	s.productRepo.On("GetCategoryAttributes", mock.Anything, req.CategoryId).Return([]*model.AttributeDefinition{}, nil)
+	s.productRepo.On("CreateProduct", mock.Anything, req).Return(res, nil)
+
+	_, err := s.service.CreateProduct(context.Background(), req)
+
+	s.NoError(err)
+	s.Equal(model.ProductStatusDraft, req.Status)
+	s.Nil(req.PublishAt)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestCreateProduct_ScheduledInThePast() {
+	publishAt := s.now.Add(-time.Hour)
+	req := &model.CreateProductReq{Status: model.ProductStatusScheduled, PublishAt: &publishAt}
+
+	s.productRepo.On("IsShopOwner", mock.Anything, req.UserId, req.ShopId).Return(nil)
+
+	resp, err := s.service.CreateProduct(context.Background(), req)
+
+	s.EqualError(err, "publish_at must be in the future")
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestSetProductStatus_Published() {
+	req := &model.SetProductStatusReq{Status: model.ProductStatusPublished}
+	res := new(model.GetProductResp)
+
+	s.productRepo.On("IsProductOwner", mock.Anything, req.UserId, req.Id).Return(nil)
+	s.productRepo.On("GetProduct", mock.Anything, &model.GetProductReq{Id: req.Id}).Return(&model.GetProductResp{Name: "Desk lamp"}, nil)
+	s.productRepo.On("SetProductStatus", mock.Anything, req).Return(res, nil)
+
+	resp, err := s.service.SetProductStatus(context.Background(), req)
+
+	s.NoError(err)
+	s.NotNil(resp)
+	s.Equal(s.now, *req.PublishAt)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestSetProductStatus_BannedWordNeedsReview() {
+	req := &model.SetProductStatusReq{Status: model.ProductStatusPublished}
+	res := new(model.GetProductResp)
+
+	s.productRepo.On("IsProductOwner", mock.Anything, req.UserId, req.Id).Return(nil)
+	s.productRepo.On("GetProduct", mock.Anything, &model.GetProductReq{Id: req.Id}).Return(&model.GetProductResp{Name: "Watch", Description: "A REPLICA, like new."}, nil)
+	s.productRepo.On("SetProductStatus", mock.Anything, req).Return(res, nil)
+
+	_, err := s.service.SetProductStatus(context.Background(), req)
+
+	s.NoError(err)
+	s.Require().NotNil(req.Review)
+	s.Equal(model.ProductStatusPublished, req.Review.TargetStatus)
+	s.Equal([]string{"replica"}, req.Review.FlaggedWords)
+	s.Nil(req.PublishAt)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestCreateProduct_ModerationEnabled() {
+	s.service.moderation.Enabled = true
+	publishAt := s.now.Add(time.Hour)
+	req := &model.CreateProductReq{Name: "Desk lamp", Status: model.ProductStatusScheduled, PublishAt: &publishAt}
+	res := new(model.GetProductResp)
+
+	s.productRepo.On("IsShopOwner", mock.Anything, req.UserId, req.ShopId).Return(nil)
+	s.productRepo.On("GetCategoryAttributes", mock.Anything, req.CategoryId).Return([]*model.AttributeDefinition{}, nil)
+	s.productRepo.On("CreateProduct", mock.Anything, req).Return(res, nil)
+
+	_, err := s.service.CreateProduct(context.Background(), req)
+
+	s.NoError(err)
+	s.Equal(model.ProductStatusPendingReview, req.Status)
+	s.Require().NotNil(req.Review)
+	s.Equal(model.ProductStatusScheduled, req.Review.TargetStatus)
+	s.Equal(publishAt, *req.Review.PublishAt)
+	s.Empty(req.Review.FlaggedWords)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestUpdateProduct_PublishedNeedsReview() {
+	s.service.moderation.Enabled = true
+	req := &model.UpdateProductReq{Name: "Desk lamp"}
+	res := new(model.GetProductResp)
+
+	s.productRepo.On("IsProductOwner", mock.Anything, req.UserId, req.Id).Return(nil)
+	s.productRepo.On("GetProduct", mock.Anything, &model.GetProductReq{Id: req.Id}).Return(&model.GetProductResp{Status: model.ProductStatusPublished}, nil)
+	s.productRepo.On("GetCategoryAttributes", mock.Anything, req.CategoryId).Return([]*model.AttributeDefinition{}, nil)
+	s.productRepo.On("UpdateProduct", mock.Anything, req).Return(res, nil)
+
+	_, err := s.service.UpdateProduct(context.Background(), req)
+
+	s.NoError(err)
+	s.Require().NotNil(req.Review)
+	s.Equal(model.ProductStatusPublished, req.Review.TargetStatus)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestBannedWords_WholeWordsOnly() {
+	found := bannedWords([]string{"ass", "fake news", "replica"}, "Glass bottle", "No FAKE-news here")
+
+	s.Equal([]string{"fake news"}, found)
+}
+
+func (s *ProductServiceTestSuite) TestSetProductStatus_ScheduledWithoutPublishAt() {
+	req := &model.SetProductStatusReq{Status: model.ProductStatusScheduled}
+
+	resp, err := s.service.SetProductStatus(context.Background(), req)
+
+	s.EqualError(err, "publish_at is required for scheduled products")
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestGetShopProducts_NotShopOwner() {
+	req := new(model.GetShopProductsReq)
+
+	s.productRepo.On("IsShopOwner", mock.Anything, req.UserId, req.ShopId).Return(repo.ErrNotShopOwner)
+
+	resp, err := s.service.GetShopProducts(context.Background(), req)
+
+	s.Error(err)
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestPublishScheduled_DrainsBatches() {
+	s.productRepo.On("PublishScheduledProducts", mock.Anything, publishBatchSize).Return(publishBatchSize, nil).Once()
+	s.productRepo.On("PublishScheduledProducts", mock.Anything, publishBatchSize).Return(3, nil).Once()
+
+	published, err := s.service.PublishScheduled(context.Background())
+
+	s.NoError(err)
+	s.Equal(publishBatchSize+3, published)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestCreateProduct_InvalidAttributes() {
+	req := &model.CreateProductReq{Attributes: map[string]interface{}{"weight": "heavy"}}
+
+	s.productRepo.On("IsShopOwner", mock.Anything, req.UserId, req.ShopId).Return(nil)
+	s.productRepo.On("GetCategoryAttributes", mock.Anything, req.CategoryId).Return([]*model.AttributeDefinition{
+		{Name: "weight", Type: model.AttributeTypeNumber},
+	}, nil)
+
+	resp, err := s.service.CreateProduct(context.Background(), req)
+
+	s.EqualError(err, `invalid attribute "weight": must be a number`)
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestUpdateProduct_Success() {
+	req := &model.UpdateProductReq{Attributes: map[string]interface{}{"gender": "unisex", "brand": "Acme"}}
+	res := new(model.GetProductResp)
+
+	s.productRepo.On("IsProductOwner", mock.Anything, req.UserId, req.Id).Return(nil)
+	s.productRepo.On("GetProduct", mock.Anything, &model.GetProductReq{Id: req.Id}).Return(&model.GetProductResp{Status: model.ProductStatusDraft}, nil)
+	s.productRepo.On("GetCategoryAttributes", mock.Anything, req.CategoryId).Return([]*model.AttributeDefinition{
+		{Name: "brand", Type: model.AttributeTypeString, Required: true},
+		{Name: "gender", Type: model.AttributeTypeEnum, Values: []string{"men", "women", "unisex"}},
+		{Name: "material", Type: model.AttributeTypeString},
+	}, nil)
+	s.productRepo.On("UpdateProduct", mock.Anything, req).Return(res, nil)
+
+	resp, err := s.service.UpdateProduct(context.Background(), req)
+
+	s.NoError(err)
+	s.NotNil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestUpdateProduct_MissingRequiredAttribute() {
+	req := &model.UpdateProductReq{Attributes: map[string]interface{}{}}
+
+	s.productRepo.On("IsProductOwner", mock.Anything, req.UserId, req.Id).Return(nil)
+	s.productRepo.On("GetProduct", mock.Anything, &model.GetProductReq{Id: req.Id}).Return(&model.GetProductResp{Status: model.ProductStatusDraft}, nil)
+	s.productRepo.On("GetCategoryAttributes", mock.Anything, req.CategoryId).Return([]*model.AttributeDefinition{
+		{Name: "isbn", Type: model.AttributeTypeString, Required: true},
+	}, nil)
+
+	resp, err := s.service.UpdateProduct(context.Background(), req)
+
+	s.EqualError(err, `invalid attribute "isbn": is required`)
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestUpdateProduct_UnknownAttribute() {
+	req := &model.UpdateProductReq{Attributes: map[string]interface{}{"colour": "red"}}
+
+	s.productRepo.On("IsProductOwner", mock.Anything, req.UserId, req.Id).Return(nil)
+	s.productRepo.On("GetProduct", mock.Anything, &model.GetProductReq{Id: req.Id}).Return(&model.GetProductResp{Status: model.ProductStatusDraft}, nil)
+	s.productRepo.On("GetCategoryAttributes", mock.Anything, req.CategoryId).Return([]*model.AttributeDefinition{
+		{Name: "brand", Type: model.AttributeTypeString},
+	}, nil)
 
-	resp, err := s.service.CreateProduct(req)
+	resp, err := s.service.UpdateProduct(context.Background(), req)
+
+	s.EqualError(err, `invalid attribute "colour": not defined for the category`)
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestSetCategoryAttributes_NotAdmin() {
+	req := &model.SetCategoryAttributesReq{Role: "seller"}
+
+	resp, err := s.service.SetCategoryAttributes(context.Background(), req)
+
+	s.EqualError(err, "user is not admin")
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestSetCategoryAttributes_EnumWithoutValues() {
+	req := &model.SetCategoryAttributesReq{Role: "admin", Attributes: []*model.AttributeDefinition{
+		{Name: "size", Type: model.AttributeTypeEnum},
+	}}
+
+	resp, err := s.service.SetCategoryAttributes(context.Background(), req)
+
+	s.EqualError(err, "enum attributes need values")
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestRestoreProduct_UsesRestoreWindow() {
+	req := new(model.RestoreProductReq)
+	res := new(model.GetProductResp)
+
+	s.productRepo.On("RestoreProduct", mock.Anything, req).Return(res, nil)
+
+	resp, err := s.service.RestoreProduct(context.Background(), req)
+
+	s.NoError(err)
+	s.NotNil(resp)
+	s.Equal(s.now.Add(-30*24*time.Hour), req.DeletedAfter)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestRestoreProduct_WindowExpired() {
+	req := new(model.RestoreProductReq)
+
+	s.productRepo.On("RestoreProduct", mock.Anything, req).Return(nil, repo.ErrRestoreWindowExpired)
+
+	resp, err := s.service.RestoreProduct(context.Background(), req)
+
+	s.EqualError(err, "restore window has expired")
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestPurgeDeleted_DrainsBatches() {
+	deletedBefore := s.now.Add(-90 * 24 * time.Hour)
+
+	s.productRepo.On("PurgeDeletedProducts", mock.Anything, deletedBefore, purgeBatchSize).Return(purgeBatchSize, nil).Once()
+	s.productRepo.On("PurgeDeletedProducts", mock.Anything, deletedBefore, purgeBatchSize).Return(0, nil).Once()
+
+	purged, err := s.service.PurgeDeleted(context.Background())
+
+	s.NoError(err)
+	s.Equal(purgeBatchSize, purged)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestCreatePromotion_Success() {
+	req := &model.CreatePromotionReq{Type: model.PromotionTypeSale}
+	res := new(model.Promotion)
+
+	s.productRepo.On("IsProductOwner", mock.Anything, req.UserId, req.ProductId).Return(nil)
+	s.productRepo.On("CreatePromotion", mock.Anything, req).Return(res, nil)
+
+	resp, err := s.service.CreatePromotion(context.Background(), req)
+
+	s.NoError(err)
+	s.NotNil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestCreatePromotion_FlashSaleWithoutQuantity() {
+	req := &model.CreatePromotionReq{Type: model.PromotionTypeFlashSale}
+
+	resp, err := s.service.CreatePromotion(context.Background(), req)
+
+	s.Error(err)
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestClaimFlashSale_HeldForReservationTTL() {
+	req := new(model.ClaimFlashSaleReq)
+	res := new(model.ClaimFlashSaleResp)
+
+	s.productRepo.On("ClaimFlashSale", mock.Anything, req).Return(res, nil)
+
+	resp, err := s.service.ClaimFlashSale(context.Background(), req)
+
+	s.NoError(err)
+	s.NotNil(resp)
+	s.Equal(s.now.Add(15*time.Minute), req.ExpiresAt)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestClaimFlashSale_Failed() {
+	req := new(model.ClaimFlashSaleReq)
+
+	s.productRepo.On("ClaimFlashSale", mock.Anything, req).Return(nil, sql.ErrConnDone)
+
+	resp, err := s.service.ClaimFlashSale(context.Background(), req)
+
+	s.Error(err)
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestCreateWarehouse_NotShopOwner() {
+	req := new(model.CreateWarehouseReq)
+
+	s.productRepo.On("IsShopOwner", mock.Anything, req.UserId, req.ShopId).Return(repo.ErrNotShopOwner)
+
+	resp, err := s.service.CreateWarehouse(context.Background(), req)
+
+	s.Error(err)
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestCreateStockAdjustment_Success() {
+	req := &model.CreateStockAdjustmentReq{Type: model.StockMovementAdjustment, Quantity: -5}
+	res := new(model.StockAdjustmentResp)
+
+	s.productRepo.On("IsProductOwner", mock.Anything, req.UserId, req.ProductId).Return(nil)
+	s.productRepo.On("CreateStockAdjustment", mock.Anything, req).Return(res, nil)
+
+	resp, err := s.service.CreateStockAdjustment(context.Background(), req)
+
+	s.NoError(err)
+	s.NotNil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestCreateStockAdjustment_NegativeReceipt() {
+	req := &model.CreateStockAdjustmentReq{Type: model.StockMovementReceipt, Quantity: -5}
+
+	resp, err := s.service.CreateStockAdjustment(context.Background(), req)
 
 	s.Error(err)
 	s.Nil(resp)
 	s.productRepo.AssertExpectations(s.T())
 }
+
+func (s *ProductServiceTestSuite) TestCreateVariant_Success() {
+	req := &model.CreateVariantReq{Options: map[string]string{"Size": "M", "Colour": "Red"}}
+	res := new(model.ProductVariant)
+
+	s.productRepo.On("IsProductOwner", mock.Anything, req.UserId, req.ProductId).Return(nil)
+	s.productRepo.On("GetProductOptions", mock.Anything, req.ProductId).Return([]*model.ProductOption{
+		{Name: "Size", Values: []string{"S", "M", "L"}},
+		{Name: "Colour", Values: []string{"Red", "Blue"}},
+	}, nil)
+	s.productRepo.On("CreateVariant", mock.Anything, req).Return(res, nil)
+
+	resp, err := s.service.CreateVariant(context.Background(), req)
+
+	s.NoError(err)
+	s.NotNil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestCreateVariant_UnknownOptionValue() {
+	req := &model.CreateVariantReq{Options: map[string]string{"Size": "XXL"}}
+
+	s.productRepo.On("IsProductOwner", mock.Anything, req.UserId, req.ProductId).Return(nil)
+	s.productRepo.On("GetProductOptions", mock.Anything, req.ProductId).Return([]*model.ProductOption{
+		{Name: "Size", Values: []string{"S", "M", "L"}},
+	}, nil)
+
+	resp, err := s.service.CreateVariant(context.Background(), req)
+
+	s.EqualError(err, "variant options do not match the product options")
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestAddProductImage_Success() {
+	req := new(model.AddProductImageReq)
+	res := new(model.ProductImage)
+
+	s.productRepo.On("IsProductOwner", mock.Anything, req.UserId, req.ProductId).Return(nil)
+	s.productRepo.On("AddProductImage", mock.Anything, req).Return(res, nil)
+
+	resp, err := s.service.AddProductImage(context.Background(), req)
+
+	s.NoError(err)
+	s.NotNil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ProductServiceTestSuite) TestDeleteProductImage_NotShopOwner() {
+	req := new(model.DeleteProductImageReq)
+
+	s.productRepo.On("IsProductOwner", mock.Anything, req.UserId, req.ProductId).Return(repo.ErrNotShopOwner)
+
+	err := s.service.DeleteProductImage(context.Background(), req)
+
+	s.Error(err)
+	s.productRepo.AssertExpectations(s.T())
+}
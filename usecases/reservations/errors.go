@@ -0,0 +1,9 @@
+package reservations
+
+import "codebase-service/util/apperror"
+
+// Errors the service reports to its callers.
+var (
+	ErrNotService   = apperror.New(apperror.Forbidden, "not_service", "caller is not an internal service")
+	ErrRefundFailed = apperror.New(apperror.Unavailable, "refund_failed", "payment provider could not refund the payment")
+)
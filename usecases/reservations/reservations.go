@@ -0,0 +1,232 @@
+package reservations
+
+import (
+	model "codebase-service/models"
+	"codebase-service/repository/products"
+	"codebase-service/util/payment"
+	"context"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+)
+
+const (
+	roleAdmin = "admin"
+	// roleService is the role the gateway gives calls from the order and
+	// payment services
+	roleService = "service"
+
+	sweepBatchSize = 100
+)
+
+var _ ReservationSvc = &svc{}
+
+type svc struct {
+	store    products.ProductRepository
+	payments payment.Provider
+	ttl      time.Duration
+	now      func() time.Time
+	logger   *slog.Logger
+}
+
+// NewReservationSvc pays refunds back through payments.
+func NewReservationSvc(store products.ProductRepository, payments payment.Provider, ttl time.Duration, logger *slog.Logger) *svc {
+	return &svc{
+		store:    store,
+		payments: payments,
+		ttl:      ttl,
+		now:      time.Now,
+		logger:   logger.With("component", "svc.reservations"),
+	}
+}
+
+type ReservationSvc interface {
+	CreateReservation(ctx context.Context, req *model.CreateReservationReq) (*model.Reservation, error)
+	ConfirmReservation(ctx context.Context, req *model.ReservationReq) (*model.Reservation, error)
+	ReleaseReservation(ctx context.Context, req *model.ReservationReq) (*model.Reservation, error)
+	ReleaseExpired(ctx context.Context) (int, error)
+	RefundReservation(ctx context.Context, req *model.CreateRefundReq) (*model.Refund, error)
+}
+
+func (s *svc) CreateReservation(ctx context.Context, req *model.CreateReservationReq) (*model.Reservation, error) {
+	req.Items = mergeItems(req.Items)
+	req.ExpiresAt = s.now().Add(s.ttl)
+
+	res, err := s.store.CreateReservation(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// ConfirmReservation is called by the payment service once the checkout is
+// paid, buyers cannot confirm their own holds.
+func (s *svc) ConfirmReservation(ctx context.Context, req *model.ReservationReq) (*model.Reservation, error) {
+	if !strings.EqualFold(req.Role, roleService) {
+		return nil, ErrNotService
+	}
+
+	res, err := s.store.ConfirmReservation(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *svc) ReleaseReservation(ctx context.Context, req *model.ReservationReq) (*model.Reservation, error) {
+	res, err := s.store.ReleaseReservation(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// RefundReservation refunds a paid reservation, whole or some of its lines.
+// Admins refund any line, sellers the lines of their own shops. The refund is
+// recorded as pending before the payment provider is called, so its units
+// cannot be refunded twice meanwhile, and the provider's answer settles it.
+// A refused refund is kept as failed and reported with ErrRefundFailed.
+func (s *svc) RefundReservation(ctx context.Context, req *model.CreateRefundReq) (*model.Refund, error) {
+	req.SellerId = ""
+	if !strings.EqualFold(req.Role, roleAdmin) {
+		req.SellerId = req.UserId
+	}
+	req.Items = mergeRefundItems(req.Items)
+
+	refund, err := s.store.CreateRefund(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	// the money may move at the provider, the answer is recorded even when
+	// the caller went away
+	ctx = context.WithoutCancel(ctx)
+
+	complete := &model.CompleteRefundReq{
+		Id:     refund.Id,
+		UserId: req.UserId,
+		Status: model.RefundStatusSucceeded,
+	}
+
+	paid, err := s.payments.Refund(ctx, &payment.RefundReq{
+		OrderId:   refund.OrderId,
+		RefundKey: refund.Id,
+		Amount:    refund.Amount,
+		Reason:    refund.Reason,
+	})
+	if err != nil {
+		s.logger.ErrorContext(ctx, "payment provider did not refund", "method", "RefundReservation", "refund_id", refund.Id, "err", err)
+		reason := err.Error()
+		complete.Status = model.RefundStatusFailed
+		complete.FailureReason = &reason
+	} else {
+		complete.ProviderReference = &paid.Reference
+	}
+
+	res, err := s.store.CompleteRefund(ctx, complete)
+	if err != nil {
+		return nil, err
+	}
+
+	if res.Status == model.RefundStatusFailed {
+		return nil, ErrRefundFailed
+	}
+
+	return res, nil
+}
+
+// ReleaseExpired releases expired reservations batch by batch until none are
+// left and returns how many were released.
+func (s *svc) ReleaseExpired(ctx context.Context) (int, error) {
+	var total int
+	for {
+		released, err := s.store.ReleaseExpiredReservations(ctx, sweepBatchSize)
+		total += released
+		if err != nil {
+			return total, err
+		}
+
+		if released < sweepBatchSize {
+			return total, nil
+		}
+	}
+}
+
+// RunSweeper releases expired reservations every interval until ctx is done.
+func (s *svc) RunSweeper(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			released, err := s.ReleaseExpired(ctx)
+			if err != nil {
+				s.logger.ErrorContext(ctx, "failed to release expired reservations", "method", "RunSweeper", "err", err)
+			}
+
+			if released > 0 {
+				s.logger.InfoContext(ctx, "released expired reservations", "method", "RunSweeper", "released", released)
+			}
+		}
+	}
+}
+
+// mergeItems folds repeated products and variants into one line and orders
+// the lines, so concurrent checkouts lock warehouse rows in the same order.
+func mergeItems(items []*model.ReservationItemReq) []*model.ReservationItemReq {
+	merged := make([]*model.ReservationItemReq, 0, len(items))
+	byKey := make(map[string]*model.ReservationItemReq, len(items))
+
+	for _, item := range items {
+		key := itemKey(item.ProductId, item.VariantId)
+		if m, ok := byKey[key]; ok {
+			m.Quantity += item.Quantity
+			continue
+		}
+
+		m := &model.ReservationItemReq{ProductId: item.ProductId, VariantId: item.VariantId, Quantity: item.Quantity}
+		byKey[key] = m
+		merged = append(merged, m)
+	}
+
+	slices.SortFunc(merged, func(a, b *model.ReservationItemReq) int {
+		return strings.Compare(itemKey(a.ProductId, a.VariantId), itemKey(b.ProductId, b.VariantId))
+	})
+
+	return merged
+}
+
+func itemKey(productId string, variantId *string) string {
+	if variantId == nil {
+		return productId
+	}
+
+	return productId + ":" + *variantId
+}
+
+// mergeRefundItems folds repeated products and variants into one line.
+func mergeRefundItems(items []*model.RefundItemReq) []*model.RefundItemReq {
+	merged := make([]*model.RefundItemReq, 0, len(items))
+	byKey := make(map[string]*model.RefundItemReq, len(items))
+
+	for _, item := range items {
+		key := itemKey(item.ProductId, item.VariantId)
+		if m, ok := byKey[key]; ok {
+			m.Quantity += item.Quantity
+			continue
+		}
+
+		m := &model.RefundItemReq{ProductId: item.ProductId, VariantId: item.VariantId, Quantity: item.Quantity}
+		byKey[key] = m
+		merged = append(merged, m)
+	}
+
+	return merged
+}
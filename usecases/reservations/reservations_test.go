@@ -0,0 +1,213 @@
+package reservations
+
+import (
+	mock_products "codebase-service/mock/repository/products"
+	model "codebase-service/models"
+	"codebase-service/util/logging"
+	"codebase-service/util/payment"
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestReservationsService(t *testing.T) {
+	suite.Run(t, new(ReservationServiceTestSuite))
+}
+
+type ReservationServiceTestSuite struct {
+	suite.Suite
+	productRepo *mock_products.MockProductRepo
+	payments    *mockProvider
+	service     *svc
+	now         time.Time
+}
+
+type mockProvider struct {
+	mock.Mock
+}
+
+func (m *mockProvider) Refund(ctx context.Context, req *payment.RefundReq) (*payment.RefundResp, error) {
+	args := m.Called(ctx, req)
+	resp, _ := args.Get(0).(*payment.RefundResp)
+	return resp, args.Error(1)
+}
+
+func (s *ReservationServiceTestSuite) SetupTest() {
+	s.productRepo = mock_products.NewMockProductRepo()
+	s.payments = new(mockProvider)
+	s.service = NewReservationSvc(s.productRepo, s.payments, 15*time.Minute, logging.Discard())
+	s.now = time.Date(2024, 11, 16, 10, 0, 0, 0, time.UTC)
+	s.service.now = func() time.Time { return s.now }
+}
+
+func (s *ReservationServiceTestSuite) TestCreateReservation_MergesItemsAndSetsExpiry() {
+	req := &model.CreateReservationReq{
+		Items: []*model.ReservationItemReq{
+			{ProductId: "b", Quantity: 1},
+			{ProductId: "a", Quantity: 2},
+			{ProductId: "b", Quantity: 3},
+		},
+	}
+	res := new(model.Reservation)
+
+	s.productRepo.On("CreateReservation", mock.Anything, mock.MatchedBy(func(r *model.CreateReservationReq) bool {
+		return len(r.Items) == 2 &&
+			r.Items[0].ProductId == "a" && r.Items[0].Quantity == 2 &&
+			r.Items[1].ProductId == "b" && r.Items[1].Quantity == 4 &&
+			r.ExpiresAt.Equal(s.now.Add(15*time.Minute))
+	})).Return(res, nil)
+
+	resp, err := s.service.CreateReservation(context.Background(), req)
+
+	s.NoError(err)
+	s.NotNil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ReservationServiceTestSuite) TestConfirmReservation_NotService() {
+	req := &model.ReservationReq{Role: "buyer"}
+
+	resp, err := s.service.ConfirmReservation(context.Background(), req)
+
+	s.ErrorIs(err, ErrNotService)
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ReservationServiceTestSuite) TestConfirmReservation_Success() {
+	req := &model.ReservationReq{Role: "service"}
+	res := new(model.Reservation)
+
+	s.productRepo.On("ConfirmReservation", mock.Anything, req).Return(res, nil)
+
+	resp, err := s.service.ConfirmReservation(context.Background(), req)
+
+	s.NoError(err)
+	s.NotNil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ReservationServiceTestSuite) TestConfirmReservation_Failed() {
+	req := &model.ReservationReq{Role: "service"}
+
+	s.productRepo.On("ConfirmReservation", mock.Anything, req).Return(nil, sql.ErrConnDone)
+
+	resp, err := s.service.ConfirmReservation(context.Background(), req)
+
+	s.Error(err)
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ReservationServiceTestSuite) TestReleaseExpired_DrainsBatches() {
+	s.productRepo.On("ReleaseExpiredReservations", mock.Anything, sweepBatchSize).Return(sweepBatchSize, nil).Once()
+	s.productRepo.On("ReleaseExpiredReservations", mock.Anything, sweepBatchSize).Return(7, nil).Once()
+
+	released, err := s.service.ReleaseExpired(context.Background())
+
+	s.NoError(err)
+	s.Equal(sweepBatchSize+7, released)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ReservationServiceTestSuite) pendingRefund() *model.Refund {
+	return &model.Refund{
+		Id:      "refund-1",
+		OrderId: "order-1",
+		Status:  model.RefundStatusPending,
+		Amount:  150000,
+		Reason:  "damaged",
+	}
+}
+
+func (s *ReservationServiceTestSuite) TestRefundReservation_Seller() {
+	variantId := "v1"
+	req := &model.CreateRefundReq{
+		UserId: "seller-1",
+		Role:   "seller",
+		Reason: "damaged",
+		Items: []*model.RefundItemReq{
+			{ProductId: "p1", VariantId: &variantId, Quantity: 1},
+			{ProductId: "p1", VariantId: &variantId, Quantity: 2},
+		},
+	}
+	reference := "gw-1"
+	res := &model.Refund{Id: "refund-1", Status: model.RefundStatusSucceeded, ProviderReference: &reference}
+
+	s.productRepo.On("CreateRefund", mock.Anything, mock.MatchedBy(func(r *model.CreateRefundReq) bool {
+		return r.SellerId == "seller-1" && len(r.Items) == 1 && r.Items[0].Quantity == 3
+	})).Return(s.pendingRefund(), nil)
+	s.payments.On("Refund", mock.Anything, &payment.RefundReq{
+		OrderId:   "order-1",
+		RefundKey: "refund-1",
+		Amount:    150000,
+		Reason:    "damaged",
+	}).Return(&payment.RefundResp{Reference: reference}, nil)
+	s.productRepo.On("CompleteRefund", mock.Anything, &model.CompleteRefundReq{
+		Id:                "refund-1",
+		UserId:            "seller-1",
+		Status:            model.RefundStatusSucceeded,
+		ProviderReference: &reference,
+	}).Return(res, nil)
+
+	resp, err := s.service.RefundReservation(context.Background(), req)
+
+	s.NoError(err)
+	s.Equal(res, resp)
+	s.productRepo.AssertExpectations(s.T())
+	s.payments.AssertExpectations(s.T())
+}
+
+func (s *ReservationServiceTestSuite) TestRefundReservation_AdminRefundsAnyShop() {
+	req := &model.CreateRefundReq{UserId: "admin-1", Role: "Admin", Reason: "damaged"}
+	res := &model.Refund{Id: "refund-1", Status: model.RefundStatusSucceeded}
+
+	s.productRepo.On("CreateRefund", mock.Anything, mock.MatchedBy(func(r *model.CreateRefundReq) bool {
+		return r.SellerId == ""
+	})).Return(s.pendingRefund(), nil)
+	s.payments.On("Refund", mock.Anything, mock.Anything).Return(&payment.RefundResp{Reference: "gw-1"}, nil)
+	s.productRepo.On("CompleteRefund", mock.Anything, mock.Anything).Return(res, nil)
+
+	resp, err := s.service.RefundReservation(context.Background(), req)
+
+	s.NoError(err)
+	s.Equal(res, resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ReservationServiceTestSuite) TestRefundReservation_ProviderRefused() {
+	req := &model.CreateRefundReq{UserId: "seller-1", Reason: "damaged"}
+	res := s.pendingRefund()
+	res.Status = model.RefundStatusFailed
+
+	s.productRepo.On("CreateRefund", mock.Anything, req).Return(s.pendingRefund(), nil)
+	s.payments.On("Refund", mock.Anything, mock.Anything).Return(nil, errors.New("gateway refused the refund: expired"))
+	s.productRepo.On("CompleteRefund", mock.Anything, mock.MatchedBy(func(r *model.CompleteRefundReq) bool {
+		return r.Status == model.RefundStatusFailed && r.ProviderReference == nil &&
+			r.FailureReason != nil && *r.FailureReason == "gateway refused the refund: expired"
+	})).Return(res, nil)
+
+	resp, err := s.service.RefundReservation(context.Background(), req)
+
+	s.ErrorIs(err, ErrRefundFailed)
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+}
+
+func (s *ReservationServiceTestSuite) TestRefundReservation_Rejected() {
+	req := &model.CreateRefundReq{UserId: "seller-1", Reason: "damaged"}
+
+	s.productRepo.On("CreateRefund", mock.Anything, req).Return(nil, sql.ErrConnDone)
+
+	resp, err := s.service.RefundReservation(context.Background(), req)
+
+	s.Error(err)
+	s.Nil(resp)
+	s.productRepo.AssertExpectations(s.T())
+	s.payments.AssertNotCalled(s.T(), "Refund", mock.Anything, mock.Anything)
+}
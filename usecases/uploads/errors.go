@@ -0,0 +1,10 @@
+package uploads
+
+import "codebase-service/util/apperror"
+
+// Errors the service reports to its callers.
+var (
+	ErrUnsupportedImage = apperror.New(apperror.Unsupported, "unsupported_image", "unsupported image type")
+	ErrInvalidImage     = apperror.New(apperror.Unprocessable, "invalid_image", "invalid image")
+	ErrImageTooLarge    = apperror.New(apperror.Unprocessable, "image_too_large", "image dimensions are too large")
+)
@@ -0,0 +1,192 @@
+package uploads
+
+import (
+	"bytes"
+	model "codebase-service/models"
+	"codebase-service/util/storage"
+	"context"
+	"fmt"
+	"image"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/google/uuid"
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// maxPixels guards against decompression bombs, small files that decode
+// into huge images.
+const maxPixels = 40_000_000
+
+var allowedImageTypes = map[string]string{
+	"image/jpeg": "jpg",
+	"image/png":  "png",
+	"image/gif":  "gif",
+	"image/webp": "webp",
+}
+
+// thumbnailSizes are the bounding boxes thumbnails are fitted into.
+var thumbnailSizes = []struct {
+	name string
+	size int
+}{
+	{name: "small", size: 150},
+	{name: "medium", size: 480},
+}
+
+var _ UploadSvc = &svc{}
+
+type svc struct {
+	store  storage.BlobStore
+	now    func() time.Time
+	logger *slog.Logger
+}
+
+func NewUploadSvc(store storage.BlobStore, logger *slog.Logger) *svc {
+	return &svc{
+		store:  store,
+		now:    time.Now,
+		logger: logger.With("component", "svc.uploads"),
+	}
+}
+
+type UploadSvc interface {
+	UploadImage(ctx context.Context, req *model.UploadImageReq) (*model.UploadImageResp, error)
+}
+
+func (s *svc) UploadImage(ctx context.Context, req *model.UploadImageReq) (*model.UploadImageResp, error) {
+	// the declared content type comes from the client, sniff the bytes instead
+	contentType := http.DetectContentType(req.Data)
+	ext, ok := allowedImageTypes[contentType]
+	if !ok {
+		return nil, ErrUnsupportedImage
+	}
+
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(req.Data))
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to decode image config", "method", "UploadImage", "err", err)
+		return nil, ErrInvalidImage
+	}
+
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(req.Data))
+	if err != nil {
+		s.logger.ErrorContext(ctx, "failed to decode image", "method", "UploadImage", "err", err)
+		return nil, ErrInvalidImage
+	}
+
+	var (
+		prefix = fmt.Sprintf("images/%s/%s", s.now().UTC().Format("2006/01"), uuid.New())
+		keys   = make([]string, 0, len(thumbnailSizes)+1)
+		res    = &model.UploadImageResp{
+			ContentType: contentType,
+			Size:        int64(len(req.Data)),
+			Width:       cfg.Width,
+			Height:      cfg.Height,
+			Thumbnails:  make([]*model.Thumbnail, 0, len(thumbnailSizes)),
+		}
+	)
+
+	put := func(key string, data []byte, contentType string) (string, error) {
+		url, err := s.store.Put(ctx, key, bytes.NewReader(data), int64(len(data)), contentType)
+		if err != nil {
+			s.logger.ErrorContext(ctx, "failed to store image", "method", "UploadImage", "key", key, "err", err)
+			return "", err
+		}
+		keys = append(keys, key)
+		return url, nil
+	}
+
+	res.Url, err = put(fmt.Sprintf("%s/original.%s", prefix, ext), req.Data, contentType)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, t := range thumbnailSizes {
+		thumb := resize(img, t.size)
+
+		data, thumbType, thumbExt, err := encodeThumbnail(thumb, contentType)
+		if err != nil {
+			s.cleanup(ctx, keys)
+			s.logger.ErrorContext(ctx, "failed to encode thumbnail", "method", "UploadImage", "err", err)
+			return nil, err
+		}
+
+		url, err := put(fmt.Sprintf("%s/%s.%s", prefix, t.name, thumbExt), data, thumbType)
+		if err != nil {
+			s.cleanup(ctx, keys)
+			return nil, err
+		}
+
+		res.Thumbnails = append(res.Thumbnails, &model.Thumbnail{
+			Name:   t.name,
+			Url:    url,
+			Width:  thumb.Bounds().Dx(),
+			Height: thumb.Bounds().Dy(),
+		})
+	}
+
+	return res, nil
+}
+
+func (s *svc) cleanup(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			s.logger.ErrorContext(ctx, "failed to delete image", "method", "cleanup", "key", key, "err", err)
+		}
+	}
+}
+
+// resize fits img into a size x size box keeping its aspect ratio. Images
+// already smaller than the box are not scaled up.
+func resize(img image.Image, size int) image.Image {
+	var (
+		bounds = img.Bounds()
+		width  = bounds.Dx()
+		height = bounds.Dy()
+	)
+
+	if width <= size && height <= size {
+		return img
+	}
+
+	if width >= height {
+		height = max(1, height*size/width)
+		width = size
+	} else {
+		width = max(1, width*size/height)
+		height = size
+	}
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+
+	return dst
+}
+
+// encodeThumbnail keeps transparency for formats that may carry it and uses
+// JPEG for everything else.
+func encodeThumbnail(img image.Image, sourceType string) ([]byte, string, string, error) {
+	var buf bytes.Buffer
+
+	switch sourceType {
+	case "image/png", "image/gif":
+		if err := png.Encode(&buf, img); err != nil {
+			return nil, "", "", err
+		}
+		return buf.Bytes(), "image/png", "png", nil
+	default:
+		if err := jpeg.Encode(&buf, img, &jpeg.Options{Quality: 85}); err != nil {
+			return nil, "", "", err
+		}
+		return buf.Bytes(), "image/jpeg", "jpg", nil
+	}
+}
@@ -0,0 +1,106 @@
+package uploads
+
+import (
+	"bytes"
+	model "codebase-service/models"
+	"codebase-service/util/logging"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+type memoryStore struct {
+	objects map[string][]byte
+	failOn  string
+}
+
+func (m *memoryStore) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (string, error) {
+	if m.failOn != "" && bytes.Contains([]byte(key), []byte(m.failOn)) {
+		return "", fmt.Errorf("storage unavailable")
+	}
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	m.objects[key] = data
+	return "http://files/" + key, nil
+}
+
+func (m *memoryStore) Delete(ctx context.Context, key string) error {
+	delete(m.objects, key)
+	return nil
+}
+
+func TestUploadsService(t *testing.T) {
+	suite.Run(t, new(UploadServiceTestSuite))
+}
+
+type UploadServiceTestSuite struct {
+	suite.Suite
+	store   *memoryStore
+	service *svc
+}
+
+func (s *UploadServiceTestSuite) SetupTest() {
+	s.store = &memoryStore{objects: map[string][]byte{}}
+	s.service = NewUploadSvc(s.store, logging.Discard())
+	s.service.now = func() time.Time { return time.Date(2024, 11, 18, 10, 0, 0, 0, time.UTC) }
+}
+
+func pngImage(width, height int) []byte {
+	var buf bytes.Buffer
+	_ = png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, width, height)))
+	return buf.Bytes()
+}
+
+func (s *UploadServiceTestSuite) TestUploadImage_GeneratesThumbnails() {
+	res, err := s.service.UploadImage(context.Background(), &model.UploadImageReq{UserId: "user-1", Filename: "a.png", Data: pngImage(1000, 500)})
+
+	s.Require().NoError(err)
+	s.Equal("image/png", res.ContentType)
+	s.Equal(1000, res.Width)
+	s.Regexp(`^http://files/images/2024/11/[0-9a-f-]+/original\.png$`, res.Url)
+	s.Require().Len(res.Thumbnails, 2)
+	s.Equal(150, res.Thumbnails[0].Width)
+	s.Equal(75, res.Thumbnails[0].Height)
+	s.Equal(480, res.Thumbnails[1].Width)
+	s.Len(s.store.objects, 3)
+}
+
+func (s *UploadServiceTestSuite) TestUploadImage_DoesNotUpscale() {
+	res, err := s.service.UploadImage(context.Background(), &model.UploadImageReq{UserId: "user-1", Data: pngImage(100, 80)})
+
+	s.Require().NoError(err)
+	s.Equal(100, res.Thumbnails[1].Width)
+	s.Equal(80, res.Thumbnails[1].Height)
+}
+
+func (s *UploadServiceTestSuite) TestUploadImage_UnsupportedType() {
+	_, err := s.service.UploadImage(context.Background(), &model.UploadImageReq{UserId: "user-1", Data: []byte("%PDF-1.4 not an image")})
+
+	s.EqualError(err, "unsupported image type")
+	s.Empty(s.store.objects)
+}
+
+func (s *UploadServiceTestSuite) TestUploadImage_CorruptImage() {
+	data := pngImage(10, 10)
+
+	_, err := s.service.UploadImage(context.Background(), &model.UploadImageReq{UserId: "user-1", Data: data[:len(data)/2]})
+
+	s.EqualError(err, "invalid image")
+}
+
+func (s *UploadServiceTestSuite) TestUploadImage_CleansUpOnFailure() {
+	s.store.failOn = "medium"
+
+	_, err := s.service.UploadImage(context.Background(), &model.UploadImageReq{UserId: "user-1", Data: pngImage(600, 600)})
+
+	s.Error(err)
+	s.Empty(s.store.objects)
+}
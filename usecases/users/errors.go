@@ -0,0 +1,11 @@
+package users
+
+import "codebase-service/util/apperror"
+
+// Errors the service reports to its callers. A login with an unknown
+// username fails with the same error as a wrong password so the response
+// does not tell which usernames exist.
+var (
+	ErrUserExists         = apperror.New(apperror.Conflict, "user_exists", "user already exists")
+	ErrInvalidCredentials = apperror.New(apperror.Unauthorized, "invalid_credentials", "invalid username or password")
+)
@@ -4,7 +4,8 @@ import (
 	model "codebase-service/models"
 	"codebase-service/repository/users"
 	"codebase-service/util/middleware"
-	"errors"
+	"context"
+	"log/slog"
 	"time"
 
 	"github.com/google/uuid"
@@ -12,27 +13,29 @@ import (
 
 type svc struct {
 	userStore users.UserRepository
+	logger    *slog.Logger
 }
 
-func NewUserSvc(userStore users.UserRepository) *svc {
+func NewUserSvc(userStore users.UserRepository, logger *slog.Logger) *svc {
 	return &svc{
 		userStore: userStore,
+		logger:    logger.With("component", "svc.users"),
 	}
 }
 
 type UserSvc interface {
-	UserRegister(req model.Users) (*uuid.UUID, error)
-	UserLogin(req model.UserLoginRequest) (*model.UserLogin, error)
+	UserRegister(ctx context.Context, req model.Users) (*uuid.UUID, error)
+	UserLogin(ctx context.Context, req model.UserLoginRequest) (*model.UserLogin, error)
 }
 
-func (s *svc) UserRegister(req model.Users) (*uuid.UUID, error) {
-	user, err := s.userStore.GetUserDetail(req)
+func (s *svc) UserRegister(ctx context.Context, req model.Users) (*uuid.UUID, error) {
+	user, err := s.userStore.GetUserDetail(ctx, req)
 	if err != nil {
 		return nil, err
 	}
 
 	if user.Email == req.Email && user.Username == req.Username {
-		return nil, errors.Join(errors.New("user already exists"))
+		return nil, ErrUserExists
 	}
 
 	salt, err := middleware.GenerateSalt(16)
@@ -47,7 +50,7 @@ func (s *svc) UserRegister(req model.Users) (*uuid.UUID, error) {
 
 	req.Password = isPassword
 
-	userID, err := s.userStore.UserRegister(req)
+	userID, err := s.userStore.UserRegister(ctx, req)
 	if err != nil {
 		return nil, err
 	}
@@ -55,8 +58,8 @@ func (s *svc) UserRegister(req model.Users) (*uuid.UUID, error) {
 	return userID, nil
 }
 
-func (s *svc) UserLogin(req model.UserLoginRequest) (*model.UserLogin, error) {
-	user, err := s.userStore.GetUserDetail(model.Users{
+func (s *svc) UserLogin(ctx context.Context, req model.UserLoginRequest) (*model.UserLogin, error) {
+	user, err := s.userStore.GetUserDetail(ctx, model.Users{
 		Username: req.Username,
 	})
 	if err != nil {
@@ -64,7 +67,7 @@ func (s *svc) UserLogin(req model.UserLoginRequest) (*model.UserLogin, error) {
 	}
 
 	if user.Username != req.Username {
-		return nil, errors.Join(errors.New("user not found"))
+		return nil, ErrInvalidCredentials
 	}
 
 	verifyPassword, err := middleware.VerifyPassword(req.Password, user.Password)
@@ -73,7 +76,7 @@ func (s *svc) UserLogin(req model.UserLoginRequest) (*model.UserLogin, error) {
 	}
 
 	if !verifyPassword {
-		return nil, errors.Join(errors.New("password not match"))
+		return nil, ErrInvalidCredentials
 	}
 
 	tokenExpiry := time.Minute * 20
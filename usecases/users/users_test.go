@@ -0,0 +1,88 @@
+package users
+
+import (
+	mock_users "codebase-service/mock/repository/users"
+	model "codebase-service/models"
+	"codebase-service/util/logging"
+	"codebase-service/util/middleware"
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestUsersService(t *testing.T) {
+	suite.Run(t, new(UserServiceTestSuite))
+}
+
+type UserServiceTestSuite struct {
+	suite.Suite
+	userRepo *mock_users.MockUserRepo
+	service  *svc
+}
+
+func (s *UserServiceTestSuite) SetupTest() {
+	s.userRepo = mock_users.NewMockUserRepo()
+	s.service = NewUserSvc(s.userRepo, logging.Discard())
+}
+
+// user is a stored user whose password is "secret123".
+func (s *UserServiceTestSuite) user() *model.Users {
+	salt, err := middleware.GenerateSalt(16)
+	s.Require().NoError(err)
+	password, err := middleware.HashPassword("secret123", salt)
+	s.Require().NoError(err)
+
+	return &model.Users{
+		Id:       uuid.New(),
+		Email:    "budi@example.com",
+		Username: "budi",
+		Password: password,
+		Role:     "seller",
+	}
+}
+
+func (s *UserServiceTestSuite) TestUserLogin_Success() {
+	s.userRepo.On("GetUserDetail", mock.Anything, model.Users{Username: "budi"}).Return(s.user(), nil)
+
+	resp, err := s.service.UserLogin(context.Background(), model.UserLoginRequest{Username: "budi", Password: "secret123"})
+
+	s.NoError(err)
+	s.NotEmpty(resp.AccessToken)
+	s.Equal("budi", resp.Users.Username)
+	s.userRepo.AssertExpectations(s.T())
+}
+
+func (s *UserServiceTestSuite) TestUserLogin_WrongPassword() {
+	s.userRepo.On("GetUserDetail", mock.Anything, model.Users{Username: "budi"}).Return(s.user(), nil)
+
+	resp, err := s.service.UserLogin(context.Background(), model.UserLoginRequest{Username: "budi", Password: "wrong"})
+
+	s.ErrorIs(err, ErrInvalidCredentials)
+	s.Nil(resp)
+	s.userRepo.AssertExpectations(s.T())
+}
+
+func (s *UserServiceTestSuite) TestUserLogin_UnknownUser() {
+	// the store finds no row and returns an empty user
+	s.userRepo.On("GetUserDetail", mock.Anything, model.Users{Username: "nobody"}).Return(&model.Users{}, nil)
+
+	resp, err := s.service.UserLogin(context.Background(), model.UserLoginRequest{Username: "nobody", Password: "secret123"})
+
+	s.ErrorIs(err, ErrInvalidCredentials)
+	s.Nil(resp)
+	s.userRepo.AssertExpectations(s.T())
+}
+
+func (s *UserServiceTestSuite) TestUserRegister_Exists() {
+	req := model.Users{Email: "budi@example.com", Username: "budi", Password: "secret123"}
+	s.userRepo.On("GetUserDetail", mock.Anything, req).Return(s.user(), nil)
+
+	resp, err := s.service.UserRegister(context.Background(), req)
+
+	s.ErrorIs(err, ErrUserExists)
+	s.Nil(resp)
+	s.userRepo.AssertNotCalled(s.T(), "UserRegister", mock.Anything, mock.Anything)
+}
@@ -0,0 +1,13 @@
+package vouchers
+
+import "codebase-service/util/apperror"
+
+// Errors the service reports to its callers.
+var (
+	ErrProductNotFound      = apperror.New(apperror.NotFound, "product_not_found", "no product found")
+	ErrNotAdmin             = apperror.New(apperror.Forbidden, "not_admin", "user is not admin")
+	ErrDiscountTooHigh      = apperror.New(apperror.Unprocessable, "discount_too_high", "percentage discount cannot exceed 100")
+	ErrVoucherNotActive     = apperror.New(apperror.Unprocessable, "voucher_not_active", "voucher is not active")
+	ErrVoucherNotApplicable = apperror.New(apperror.Unprocessable, "voucher_not_applicable", "voucher is not applicable to the items")
+	ErrMinimumSpend         = apperror.New(apperror.Unprocessable, "minimum_spend", "minimum spend not reached")
+)
@@ -0,0 +1,210 @@
+package vouchers
+
+import (
+	model "codebase-service/models"
+	"codebase-service/repository/vouchers"
+	"context"
+	"log/slog"
+	"math"
+	"slices"
+	"strings"
+	"time"
+)
+
+const roleAdmin = "admin"
+
+var _ VoucherSvc = &svc{}
+
+type svc struct {
+	store  vouchers.VoucherRepository
+	now    func() time.Time
+	logger *slog.Logger
+}
+
+func NewVoucherSvc(store vouchers.VoucherRepository, logger *slog.Logger) *svc {
+	return &svc{
+		store:  store,
+		now:    time.Now,
+		logger: logger.With("component", "svc.vouchers"),
+	}
+}
+
+type VoucherSvc interface {
+	CreateVoucher(ctx context.Context, req *model.CreateVoucherReq) (*model.Voucher, error)
+	ApplyVoucher(ctx context.Context, req *model.ApplyVoucherReq) (*model.ApplyVoucherResp, error)
+	RedeemVoucher(ctx context.Context, req *model.RedeemVoucherReq) (*model.RedeemVoucherResp, error)
+}
+
+func (s *svc) CreateVoucher(ctx context.Context, req *model.CreateVoucherReq) (*model.Voucher, error) {
+	req.Code = normalizeCode(req.Code)
+
+	if req.DiscountType == model.VoucherTypePercentage && req.DiscountValue > 100 {
+		return nil, ErrDiscountTooHigh
+	}
+
+	// platform vouchers are funded by the marketplace, only admins may issue them
+	if req.ShopId == nil {
+		if !strings.EqualFold(req.Role, roleAdmin) {
+			return nil, ErrNotAdmin
+		}
+	} else {
+		if err := s.store.IsShopOwner(ctx, req.UserId, *req.ShopId); err != nil {
+			return nil, err
+		}
+	}
+
+	if req.CategoryIds == nil {
+		req.CategoryIds = []string{}
+	}
+
+	if req.ProductIds == nil {
+		req.ProductIds = []string{}
+	}
+
+	res, err := s.store.CreateVoucher(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+func (s *svc) ApplyVoucher(ctx context.Context, req *model.ApplyVoucherReq) (*model.ApplyVoucherResp, error) {
+	voucher, err := s.store.GetVoucherByCode(ctx, normalizeCode(req.Code))
+	if err != nil {
+		return nil, err
+	}
+
+	if voucher.UsageLimit != nil && voucher.UsedCount >= *voucher.UsageLimit {
+		return nil, vouchers.ErrUsageLimitReached
+	}
+
+	if voucher.UsageLimitPerUser != nil {
+		count, err := s.store.CountUserRedemptions(ctx, voucher.Id, req.UserId)
+		if err != nil {
+			return nil, err
+		}
+
+		if count >= *voucher.UsageLimitPerUser {
+			return nil, vouchers.ErrUserUsageLimitReached
+		}
+	}
+
+	subtotal, discount, err := s.calculate(ctx, voucher, req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	return &model.ApplyVoucherResp{
+		VoucherId:        voucher.Id,
+		Code:             voucher.Code,
+		EligibleSubtotal: subtotal,
+		DiscountAmount:   discount,
+	}, nil
+}
+
+func (s *svc) RedeemVoucher(ctx context.Context, req *model.RedeemVoucherReq) (*model.RedeemVoucherResp, error) {
+	voucher, err := s.store.GetVoucherByCode(ctx, normalizeCode(req.Code))
+	if err != nil {
+		return nil, err
+	}
+
+	_, discount, err := s.calculate(ctx, voucher, req.Items)
+	if err != nil {
+		return nil, err
+	}
+
+	res, err := s.store.RedeemVoucher(ctx, voucher.Id, req, discount)
+	if err != nil {
+		return nil, err
+	}
+
+	return res, nil
+}
+
+// calculate returns the subtotal of the items the voucher applies to and the
+// discount granted on it.
+func (s *svc) calculate(ctx context.Context, voucher *model.Voucher, items []*model.VoucherItemReq) (float64, float64, error) {
+	now := s.now()
+	if now.Before(voucher.StartsAt) || !now.Before(voucher.EndsAt) {
+		return 0, 0, ErrVoucherNotActive
+	}
+
+	products, err := s.store.GetVoucherProducts(ctx, items)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	productByKey := make(map[string]*model.VoucherProduct, len(products))
+	for _, p := range products {
+		productByKey[itemKey(p.Id, p.VariantId)] = p
+	}
+
+	var subtotal float64
+	for _, item := range items {
+		p, ok := productByKey[itemKey(item.ProductId, item.VariantId)]
+		if !ok {
+			return 0, 0, ErrProductNotFound
+		}
+
+		if !inScope(voucher, p) {
+			continue
+		}
+
+		subtotal += p.Price * float64(item.Quantity)
+	}
+
+	if subtotal == 0 {
+		return 0, 0, ErrVoucherNotApplicable
+	}
+
+	if subtotal < voucher.MinSpend {
+		return 0, 0, ErrMinimumSpend
+	}
+
+	var discount float64
+	switch voucher.DiscountType {
+	case model.VoucherTypePercentage:
+		discount = subtotal * voucher.DiscountValue / 100
+	case model.VoucherTypeFixed:
+		discount = voucher.DiscountValue
+	}
+
+	if voucher.MaxDiscount != nil && discount > *voucher.MaxDiscount {
+		discount = *voucher.MaxDiscount
+	}
+
+	if discount > subtotal {
+		discount = subtotal
+	}
+
+	return subtotal, math.Round(discount*100) / 100, nil
+}
+
+func inScope(voucher *model.Voucher, p *model.VoucherProduct) bool {
+	if voucher.ShopId != nil && *voucher.ShopId != p.ShopId {
+		return false
+	}
+
+	if len(voucher.CategoryIds) > 0 && !slices.Contains(voucher.CategoryIds, p.CategoryId) {
+		return false
+	}
+
+	if len(voucher.ProductIds) > 0 && !slices.Contains(voucher.ProductIds, p.Id) {
+		return false
+	}
+
+	return true
+}
+
+func itemKey(productId string, variantId *string) string {
+	if variantId == nil {
+		return productId
+	}
+
+	return productId + ":" + *variantId
+}
+
+func normalizeCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
@@ -0,0 +1,219 @@
+package vouchers
+
+import (
+	mock_vouchers "codebase-service/mock/repository/vouchers"
+	model "codebase-service/models"
+	"codebase-service/util/logging"
+	"context"
+	"database/sql"
+	"github.com/stretchr/testify/mock"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestVouchersService(t *testing.T) {
+	suite.Run(t, new(VoucherServiceTestSuite))
+}
+
+type VoucherServiceTestSuite struct {
+	suite.Suite
+	voucherRepo *mock_vouchers.MockVoucherRepo
+	service     *svc
+	now         time.Time
+}
+
+func (s *VoucherServiceTestSuite) SetupTest() {
+	s.voucherRepo = mock_vouchers.NewMockVoucherRepo()
+	s.service = NewVoucherSvc(s.voucherRepo, logging.Discard())
+	s.now = time.Date(2024, 11, 13, 10, 0, 0, 0, time.UTC)
+	s.service.now = func() time.Time { return s.now }
+}
+
+func (s *VoucherServiceTestSuite) activeVoucher() *model.Voucher {
+	return &model.Voucher{
+		Id:            "voucher-1",
+		Code:          "HEMAT10",
+		DiscountType:  model.VoucherTypePercentage,
+		DiscountValue: 10,
+		StartsAt:      s.now.Add(-time.Hour),
+		EndsAt:        s.now.Add(time.Hour),
+	}
+}
+
+func (s *VoucherServiceTestSuite) TestCreateVoucher_PlatformRequiresAdmin() {
+	req := &model.CreateVoucherReq{Code: "promo", Role: "Buyer"}
+
+	resp, err := s.service.CreateVoucher(context.Background(), req)
+
+	s.EqualError(err, "user is not admin")
+	s.Nil(resp)
+	s.voucherRepo.AssertExpectations(s.T())
+}
+
+func (s *VoucherServiceTestSuite) TestCreateVoucher_ShopOwner() {
+	shopId := "shop-1"
+	req := &model.CreateVoucherReq{UserId: "user-1", ShopId: &shopId, Code: " promo "}
+	res := new(model.Voucher)
+
+	s.voucherRepo.On("IsShopOwner", mock.Anything, "user-1", shopId).Return(nil)
+	s.voucherRepo.On("CreateVoucher", mock.Anything, req).Return(res, nil)
+
+	resp, err := s.service.CreateVoucher(context.Background(), req)
+
+	s.NoError(err)
+	s.Equal(res, resp)
+	s.Equal("PROMO", req.Code)
+	s.voucherRepo.AssertExpectations(s.T())
+}
+
+func (s *VoucherServiceTestSuite) TestApplyVoucher_PercentageCappedByMaxDiscount() {
+	voucher := s.activeVoucher()
+	maxDiscount := 15.0
+	voucher.MaxDiscount = &maxDiscount
+	req := &model.ApplyVoucherReq{
+		UserId: "user-1",
+		Code:   "hemat10",
+		Items:  []*model.VoucherItemReq{{ProductId: "product-1", Quantity: 2}},
+	}
+
+	s.voucherRepo.On("GetVoucherByCode", mock.Anything, "HEMAT10").Return(voucher, nil)
+	s.voucherRepo.On("GetVoucherProducts", mock.Anything, req.Items).Return([]*model.VoucherProduct{
+		{Id: "product-1", ShopId: "shop-1", CategoryId: "category-1", Price: 100},
+	}, nil)
+
+	resp, err := s.service.ApplyVoucher(context.Background(), req)
+
+	s.NoError(err)
+	s.Equal(200.0, resp.EligibleSubtotal)
+	s.Equal(15.0, resp.DiscountAmount)
+	s.voucherRepo.AssertExpectations(s.T())
+}
+
+func (s *VoucherServiceTestSuite) TestApplyVoucher_OnlyScopedItemsCount() {
+	voucher := s.activeVoucher()
+	voucher.DiscountType = model.VoucherTypeFixed
+	voucher.DiscountValue = 20
+	voucher.MinSpend = 150
+	voucher.CategoryIds = []string{"category-1"}
+	req := &model.ApplyVoucherReq{
+		UserId: "user-1",
+		Code:   "HEMAT10",
+		Items: []*model.VoucherItemReq{
+			{ProductId: "product-1", Quantity: 1},
+			{ProductId: "product-2", Quantity: 1},
+		},
+	}
+
+	s.voucherRepo.On("GetVoucherByCode", mock.Anything, "HEMAT10").Return(voucher, nil)
+	s.voucherRepo.On("GetVoucherProducts", mock.Anything, req.Items).Return([]*model.VoucherProduct{
+		{Id: "product-1", ShopId: "shop-1", CategoryId: "category-1", Price: 100},
+		{Id: "product-2", ShopId: "shop-1", CategoryId: "category-2", Price: 100},
+	}, nil)
+
+	resp, err := s.service.ApplyVoucher(context.Background(), req)
+
+	s.EqualError(err, "minimum spend not reached")
+	s.Nil(resp)
+	s.voucherRepo.AssertExpectations(s.T())
+}
+
+func (s *VoucherServiceTestSuite) TestApplyVoucher_VariantPrices() {
+	voucher := s.activeVoucher()
+	variantId := "variant-1"
+	req := &model.ApplyVoucherReq{
+		UserId: "user-1",
+		Code:   "HEMAT10",
+		Items: []*model.VoucherItemReq{
+			{ProductId: "product-1", Quantity: 1},
+			{ProductId: "product-1", VariantId: &variantId, Quantity: 2},
+		},
+	}
+
+	// the store prices each line, a sale on product-1 and the variant's own price
+	s.voucherRepo.On("GetVoucherByCode", mock.Anything, "HEMAT10").Return(voucher, nil)
+	s.voucherRepo.On("GetVoucherProducts", mock.Anything, req.Items).Return([]*model.VoucherProduct{
+		{Id: "product-1", ShopId: "shop-1", CategoryId: "category-1", Price: 80},
+		{Id: "product-1", VariantId: &variantId, ShopId: "shop-1", CategoryId: "category-1", Price: 150},
+	}, nil)
+
+	resp, err := s.service.ApplyVoucher(context.Background(), req)
+
+	s.NoError(err)
+	s.Equal(380.0, resp.EligibleSubtotal)
+	s.Equal(38.0, resp.DiscountAmount)
+	s.voucherRepo.AssertExpectations(s.T())
+}
+
+func (s *VoucherServiceTestSuite) TestApplyVoucher_VariantNotFound() {
+	voucher := s.activeVoucher()
+	variantId := "variant-1"
+	req := &model.ApplyVoucherReq{
+		UserId: "user-1",
+		Code:   "HEMAT10",
+		Items:  []*model.VoucherItemReq{{ProductId: "product-1", VariantId: &variantId, Quantity: 1}},
+	}
+
+	s.voucherRepo.On("GetVoucherByCode", mock.Anything, "HEMAT10").Return(voucher, nil)
+	s.voucherRepo.On("GetVoucherProducts", mock.Anything, req.Items).Return([]*model.VoucherProduct{}, nil)
+
+	resp, err := s.service.ApplyVoucher(context.Background(), req)
+
+	s.ErrorIs(err, ErrProductNotFound)
+	s.Nil(resp)
+	s.voucherRepo.AssertExpectations(s.T())
+}
+
+func (s *VoucherServiceTestSuite) TestApplyVoucher_Expired() {
+	voucher := s.activeVoucher()
+	voucher.EndsAt = s.now
+	req := &model.ApplyVoucherReq{
+		UserId: "user-1",
+		Code:   "HEMAT10",
+		Items:  []*model.VoucherItemReq{{ProductId: "product-1", Quantity: 1}},
+	}
+
+	s.voucherRepo.On("GetVoucherByCode", mock.Anything, "HEMAT10").Return(voucher, nil)
+
+	resp, err := s.service.ApplyVoucher(context.Background(), req)
+
+	s.EqualError(err, "voucher is not active")
+	s.Nil(resp)
+	s.voucherRepo.AssertExpectations(s.T())
+}
+
+func (s *VoucherServiceTestSuite) TestRedeemVoucher_Success() {
+	voucher := s.activeVoucher()
+	req := &model.RedeemVoucherReq{
+		UserId:  "user-1",
+		OrderId: "order-1",
+		Code:    "HEMAT10",
+		Items:   []*model.VoucherItemReq{{ProductId: "product-1", Quantity: 1}},
+	}
+	res := new(model.RedeemVoucherResp)
+
+	s.voucherRepo.On("GetVoucherByCode", mock.Anything, "HEMAT10").Return(voucher, nil)
+	s.voucherRepo.On("GetVoucherProducts", mock.Anything, req.Items).Return([]*model.VoucherProduct{
+		{Id: "product-1", ShopId: "shop-1", CategoryId: "category-1", Price: 50},
+	}, nil)
+	s.voucherRepo.On("RedeemVoucher", mock.Anything, "voucher-1", req, 5.0).Return(res, nil)
+
+	resp, err := s.service.RedeemVoucher(context.Background(), req)
+
+	s.NoError(err)
+	s.NotNil(resp)
+	s.voucherRepo.AssertExpectations(s.T())
+}
+
+func (s *VoucherServiceTestSuite) TestRedeemVoucher_Failed() {
+	req := &model.RedeemVoucherReq{Code: "HEMAT10"}
+
+	s.voucherRepo.On("GetVoucherByCode", mock.Anything, "HEMAT10").Return(nil, sql.ErrConnDone)
+
+	resp, err := s.service.RedeemVoucher(context.Background(), req)
+
+	s.Error(err)
+	s.Nil(resp)
+	s.voucherRepo.AssertExpectations(s.T())
+}
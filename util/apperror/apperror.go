@@ -0,0 +1,85 @@
+// Package apperror holds the errors the service reports to its clients. An
+// Error carries a Kind, which the handlers turn into a status code, a Code
+// that keys its translations, and an English message that is safe to send
+// back. Anything else is an internal error and its message never leaves the
+// service.
+package apperror
+
+import (
+	"errors"
+	"fmt"
+)
+
+type Kind int
+
+const (
+	Internal Kind = iota
+	NotFound
+	Forbidden
+	Conflict
+	Validation
+	Unauthorized
+	Unprocessable
+	Gone
+	Unsupported
+	Unavailable
+	TooLarge
+)
+
+func (k Kind) String() string {
+	switch k {
+	case NotFound:
+		return "not found"
+	case Forbidden:
+		return "forbidden"
+	case Conflict:
+		return "conflict"
+	case Validation:
+		return "validation"
+	case Unauthorized:
+		return "unauthorized"
+	case Unprocessable:
+		return "unprocessable"
+	case Gone:
+		return "gone"
+	case Unsupported:
+		return "unsupported"
+	case Unavailable:
+		return "unavailable"
+	case TooLarge:
+		return "too large"
+	default:
+		return "internal"
+	}
+}
+
+// Error.Args are the values formatted into Message, translations take them
+// in the same order.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Args    []any
+}
+
+func New(kind Kind, code, message string) *Error {
+	return &Error{Kind: kind, Code: code, Message: message}
+}
+
+func Newf(kind Kind, code, format string, args ...any) *Error {
+	return &Error{Kind: kind, Code: code, Message: fmt.Sprintf(format, args...), Args: args}
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// KindOf returns the kind of the first Error in err's chain, or Internal
+// when there is none.
+func KindOf(err error) Kind {
+	var appErr *Error
+	if errors.As(err, &appErr) {
+		return appErr.Kind
+	}
+	return Internal
+}
@@ -0,0 +1,116 @@
+// Package breaker is a consecutive failure circuit breaker. After Threshold
+// failures in a row it opens and rejects calls for Cooldown, then lets a
+// single probe through. A successful probe closes it, a failed one opens it
+// again.
+package breaker
+
+import (
+	"sync"
+	"time"
+)
+
+type State int
+
+const (
+	Closed State = iota
+	Open
+	HalfOpen
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	default:
+		return "closed"
+	}
+}
+
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+
+	// OnStateChange, when set, is called with the lock held on every state
+	// change, it must not call back into the breaker.
+	OnStateChange func(from, to State)
+
+	mu       sync.Mutex
+	state    State
+	failures int
+	openedAt time.Time
+	probing  bool
+	now      func() time.Time
+}
+
+func New(threshold int, cooldown time.Duration) *Breaker {
+	return &Breaker{
+		threshold: max(threshold, 1),
+		cooldown:  cooldown,
+		now:       time.Now,
+	}
+}
+
+// Allow reports whether a call may go ahead. Every allowed call must be
+// followed by Success or Failure.
+func (b *Breaker) Allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case Open:
+		if b.now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.setState(HalfOpen)
+		b.probing = true
+		return true
+	case HalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+func (b *Breaker) Success() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures = 0
+	b.probing = false
+	if b.state != Closed {
+		b.setState(Closed)
+	}
+}
+
+func (b *Breaker) Failure() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.failures++
+	b.probing = false
+	if b.state == HalfOpen || (b.state == Closed && b.failures >= b.threshold) {
+		b.openedAt = b.now()
+		b.setState(Open)
+	}
+}
+
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.state
+}
+
+func (b *Breaker) setState(state State) {
+	from := b.state
+	b.state = state
+	if b.OnStateChange != nil {
+		b.OnStateChange(from, state)
+	}
+}
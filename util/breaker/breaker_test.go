@@ -0,0 +1,83 @@
+package breaker
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestBreaker(t *testing.T) {
+	suite.Run(t, new(BreakerTestSuite))
+}
+
+type BreakerTestSuite struct {
+	suite.Suite
+	breaker *Breaker
+	now     time.Time
+	changes []State
+}
+
+func (s *BreakerTestSuite) SetupTest() {
+	s.now = time.Date(2024, 11, 20, 10, 0, 0, 0, time.UTC)
+	s.changes = nil
+	s.breaker = New(3, 30*time.Second)
+	s.breaker.now = func() time.Time { return s.now }
+	s.breaker.OnStateChange = func(from, to State) { s.changes = append(s.changes, to) }
+}
+
+func (s *BreakerTestSuite) fail(times int) {
+	for range times {
+		s.True(s.breaker.Allow())
+		s.breaker.Failure()
+	}
+}
+
+func (s *BreakerTestSuite) TestOpensAfterThreshold() {
+	s.fail(2)
+	s.Equal(Closed, s.breaker.State())
+
+	s.fail(1)
+	s.Equal(Open, s.breaker.State())
+	s.False(s.breaker.Allow())
+	s.Equal([]State{Open}, s.changes)
+}
+
+func (s *BreakerTestSuite) TestSuccessResetsFailures() {
+	s.fail(2)
+	s.True(s.breaker.Allow())
+	s.breaker.Success()
+	s.fail(2)
+
+	s.Equal(Closed, s.breaker.State())
+}
+
+func (s *BreakerTestSuite) TestProbeAfterCooldown() {
+	s.fail(3)
+
+	s.now = s.now.Add(30 * time.Second)
+	s.True(s.breaker.Allow())
+	s.Equal(HalfOpen, s.breaker.State())
+
+	// only one probe at a time
+	s.False(s.breaker.Allow())
+
+	s.breaker.Success()
+	s.Equal(Closed, s.breaker.State())
+	s.True(s.breaker.Allow())
+	s.Equal([]State{Open, HalfOpen, Closed}, s.changes)
+}
+
+func (s *BreakerTestSuite) TestFailedProbeReopens() {
+	s.fail(3)
+
+	s.now = s.now.Add(30 * time.Second)
+	s.True(s.breaker.Allow())
+	s.breaker.Failure()
+
+	s.Equal(Open, s.breaker.State())
+	s.False(s.breaker.Allow())
+
+	s.now = s.now.Add(29 * time.Second)
+	s.False(s.breaker.Allow())
+}
@@ -0,0 +1,151 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+)
+
+// entries carry a leading byte telling a value from a cached miss
+const (
+	valueEntry    byte = 'v'
+	notFoundEntry byte = 'n'
+)
+
+// Cache holds values of type T in one namespace of a client. Its keys are
+// cache:<namespace>:<key> in redis, or cache:<namespace>:<generation>:<key>
+// for versioned caches, which are flushed by bumping the generation instead
+// of deleting every key.
+type Cache[T any] struct {
+	client    *Client
+	namespace string
+	versioned bool
+}
+
+func New[T any](client *Client, namespace string) *Cache[T] {
+	client.register(namespace, false)
+	return &Cache[T]{client: client, namespace: namespace}
+}
+
+// NewVersioned suits namespaces flushed as a whole on most writes, such as
+// list pages, whose keys cannot be enumerated cheaply.
+func NewVersioned[T any](client *Client, namespace string) *Cache[T] {
+	client.register(namespace, true)
+	return &Cache[T]{client: client, namespace: namespace, versioned: true}
+}
+
+func (c *Cache[T]) Namespace() string {
+	return c.namespace
+}
+
+// TTL is the longest a value of the namespace is cached.
+func (c *Cache[T]) TTL() time.Duration {
+	return c.client.ttl(c.namespace)
+}
+
+// Get returns ErrMiss when key is not cached and ErrNotFound when it is
+// cached as missing.
+func (c *Cache[T]) Get(ctx context.Context, key string) (T, error) {
+	entry, err := c.client.get(ctx, c.namespace, c.versioned, key)
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return c.decode(entry)
+}
+
+// Set caches value for the namespace TTL, or ttl when it is shorter.
+func (c *Cache[T]) Set(ctx context.Context, key string, value T, ttl time.Duration) error {
+	entry, err := c.encode(value)
+	if err != nil {
+		return err
+	}
+
+	return c.client.set(ctx, c.namespace, c.versioned, key, entry, c.expiration(ttl))
+}
+
+// Load returns the cached value of key, or calls load on a miss and caches
+// its result. A load returning ErrNotFound is cached as a miss for the
+// negative TTL, any other error is returned as is. expiry may shorten the
+// TTL of a loaded value, it is only called when the value gets cached.
+// load and expiry get a ctx that is not cancelled with the caller's, their
+// result is shared with every concurrent caller of the key. A Delete of the
+// key while load runs keeps the result out of the cache.
+func (c *Cache[T]) Load(ctx context.Context, key string, load func(ctx context.Context) (T, error), expiry func(ctx context.Context, value T) time.Duration) (T, error) {
+	entry, err := c.client.load(ctx, c.namespace, c.versioned, key, func(ctx context.Context) ([]byte, func() time.Duration, error) {
+		value, err := load(ctx)
+		if errors.Is(err, ErrNotFound) {
+			return []byte{notFoundEntry}, func() time.Duration { return c.client.cfg.NegativeTTL }, nil
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		entry, err := c.encode(value)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		return entry, func() time.Duration {
+			if expiry == nil {
+				return c.TTL()
+			}
+			return c.expiration(expiry(ctx, value))
+		}, nil
+	})
+	if err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return c.decode(entry)
+}
+
+// Delete drops keys on every instance. A delete that cannot reach redis is
+// replayed once it is back and reported as ErrDeletePending, writes
+// evicting what they changed may ignore it.
+func (c *Cache[T]) Delete(ctx context.Context, keys ...string) error {
+	return c.client.delete(ctx, c.namespace, c.versioned, keys...)
+}
+
+// Flush drops every value of the namespace on every instance. A flush that
+// cannot reach redis is replayed once it is back and reported as
+// ErrFlushPending.
+func (c *Cache[T]) Flush(ctx context.Context) error {
+	return c.client.flush(ctx, c.namespace, c.versioned)
+}
+
+func (c *Cache[T]) expiration(ttl time.Duration) time.Duration {
+	if ttl <= 0 {
+		return c.TTL()
+	}
+
+	return min(ttl, c.TTL())
+}
+
+func (c *Cache[T]) encode(value T) ([]byte, error) {
+	data, err := c.client.cfg.Codec.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+
+	return append([]byte{valueEntry}, data...), nil
+}
+
+func (c *Cache[T]) decode(entry []byte) (T, error) {
+	var value T
+	if len(entry) == 0 {
+		return value, ErrMiss
+	}
+
+	if entry[0] == notFoundEntry {
+		return value, ErrNotFound
+	}
+
+	if err := c.client.cfg.Codec.Unmarshal(entry[1:], &value); err != nil {
+		return value, err
+	}
+
+	return value, nil
+}
@@ -0,0 +1,470 @@
+package cache
+
+import (
+	"context"
+	"database/sql"
+	"expvar"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/stretchr/testify/suite"
+)
+
+func TestCache(t *testing.T) {
+	suite.Run(t, new(CacheTestSuite))
+}
+
+type CacheTestSuite struct {
+	suite.Suite
+	redis  *miniredis.Miniredis
+	cfg    Config
+	client *Client
+	items  *Cache[*codecItem]
+	ctx    context.Context
+}
+
+func (s *CacheTestSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.redis = miniredis.RunT(s.T())
+	s.cfg = Config{
+		TTL:         5 * time.Minute,
+		TTLs:        map[string]time.Duration{"short": time.Minute},
+		NegativeTTL: 30 * time.Second,
+		L1Size:      100,
+		L1TTL:       30 * time.Second,
+	}
+	s.client = s.newClient()
+	s.items = New[*codecItem](s.client, "item")
+}
+
+func (s *CacheTestSuite) newClient() *Client {
+	return NewClient(redis.NewClient(&redis.Options{Addr: s.redis.Addr()}), s.cfg)
+}
+
+// listen starts the invalidation listener of client and waits until it is
+// subscribed.
+func (s *CacheTestSuite) listen(client *Client) {
+	ctx, cancel := context.WithCancel(context.Background())
+	s.T().Cleanup(cancel)
+
+	subscribers := s.redis.PubSubNumSub(invalidationChannel)[invalidationChannel]
+	go client.Listen(ctx)
+	s.Eventually(func() bool {
+		return s.redis.PubSubNumSub(invalidationChannel)[invalidationChannel] > subscribers
+	}, time.Second, 10*time.Millisecond)
+}
+
+func (s *CacheTestSuite) load(cache *Cache[*codecItem], id string, loads *int) (*codecItem, error) {
+	return cache.Load(s.ctx, id, func(context.Context) (*codecItem, error) {
+		*loads++
+		return &codecItem{Id: id}, nil
+	}, nil)
+}
+
+func (s *CacheTestSuite) TestLoad_CachesInBothTiers() {
+	var loads int
+
+	for range 2 {
+		item, err := s.load(s.items, "p1", &loads)
+		s.NoError(err)
+		s.Equal("p1", item.Id)
+	}
+
+	s.Equal(1, loads)
+	s.True(s.redis.Exists("cache:item:p1"))
+	s.Equal(5*time.Minute, s.redis.TTL("cache:item:p1"))
+	s.Equal(1, s.client.l1.len())
+
+	// another instance finds it in redis
+	item, err := New[*codecItem](s.newClient(), "item").Get(s.ctx, "p1")
+	s.NoError(err)
+	s.Equal("p1", item.Id)
+}
+
+func (s *CacheTestSuite) TestLoad_NamespaceTTL() {
+	var loads int
+
+	_, err := s.load(New[*codecItem](s.client, "short"), "p1", &loads)
+
+	s.NoError(err)
+	s.Equal(time.Minute, s.redis.TTL("cache:short:p1"))
+}
+
+func (s *CacheTestSuite) TestLoad_ExpiryShortensTTL() {
+	load := func(context.Context) (*codecItem, error) { return &codecItem{Id: "p1"}, nil }
+
+	_, err := s.items.Load(s.ctx, "p1", load, func(context.Context, *codecItem) time.Duration { return 10 * time.Second })
+	s.NoError(err)
+	s.Equal(10*time.Second, s.redis.TTL("cache:item:p1"))
+
+	// expiry never extends the namespace TTL
+	_, err = s.items.Load(s.ctx, "p2", load, func(context.Context, *codecItem) time.Duration { return time.Hour })
+	s.NoError(err)
+	s.Equal(5*time.Minute, s.redis.TTL("cache:item:p2"))
+}
+
+func (s *CacheTestSuite) TestLoad_NotFoundIsCachedNegatively() {
+	var loads int
+
+	for range 2 {
+		item, err := s.items.Load(s.ctx, "missing", func(context.Context) (*codecItem, error) {
+			loads++
+			return nil, ErrNotFound
+		}, nil)
+		s.ErrorIs(err, ErrNotFound)
+		s.Nil(item)
+	}
+
+	s.Equal(1, loads)
+	s.Equal(30*time.Second, s.redis.TTL("cache:item:missing"))
+
+	_, err := s.items.Get(s.ctx, "missing")
+	s.ErrorIs(err, ErrNotFound)
+}
+
+func (s *CacheTestSuite) TestLoad_ErrorIsNotCached() {
+	_, err := s.items.Load(s.ctx, "p1", func(context.Context) (*codecItem, error) {
+		return nil, sql.ErrConnDone
+	}, nil)
+
+	s.ErrorIs(err, sql.ErrConnDone)
+	s.False(s.redis.Exists("cache:item:p1"))
+	s.False(s.redis.Exists("lock:cache:item:p1"))
+}
+
+func (s *CacheTestSuite) TestLoad_CoalescesConcurrentMisses() {
+	var (
+		loads   atomic.Int32
+		wg      sync.WaitGroup
+		release = make(chan struct{})
+	)
+
+	for range 20 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			item, err := s.items.Load(s.ctx, "p1", func(context.Context) (*codecItem, error) {
+				loads.Add(1)
+				<-release
+				return &codecItem{Id: "p1"}, nil
+			}, nil)
+			s.NoError(err)
+			s.Equal("p1", item.Id)
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	s.Equal(int32(1), loads.Load())
+	s.False(s.redis.Exists("lock:cache:item:p1"))
+}
+
+func (s *CacheTestSuite) TestLoad_CancelledWaiterDoesNotCancelLoad() {
+	ctx, cancel := context.WithCancel(s.ctx)
+	release := make(chan struct{})
+	done := make(chan struct{})
+
+	go func() {
+		defer close(done)
+		item, err := s.items.Load(s.ctx, "p1", func(ctx context.Context) (*codecItem, error) {
+			<-release
+			return &codecItem{Id: "p1"}, ctx.Err()
+		}, nil)
+		s.NoError(err)
+		s.Equal("p1", item.Id)
+	}()
+
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	_, err := s.items.Load(ctx, "p1", func(context.Context) (*codecItem, error) {
+		s.Fail("loaded twice")
+		return nil, nil
+	}, nil)
+	s.ErrorIs(err, context.Canceled)
+
+	close(release)
+	<-done
+}
+
+func (s *CacheTestSuite) TestLoad_WaitsForOtherInstance() {
+	other := New[*codecItem](s.newClient(), "item")
+	s.NoError(s.redis.Set("lock:cache:item:p1", "other"))
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		other.Set(s.ctx, "p1", &codecItem{Id: "p1", Stock: 7}, 0)
+	}()
+
+	item, err := s.items.Load(s.ctx, "p1", func(context.Context) (*codecItem, error) {
+		s.Fail("loaded while another instance holds the lock")
+		return nil, nil
+	}, nil)
+
+	s.NoError(err)
+	s.Equal(7, item.Stock)
+}
+
+func (s *CacheTestSuite) TestLoad_LoadsWhenLockIsNotReleased() {
+	var loads int
+	s.NoError(s.redis.Set("lock:cache:item:p1", "other"))
+
+	item, err := s.load(s.items, "p1", &loads)
+
+	s.NoError(err)
+	s.Equal("p1", item.Id)
+	s.Equal(1, loads)
+	s.Equal("other", s.mustGet("lock:cache:item:p1"))
+}
+
+func (s *CacheTestSuite) TestUnlock_KeepsOtherToken() {
+	s.NoError(s.redis.Set("lock:cache:item:p1", "other"))
+
+	s.client.unlock(s.ctx, "cache:item:p1", "mine")
+
+	s.Equal("other", s.mustGet("lock:cache:item:p1"))
+}
+
+func (s *CacheTestSuite) TestDelete_InvalidatesOtherInstances() {
+	var loads int
+	other := s.newClient()
+	otherItems := New[*codecItem](other, "item")
+	s.listen(other)
+
+	_, err := s.load(otherItems, "p1", &loads)
+	s.NoError(err)
+	s.Equal(1, other.l1.len())
+
+	s.items.Delete(s.ctx, "p1")
+
+	s.False(s.redis.Exists("cache:item:p1"))
+	s.Eventually(func() bool { return other.l1.len() == 0 }, time.Second, 10*time.Millisecond)
+	_, err = otherItems.Get(s.ctx, "p1")
+	s.ErrorIs(err, ErrMiss)
+}
+
+func (s *CacheTestSuite) TestLoad_DeleteDuringLoadIsNotCached() {
+	var loads int
+
+	// a writer commits and deletes the key after the load read the old row
+	item, err := s.items.Load(s.ctx, "p1", func(context.Context) (*codecItem, error) {
+		loads++
+		s.items.Delete(s.ctx, "p1")
+		return &codecItem{Id: "p1", Stock: 5}, nil
+	}, nil)
+
+	s.NoError(err)
+	s.Equal(5, item.Stock)
+	s.False(s.redis.Exists("cache:item:p1"))
+	s.Equal(0, s.client.l1.len())
+	s.Equal("1", s.mustGet("generation:cache:item:p1"))
+
+	item, err = s.items.Load(s.ctx, "p1", func(context.Context) (*codecItem, error) {
+		loads++
+		return &codecItem{Id: "p1", Stock: 3}, nil
+	}, nil)
+
+	s.NoError(err)
+	s.Equal(3, item.Stock)
+	s.Equal(2, loads)
+	s.True(s.redis.Exists("cache:item:p1"))
+}
+
+func (s *CacheTestSuite) TestListen_IgnoresOwnInvalidations() {
+	var loads int
+	s.listen(s.client)
+	received := metricValue("invalidations_received")
+
+	s.items.Delete(s.ctx, "p2")
+	_, err := s.load(s.items, "p1", &loads)
+	s.NoError(err)
+
+	time.Sleep(50 * time.Millisecond)
+	s.Equal(1, s.client.l1.len())
+	s.Equal(received, metricValue("invalidations_received"))
+}
+
+func (s *CacheTestSuite) TestFlush_Versioned() {
+	var loads int
+	pages := NewVersioned[*codecItem](s.client, "pages")
+
+	_, err := s.load(pages, "page:1", &loads)
+	s.NoError(err)
+	s.True(s.redis.Exists("cache:pages:0:page:1"))
+
+	pages.Flush(s.ctx)
+
+	s.Equal("1", s.mustGet("cache:pages:generation"))
+	_, err = pages.Get(s.ctx, "page:1")
+	s.ErrorIs(err, ErrMiss)
+
+	_, err = s.load(pages, "page:1", &loads)
+	s.NoError(err)
+	s.Equal(2, loads)
+	s.True(s.redis.Exists("cache:pages:1:page:1"))
+}
+
+func (s *CacheTestSuite) TestFlush_Plain() {
+	s.NoError(s.items.Set(s.ctx, "p1", &codecItem{Id: "p1"}, 0))
+	s.NoError(s.items.Set(s.ctx, "p2", &codecItem{Id: "p2"}, 0))
+	s.NoError(New[*codecItem](s.client, "items").Set(s.ctx, "p1", &codecItem{Id: "p1"}, 0))
+
+	s.items.Flush(s.ctx)
+
+	s.False(s.redis.Exists("cache:item:p1"))
+	s.False(s.redis.Exists("cache:item:p2"))
+	s.True(s.redis.Exists("cache:items:p1"))
+	s.Equal(1, s.client.l1.len())
+}
+
+func (s *CacheTestSuite) TestSetL1_SkipsValuesReadBeforeInvalidation() {
+	epoch := s.client.epoch("item")
+
+	// a delete lands while the value is read from redis
+	s.client.invalidateL1("item", []string{"p1"}, false)
+	s.client.setL1("item", "p1", []byte("v{}"), time.Minute, epoch)
+
+	s.Zero(s.client.l1.len())
+}
+
+func (s *CacheTestSuite) TestRedisErrorsOpenBreaker() {
+	var loads int
+	s.NoError(s.items.Set(s.ctx, "p1", &codecItem{Id: "p1"}, 0))
+	s.client.l1.clear()
+	s.redis.SetError("LOADING redis is loading")
+	errors := metricValue("errors")
+
+	for range defaultBreakerFailures {
+		_, err := s.items.Get(s.ctx, "p1")
+		s.ErrorIs(err, ErrUnavailable)
+	}
+
+	// redis is back but the breaker keeps calls away until the cooldown
+	s.redis.SetError("")
+	_, err := s.items.Get(s.ctx, "p1")
+	s.ErrorIs(err, ErrUnavailable)
+	s.Equal("open", breakerState.Value())
+	s.Equal(errors+defaultBreakerFailures, metricValue("errors"))
+
+	// values are loaded from their source meanwhile
+	item, err := s.load(s.items, "p1", &loads)
+	s.NoError(err)
+	s.Equal("p1", item.Id)
+	s.Equal(1, loads)
+}
+
+func (s *CacheTestSuite) TestMissIsNotAFailure() {
+	for range defaultBreakerFailures + 1 {
+		_, err := s.items.Get(s.ctx, "missing")
+		s.ErrorIs(err, ErrMiss)
+	}
+
+	s.Equal("closed", s.client.breaker.State().String())
+}
+
+func (s *CacheTestSuite) TestRecover_ReplaysPendingEvictions() {
+	pages := NewVersioned[*codecItem](s.client, "pages")
+	s.NoError(s.items.Set(s.ctx, "p1", &codecItem{Id: "p1"}, 0))
+	s.redis.SetError("LOADING redis is loading")
+
+	s.items.Delete(s.ctx, "p1")
+	pages.Flush(s.ctx)
+
+	s.redis.SetError("")
+	s.client.recover(s.ctx)
+
+	s.False(s.redis.Exists("cache:item:p1"))
+	s.Equal("1", s.mustGet("cache:pages:generation"))
+	s.Zero(s.client.l1.len())
+}
+
+func (s *CacheTestSuite) TestFlush_PendingWhileRedisFails() {
+	s.redis.SetError("LOADING redis is loading")
+
+	s.ErrorIs(s.client.Flush(s.ctx, "item"), ErrFlushPending)
+
+	s.redis.SetError("")
+	s.NoError(s.client.Flush(s.ctx, "item"))
+}
+
+func (s *CacheTestSuite) TestDelete_PendingWhileRedisFails() {
+	s.NoError(s.items.Set(s.ctx, "p1", &codecItem{Id: "p1"}, 0))
+	s.redis.SetError("LOADING redis is loading")
+
+	s.ErrorIs(s.items.Delete(s.ctx, "p1"), ErrDeletePending)
+
+	s.redis.SetError("")
+	s.NoError(s.items.Delete(s.ctx, "p1"))
+	s.False(s.redis.Exists("cache:item:p1"))
+}
+
+func (s *CacheTestSuite) TestWithoutRedis() {
+	var loads int
+	client := NewClient(nil, s.cfg)
+	items := New[*codecItem](client, "item")
+
+	for range 2 {
+		item, err := s.load(items, "p1", &loads)
+		s.NoError(err)
+		s.Equal("p1", item.Id)
+	}
+
+	s.Equal(2, loads)
+	s.False(client.Enabled())
+
+	_, err := items.Get(s.ctx, "p1")
+	s.ErrorIs(err, ErrUnavailable)
+	s.ErrorIs(items.Set(s.ctx, "p1", &codecItem{}, 0), ErrUnavailable)
+
+	items.Delete(s.ctx, "p1")
+	items.Flush(s.ctx)
+	s.Empty(client.pending)
+}
+
+func (s *CacheTestSuite) TestMsgpackCodec() {
+	s.cfg.Codec = Msgpack{}
+	items := New[*codecItem](s.newClient(), "item")
+
+	s.NoError(items.Set(s.ctx, "p1", &codecItem{Id: "p1", Price: 9.5}, 0))
+	item, err := items.Get(s.ctx, "p1")
+
+	s.NoError(err)
+	s.Equal(9.5, item.Price)
+}
+
+func (s *CacheTestSuite) mustGet(key string) string {
+	value, err := s.redis.Get(key)
+	s.NoError(err)
+	return value
+}
+
+func metricValue(name string) int64 {
+	if v, ok := metrics.Get(name).(*expvar.Int); ok {
+		return v.Value()
+	}
+
+	return 0
+}
+
+func (s *CacheTestSuite) TestInspect() {
+	_, err := s.items.Load(s.ctx, "missing", func(context.Context) (*codecItem, error) { return nil, ErrNotFound }, nil)
+	s.ErrorIs(err, ErrNotFound)
+
+	entry, err := s.client.Inspect(s.ctx, "item", "missing")
+	s.NoError(err)
+	s.True(entry.InL1)
+	s.True(entry.InL2)
+	s.True(entry.NotFound)
+	s.Equal(30*time.Second, entry.TTL)
+
+	_, err = s.client.Inspect(s.ctx, "item", "p1")
+	s.ErrorIs(err, ErrMiss)
+
+	_, err = s.client.Inspect(s.ctx, "unknown", "p1")
+	s.ErrorIs(err, ErrUnknownNamespace)
+}
@@ -0,0 +1,791 @@
+// Package cache is a two tier cache, an in-process LRU in front of redis.
+// Values live in namespaces, each with its own TTL, and are encoded with a
+// pluggable codec. Deletes are broadcast over redis pub/sub so every
+// instance drops its in-process copy. Redis errors never fail a caller, a
+// circuit breaker keeps calls away from redis while it is down and values
+// are loaded from their source instead.
+package cache
+
+import (
+	"codebase-service/util/breaker"
+	"context"
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	keyPrefix           = "cache:"
+	invalidationChannel = "cache:invalidate"
+
+	// a loader holds the lock of a key for at most lockTTL. Instances that
+	// miss the lock poll the cache every lockPoll for up to lockWait before
+	// loading the value themselves.
+	lockTTL  = 3 * time.Second
+	lockWait = time.Second
+	lockPoll = 50 * time.Millisecond
+
+	// keyGenerationTTL keeps the generation of a deleted key for longer
+	// than any load of it runs
+	keyGenerationTTL = time.Minute
+
+	// maxPendingEvictions bounds the deletes kept while redis is
+	// unreachable, past it the rest expire with their TTL
+	maxPendingEvictions = 10000
+
+	defaultTTL             = 5 * time.Minute
+	defaultL1TTL           = 30 * time.Second
+	defaultNegativeTTL     = 30 * time.Second
+	defaultBreakerFailures = 5
+	defaultBreakerCooldown = 30 * time.Second
+)
+
+var (
+	// ErrMiss is returned by Get when the key is not cached.
+	ErrMiss = errors.New("cache miss")
+
+	// ErrNotFound is returned for keys cached as missing from their source.
+	// Loaders return it to have the miss cached for the negative TTL.
+	ErrNotFound = errors.New("not found")
+
+	// ErrUnavailable is returned when redis cannot be used, because the
+	// client has none, the breaker is open or the call failed.
+	ErrUnavailable = errors.New("cache unavailable")
+
+	// ErrFlushPending is returned by Flush when redis could not be reached.
+	// The flush is replayed once it is back, until then other instances may
+	// still serve the values.
+	ErrFlushPending = errors.New("cache flush pending")
+
+	// ErrDeletePending is returned by Delete when redis could not be
+	// reached. The delete is replayed once it is back.
+	ErrDeletePending = errors.New("cache delete pending")
+)
+
+// metrics is published on /debug/vars. Per namespace counters are named
+// <namespace>.<counter>, errors and rejected calls are degradation events.
+var (
+	metrics      = expvar.NewMap("cache")
+	breakerState = new(expvar.String)
+)
+
+func init() {
+	breakerState.Set(breaker.Closed.String())
+	metrics.Set("breaker_state", breakerState)
+}
+
+// Config.TTLs overrides TTL per namespace. L1Size is the number of values
+// kept in process, zero turns the in-process tier off. L1TTL caps how long
+// an instance may serve a value after a delete it did not hear about.
+type Config struct {
+	TTL         time.Duration
+	TTLs        map[string]time.Duration
+	NegativeTTL time.Duration
+	L1Size      int
+	L1TTL       time.Duration
+	Codec       Codec
+
+	BreakerFailures int
+	BreakerCooldown time.Duration
+
+	// Logger defaults to slog.Default()
+	Logger *slog.Logger
+}
+
+type Client struct {
+	redis   *redis.Client
+	cfg     Config
+	l1      *lru
+	breaker *breaker.Breaker
+	id      string
+
+	// loads coalesces concurrent misses per key
+	loads singleflight.Group
+
+	mu         sync.Mutex
+	namespaces map[string]bool
+	// epochs change on every invalidation of a namespace, a value read
+	// before one is not put in the in-process tier
+	epochs   map[string]uint64
+	epochAll uint64
+	pending  map[string]*pendingEvictions
+}
+
+// pendingEvictions remembers the deletes that could not reach redis, so
+// values cached before an outage are not served once redis is back.
+type pendingEvictions struct {
+	versioned bool
+	keys      map[string]struct{}
+	flush     bool
+}
+
+type invalidation struct {
+	Origin    string   `json:"origin"`
+	Namespace string   `json:"namespace"`
+	Keys      []string `json:"keys,omitempty"`
+	All       bool     `json:"all,omitempty"`
+}
+
+// NewClient returns a client on rdb. A nil rdb gives a client that caches
+// nothing, every lookup goes to the source.
+func NewClient(rdb *redis.Client, cfg Config) *Client {
+	if cfg.TTL <= 0 {
+		cfg.TTL = defaultTTL
+	}
+
+	if cfg.NegativeTTL <= 0 {
+		cfg.NegativeTTL = defaultNegativeTTL
+	}
+
+	if cfg.L1TTL <= 0 {
+		cfg.L1TTL = defaultL1TTL
+	}
+
+	if cfg.Codec == nil {
+		cfg.Codec = JSON{}
+	}
+
+	if cfg.BreakerFailures <= 0 {
+		cfg.BreakerFailures = defaultBreakerFailures
+	}
+
+	if cfg.BreakerCooldown <= 0 {
+		cfg.BreakerCooldown = defaultBreakerCooldown
+	}
+
+	if cfg.Logger == nil {
+		cfg.Logger = slog.Default()
+	}
+	cfg.Logger = cfg.Logger.With("component", "cache")
+
+	c := &Client{
+		redis:      rdb,
+		cfg:        cfg,
+		id:         uuid.NewString(),
+		namespaces: make(map[string]bool),
+		epochs:     make(map[string]uint64),
+		pending:    make(map[string]*pendingEvictions),
+	}
+
+	// without redis an instance would never hear about deletes made by
+	// the others
+	if rdb != nil && cfg.L1Size > 0 {
+		c.l1 = newLRU(cfg.L1Size)
+	}
+
+	c.breaker = breaker.New(cfg.BreakerFailures, cfg.BreakerCooldown)
+	c.breaker.OnStateChange = func(from, to breaker.State) {
+		breakerState.Set(to.String())
+
+		switch to {
+		case breaker.Open:
+			metrics.Add("breaker_opened", 1)
+			cfg.Logger.Warn("redis unavailable, loading from source", "cooldown", cfg.BreakerCooldown)
+		case breaker.Closed:
+			if from != breaker.Closed {
+				cfg.Logger.Info("redis is back")
+				// the callback runs under the breaker lock, the replay goes
+				// through the breaker again
+				go c.recover(context.Background())
+			}
+		}
+	}
+
+	return c
+}
+
+// Enabled reports whether the client has a redis to cache in.
+func (c *Client) Enabled() bool {
+	return c.redis != nil
+}
+
+// Do runs fn against redis unless the client has none or the breaker is
+// open, and feeds the outcome to the breaker. redis.Nil is a miss, not a
+// failure, and neither is a call cut short because ctx is done. It is also
+// how callers use redis for more than caching.
+func (c *Client) Do(ctx context.Context, fn func(ctx context.Context, rdb *redis.Client) error) error {
+	if c.redis == nil {
+		return ErrUnavailable
+	}
+
+	if !c.breaker.Allow() {
+		metrics.Add("rejected", 1)
+		return ErrUnavailable
+	}
+
+	err := fn(ctx, c.redis)
+	if err != nil && err != redis.Nil {
+		if ctx.Err() != nil {
+			return err
+		}
+
+		metrics.Add("errors", 1)
+		c.breaker.Failure()
+		return err
+	}
+
+	c.breaker.Success()
+	return err
+}
+
+// Listen drops in-process values deleted by other instances until ctx is
+// done. It does nothing when the in-process tier is off.
+func (c *Client) Listen(ctx context.Context) {
+	if c.l1 == nil {
+		return
+	}
+
+	sub := c.redis.Subscribe(ctx, invalidationChannel)
+	defer sub.Close()
+
+	ch := sub.Channel()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg, ok := <-ch:
+			if !ok {
+				return
+			}
+
+			var inv invalidation
+			if err := json.Unmarshal([]byte(msg.Payload), &inv); err != nil {
+				c.cfg.Logger.ErrorContext(ctx, "failed to decode invalidation", "method", "Listen", "err", err)
+				continue
+			}
+
+			if inv.Origin == c.id {
+				continue
+			}
+
+			metrics.Add("invalidations_received", 1)
+			c.invalidateL1(inv.Namespace, inv.Keys, inv.All)
+		}
+	}
+}
+
+func (c *Client) ttl(namespace string) time.Duration {
+	if ttl, ok := c.cfg.TTLs[namespace]; ok && ttl > 0 {
+		return ttl
+	}
+
+	return c.cfg.TTL
+}
+
+func generationKey(namespace string) string {
+	return keyPrefix + namespace + ":generation"
+}
+
+// keyGenerationKey counts the deletes of one key. It lives outside the
+// namespace prefix so a flush scan does not touch it.
+func keyGenerationKey(rkey string) string {
+	return "generation:" + rkey
+}
+
+// redisKey is the key a value is kept under in redis. Keys of versioned
+// namespaces carry the namespace generation, a flush bumps it.
+func redisKey(ctx context.Context, rdb *redis.Client, namespace string, versioned bool, key string) (string, error) {
+	if !versioned {
+		return keyPrefix + namespace + ":" + key, nil
+	}
+
+	generation, err := rdb.Get(ctx, generationKey(namespace)).Int64()
+	if err != nil && err != redis.Nil {
+		return "", err
+	}
+
+	return fmt.Sprintf("%s%s:%d:%s", keyPrefix, namespace, generation, key), nil
+}
+
+// resolve only goes to redis for versioned namespaces, a call that does
+// not reach redis must not count as a success for the breaker.
+func (c *Client) resolve(ctx context.Context, namespace string, versioned bool, key string) (string, error) {
+	if !versioned {
+		return redisKey(ctx, nil, namespace, false, key)
+	}
+
+	var rkey string
+	err := c.Do(ctx, func(ctx context.Context, rdb *redis.Client) (err error) {
+		rkey, err = redisKey(ctx, rdb, namespace, versioned, key)
+		return err
+	})
+
+	return rkey, err
+}
+
+func (c *Client) epoch(namespace string) uint64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.epochs[namespace] + c.epochAll
+}
+
+func (c *Client) getL1(namespace, key string) ([]byte, bool) {
+	if c.l1 == nil {
+		return nil, false
+	}
+
+	entry, ok := c.l1.get(namespace + ":" + key)
+	if ok {
+		metrics.Add(namespace+".l1_hits", 1)
+	}
+
+	return entry, ok
+}
+
+// setL1 keeps entry in process for the L1 TTL, or less when redis expires
+// it sooner, unless the namespace was invalidated since epoch.
+func (c *Client) setL1(namespace, key string, entry []byte, ttl time.Duration, epoch uint64) {
+	if c.l1 == nil {
+		return
+	}
+
+	switch {
+	case ttl == -1:
+		ttl = c.cfg.L1TTL
+	case ttl <= 0:
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.epochs[namespace]+c.epochAll != epoch {
+		return
+	}
+
+	c.l1.set(namespace+":"+key, entry, min(ttl, c.cfg.L1TTL))
+}
+
+func (c *Client) invalidateL1(namespace string, keys []string, all bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.epochs[namespace]++
+	if c.l1 == nil {
+		return
+	}
+
+	if all {
+		c.l1.deletePrefix(namespace + ":")
+		return
+	}
+
+	for _, key := range keys {
+		c.l1.delete(namespace + ":" + key)
+	}
+}
+
+// getL2 reads an entry and its remaining TTL.
+func (c *Client) getL2(ctx context.Context, rkey string) ([]byte, time.Duration, error) {
+	var (
+		entry []byte
+		ttl   time.Duration
+	)
+
+	err := c.Do(ctx, func(ctx context.Context, rdb *redis.Client) error {
+		pipe := rdb.Pipeline()
+		get := pipe.Get(ctx, rkey)
+		pttl := pipe.PTTL(ctx, rkey)
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return err
+		}
+
+		var err error
+		entry, err = get.Bytes()
+		ttl = pttl.Val()
+		return err
+	})
+
+	return entry, ttl, err
+}
+
+func (c *Client) get(ctx context.Context, namespace string, versioned bool, key string) ([]byte, error) {
+	if entry, ok := c.getL1(namespace, key); ok {
+		return entry, nil
+	}
+
+	epoch := c.epoch(namespace)
+	rkey, err := c.resolve(ctx, namespace, versioned, key)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	entry, ttl, err := c.getL2(ctx, rkey)
+	switch err {
+	case nil:
+		metrics.Add(namespace+".l2_hits", 1)
+		c.setL1(namespace, key, entry, ttl, epoch)
+		return entry, nil
+	case redis.Nil:
+		metrics.Add(namespace+".misses", 1)
+		return nil, ErrMiss
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+}
+
+func (c *Client) set(ctx context.Context, namespace string, versioned bool, key string, entry []byte, ttl time.Duration) error {
+	epoch := c.epoch(namespace)
+	err := c.Do(ctx, func(ctx context.Context, rdb *redis.Client) error {
+		rkey, err := redisKey(ctx, rdb, namespace, versioned, key)
+		if err != nil {
+			return err
+		}
+
+		return rdb.Set(ctx, rkey, entry, ttl).Err()
+	})
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	c.setL1(namespace, key, entry, ttl, epoch)
+	return nil
+}
+
+// load returns the entry of key, calling fn on a miss. Concurrent misses
+// share one call of fn within the process, and a short redis lock lets a
+// single instance call it while the others wait for the result. fn returns
+// the entry with a function giving its TTL, only called when the entry is
+// cached.
+func (c *Client) load(ctx context.Context, namespace string, versioned bool, key string, fn func(ctx context.Context) ([]byte, func() time.Duration, error)) ([]byte, error) {
+	// the load is shared by every caller of the key, it runs detached from
+	// the caller that started it so that caller giving up does not fail the
+	// others. Each caller still stops waiting once its own ctx is done.
+	loadCtx := context.WithoutCancel(ctx)
+	ch := c.loads.DoChan(namespace+":"+key, func() (interface{}, error) {
+		ctx := loadCtx
+
+		if entry, ok := c.getL1(namespace, key); ok {
+			return entry, nil
+		}
+
+		// the key is resolved once, so a value loaded while a flush bumps
+		// the generation is cached under the old one and never served
+		epoch := c.epoch(namespace)
+		rkey, err := c.resolve(ctx, namespace, versioned, key)
+		if err != nil {
+			entry, _, err := fn(ctx)
+			return entry, err
+		}
+
+		entry, ttl, err := c.getL2(ctx, rkey)
+		switch err {
+		case nil:
+			metrics.Add(namespace+".l2_hits", 1)
+			c.setL1(namespace, key, entry, ttl, epoch)
+			return entry, nil
+		case redis.Nil:
+			metrics.Add(namespace+".misses", 1)
+		default:
+			entry, _, err := fn(ctx)
+			return entry, err
+		}
+
+		token, locked := c.lock(ctx, rkey)
+		if locked {
+			defer c.unlock(ctx, rkey, token)
+		} else if entry, ttl, ok := c.waitFor(ctx, rkey); ok {
+			c.setL1(namespace, key, entry, ttl, epoch)
+			return entry, nil
+		}
+
+		// a delete landing while fn runs bumps the generation of the key,
+		// the value fn read before it is then not cached
+		generation, err := c.keyGeneration(ctx, rkey)
+		if err != nil {
+			entry, _, err := fn(ctx)
+			return entry, err
+		}
+
+		entry, expiration, err := fn(ctx)
+		if err != nil {
+			return nil, err
+		}
+
+		ttl = expiration()
+		var stored bool
+		err = c.Do(ctx, func(ctx context.Context, rdb *redis.Client) (err error) {
+			stored, err = setIfGenerationScript.Run(ctx, rdb, []string{rkey, keyGenerationKey(rkey)}, generation, entry, ttl.Milliseconds()).Bool()
+			return err
+		})
+		if err != nil {
+			if err != ErrUnavailable {
+				c.cfg.Logger.ErrorContext(ctx, "failed to cache value", "method", "load", "key", rkey, "err", err)
+			}
+			return entry, nil
+		}
+
+		if !stored {
+			metrics.Add(namespace+".stale_loads", 1)
+			return entry, nil
+		}
+
+		c.setL1(namespace, key, entry, ttl, epoch)
+		return entry, nil
+	})
+
+	select {
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case res := <-ch:
+		if res.Err != nil {
+			return nil, res.Err
+		}
+		return res.Val.([]byte), nil
+	}
+}
+
+// keyGeneration reads the generation of rkey, empty for keys never deleted.
+func (c *Client) keyGeneration(ctx context.Context, rkey string) (string, error) {
+	var generation string
+	err := c.Do(ctx, func(ctx context.Context, rdb *redis.Client) (err error) {
+		generation, err = rdb.Get(ctx, keyGenerationKey(rkey)).Result()
+		return err
+	})
+	if err == redis.Nil {
+		return "", nil
+	}
+
+	return generation, err
+}
+
+// setIfGenerationScript caches a loaded value only if the key was not
+// deleted since its generation was read. The TTL is in milliseconds, zero
+// keeps the value until it is deleted.
+var setIfGenerationScript = redis.NewScript(`
+	if (redis.call('GET', KEYS[2]) or '') ~= ARGV[1] then
+		return 0
+	end
+	if tonumber(ARGV[3]) > 0 then
+		redis.call('SET', KEYS[1], ARGV[2], 'PX', ARGV[3])
+	else
+		redis.call('SET', KEYS[1], ARGV[2])
+	end
+	return 1
+`)
+
+// unlockScript deletes a lock only if it still holds the caller's token, so
+// a loader that outlived its lock does not release the next one.
+var unlockScript = redis.NewScript(`
+	if redis.call('GET', KEYS[1]) == ARGV[1] then
+		return redis.call('DEL', KEYS[1])
+	end
+	return 0
+`)
+
+func (c *Client) lock(ctx context.Context, rkey string) (string, bool) {
+	var (
+		token  = uuid.NewString()
+		locked bool
+	)
+
+	err := c.Do(ctx, func(ctx context.Context, rdb *redis.Client) (err error) {
+		locked, err = rdb.SetNX(ctx, "lock:"+rkey, token, lockTTL).Result()
+		return err
+	})
+	if err != nil {
+		// without a lock every instance loads
+		return "", true
+	}
+
+	if !locked {
+		metrics.Add("lock_waits", 1)
+	}
+
+	return token, locked
+}
+
+func (c *Client) unlock(ctx context.Context, rkey, token string) {
+	if token == "" {
+		return
+	}
+
+	err := c.Do(ctx, func(ctx context.Context, rdb *redis.Client) error {
+		return unlockScript.Run(ctx, rdb, []string{"lock:" + rkey}, token).Err()
+	})
+	if err != nil && err != ErrUnavailable {
+		c.cfg.Logger.ErrorContext(ctx, "failed to release lock", "method", "unlock", "key", rkey, "err", err)
+	}
+}
+
+// waitFor polls rkey while another instance loads it. It gives up when the
+// wait is over or redis fails.
+func (c *Client) waitFor(ctx context.Context, rkey string) ([]byte, time.Duration, bool) {
+	deadline := time.Now().Add(lockWait)
+	for time.Now().Before(deadline) {
+		select {
+		case <-ctx.Done():
+			return nil, 0, false
+		case <-time.After(lockPoll):
+		}
+
+		entry, ttl, err := c.getL2(ctx, rkey)
+		switch err {
+		case nil:
+			return entry, ttl, true
+		case redis.Nil:
+		default:
+			return nil, 0, false
+		}
+	}
+
+	metrics.Add("lock_timeouts", 1)
+	return nil, 0, false
+}
+
+// delete drops keys from both tiers on every instance. It also bumps their
+// generations, so loads that read the source before the delete do not cache
+// what they read. It returns ErrDeletePending when the delete waits for
+// redis to come back.
+func (c *Client) delete(ctx context.Context, namespace string, versioned bool, keys ...string) error {
+	if c.redis == nil || len(keys) == 0 {
+		return nil
+	}
+
+	// deletes follow writes that already happened, they must not be cut
+	// short by the caller going away
+	ctx = context.WithoutCancel(ctx)
+
+	c.invalidateL1(namespace, keys, false)
+
+	err := c.Do(ctx, func(ctx context.Context, rdb *redis.Client) error {
+		rkeys := make([]string, 0, len(keys))
+		for _, key := range keys {
+			rkey, err := redisKey(ctx, rdb, namespace, versioned, key)
+			if err != nil {
+				return err
+			}
+			rkeys = append(rkeys, rkey)
+		}
+
+		_, err := rdb.TxPipelined(ctx, func(pipe redis.Pipeliner) error {
+			pipe.Del(ctx, rkeys...)
+			for _, rkey := range rkeys {
+				pipe.Incr(ctx, keyGenerationKey(rkey))
+				pipe.PExpire(ctx, keyGenerationKey(rkey), keyGenerationTTL)
+			}
+			return nil
+		})
+		return err
+	})
+	if err != nil {
+		if err != ErrUnavailable {
+			c.cfg.Logger.ErrorContext(ctx, "failed to delete keys", "method", "delete", "namespace", namespace, "err", err)
+		}
+		c.addPending(namespace, versioned, keys, false)
+		return ErrDeletePending
+	}
+
+	c.publish(ctx, invalidation{Namespace: namespace, Keys: keys})
+	return nil
+}
+
+// flush drops every value of a namespace on every instance. Versioned
+// namespaces bump their generation, the others delete their keys. It
+// returns ErrFlushPending when the flush waits for redis to come back.
+func (c *Client) flush(ctx context.Context, namespace string, versioned bool) error {
+	if c.redis == nil {
+		return nil
+	}
+
+	ctx = context.WithoutCancel(ctx)
+
+	c.invalidateL1(namespace, nil, true)
+
+	err := c.Do(ctx, func(ctx context.Context, rdb *redis.Client) error {
+		if versioned {
+			return rdb.Incr(ctx, generationKey(namespace)).Err()
+		}
+
+		iter := rdb.Scan(ctx, 0, keyPrefix+namespace+":*", 100).Iterator()
+		for iter.Next(ctx) {
+			if err := rdb.Del(ctx, iter.Val()).Err(); err != nil {
+				return err
+			}
+		}
+
+		return iter.Err()
+	})
+	if err != nil {
+		if err != ErrUnavailable {
+			c.cfg.Logger.ErrorContext(ctx, "failed to flush namespace", "method", "flush", "namespace", namespace, "err", err)
+		}
+		c.addPending(namespace, versioned, nil, true)
+		return ErrFlushPending
+	}
+
+	c.publish(ctx, invalidation{Namespace: namespace, All: true})
+	return nil
+}
+
+func (c *Client) publish(ctx context.Context, inv invalidation) {
+	if c.l1 == nil {
+		return
+	}
+
+	inv.Origin = c.id
+	payload, err := json.Marshal(inv)
+	if err != nil {
+		c.cfg.Logger.ErrorContext(ctx, "failed to encode invalidation", "method", "publish", "err", err)
+		return
+	}
+
+	err = c.Do(ctx, func(ctx context.Context, rdb *redis.Client) error {
+		return rdb.Publish(ctx, invalidationChannel, payload).Err()
+	})
+	if err != nil && err != ErrUnavailable {
+		c.cfg.Logger.ErrorContext(ctx, "failed to publish invalidation", "method", "publish", "err", err)
+	}
+}
+
+func (c *Client) addPending(namespace string, versioned bool, keys []string, flush bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	p, ok := c.pending[namespace]
+	if !ok {
+		p = &pendingEvictions{versioned: versioned, keys: make(map[string]struct{})}
+		c.pending[namespace] = p
+	}
+
+	p.flush = p.flush || flush
+	for _, key := range keys {
+		if len(p.keys) < maxPendingEvictions {
+			p.keys[key] = struct{}{}
+		}
+	}
+}
+
+// recover runs after an outage. The in-process tier may have missed
+// deletes from other instances so it starts over, and the deletes that
+// could not reach redis are replayed. Those failing again stay pending.
+func (c *Client) recover(ctx context.Context) {
+	c.mu.Lock()
+	c.epochAll++
+	if c.l1 != nil {
+		c.l1.clear()
+	}
+	pending := c.pending
+	c.pending = make(map[string]*pendingEvictions)
+	c.mu.Unlock()
+
+	for namespace, p := range pending {
+		if p.flush {
+			c.flush(ctx, namespace, p.versioned)
+			continue
+		}
+
+		c.delete(ctx, namespace, p.versioned, slices.Collect(maps.Keys(p.keys))...)
+	}
+}
@@ -0,0 +1,63 @@
+package cache
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestCodec(t *testing.T) {
+	suite.Run(t, new(CodecTestSuite))
+}
+
+type CodecTestSuite struct {
+	suite.Suite
+}
+
+type codecItem struct {
+	Id       string            `json:"id"`
+	Price    float64           `json:"price"`
+	Stock    int               `json:"stock"`
+	Tags     []string          `json:"tags,omitempty"`
+	Attrs    map[string]string `json:"attributes"`
+	Discount *float64          `json:"discount"`
+}
+
+func (s *CodecTestSuite) TestRoundTrip() {
+	discount := 0.25
+	item := &codecItem{
+		Id:       "p1",
+		Price:    19.99,
+		Stock:    3,
+		Tags:     []string{"sale"},
+		Attrs:    map[string]string{"size": "M"},
+		Discount: &discount,
+	}
+
+	for _, name := range []string{"json", "msgpack"} {
+		codec, err := NewCodec(name)
+		s.NoError(err)
+
+		data, err := codec.Marshal(item)
+		s.NoError(err)
+
+		var res *codecItem
+		s.NoError(codec.Unmarshal(data, &res))
+		s.Equal(item, res, name)
+	}
+}
+
+func (s *CodecTestSuite) TestMsgpackUsesJSONTags() {
+	data, err := Msgpack{}.Marshal(&codecItem{Id: "p1"})
+	s.NoError(err)
+
+	var res map[string]interface{}
+	s.NoError(Msgpack{}.Unmarshal(data, &res))
+	s.Contains(res, "id")
+	s.NotContains(res, "Id")
+}
+
+func (s *CodecTestSuite) TestUnknownCodec() {
+	_, err := NewCodec("gob")
+	s.Error(err)
+}
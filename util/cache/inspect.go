@@ -0,0 +1,152 @@
+package cache
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// ErrUnknownNamespace is returned for namespaces no cache was created for.
+var ErrUnknownNamespace = errors.New("unknown cache namespace")
+
+type Namespace struct {
+	Name      string
+	Versioned bool
+	TTL       time.Duration
+}
+
+// Entry describes what both tiers hold for a key. TTL is the time left in
+// redis, Value is decoded without knowing its type, as the codec would
+// decode it into an interface{}.
+type Entry struct {
+	Namespace string
+	Key       string
+	RedisKey  string
+	InL1      bool
+	InL2      bool
+	TTL       time.Duration
+	Size      int
+	NotFound  bool
+	Value     interface{}
+}
+
+func (c *Client) register(namespace string, versioned bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.namespaces[namespace] = versioned
+}
+
+func (c *Client) namespace(name string) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	versioned, ok := c.namespaces[name]
+	if !ok {
+		return false, ErrUnknownNamespace
+	}
+
+	return versioned, nil
+}
+
+// Namespaces lists the namespaces caches were created for, by name.
+func (c *Client) Namespaces() []Namespace {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	res := make([]Namespace, 0, len(c.namespaces))
+	for name, versioned := range c.namespaces {
+		res = append(res, Namespace{Name: name, Versioned: versioned, TTL: c.ttl(name)})
+	}
+
+	slices.SortFunc(res, func(a, b Namespace) int {
+		return cmp.Compare(a.Name, b.Name)
+	})
+
+	return res
+}
+
+// Flush drops every value of a namespace on every instance. It returns
+// ErrFlushPending when redis is unreachable, the flush then runs once redis
+// is back.
+func (c *Client) Flush(ctx context.Context, namespace string) error {
+	versioned, err := c.namespace(namespace)
+	if err != nil {
+		return err
+	}
+
+	return c.flush(ctx, namespace, versioned)
+}
+
+// Inspect looks key up in both tiers without counting the lookup as a hit
+// or a miss, and without caching anything. It returns ErrMiss when neither
+// tier has the key.
+func (c *Client) Inspect(ctx context.Context, namespace, key string) (*Entry, error) {
+	versioned, err := c.namespace(namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Entry{Namespace: namespace, Key: key}
+
+	var entry []byte
+	if c.l1 != nil {
+		entry, res.InL1 = c.l1.get(namespace + ":" + key)
+	}
+
+	err = c.Do(ctx, func(ctx context.Context, rdb *redis.Client) error {
+		rkey, err := redisKey(ctx, rdb, namespace, versioned, key)
+		if err != nil {
+			return err
+		}
+		res.RedisKey = rkey
+
+		pipe := rdb.Pipeline()
+		get := pipe.Get(ctx, rkey)
+		pttl := pipe.PTTL(ctx, rkey)
+		if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+			return err
+		}
+
+		data, err := get.Bytes()
+		if err != nil {
+			return err
+		}
+
+		// redis has the value every instance ends up with
+		entry = data
+		res.InL2 = true
+		res.TTL = pttl.Val()
+		return nil
+	})
+	switch err {
+	case nil, redis.Nil:
+	case ErrUnavailable:
+		return nil, err
+	default:
+		return nil, fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+
+	if !res.InL1 && !res.InL2 {
+		return nil, ErrMiss
+	}
+
+	res.Size = len(entry)
+	if len(entry) > 0 && entry[0] == notFoundEntry {
+		res.NotFound = true
+		return res, nil
+	}
+
+	if len(entry) > 1 {
+		if err := c.cfg.Codec.Unmarshal(entry[1:], &res.Value); err != nil {
+			return nil, err
+		}
+	}
+
+	return res, nil
+}
@@ -0,0 +1,119 @@
+package cache
+
+import (
+	"container/list"
+	"strings"
+	"sync"
+	"time"
+)
+
+// lru is the in-process tier, a size bounded least recently used map whose
+// entries also expire.
+type lru struct {
+	mu    sync.Mutex
+	size  int
+	items map[string]*list.Element
+	order *list.List
+	now   func() time.Time
+}
+
+type lruEntry struct {
+	key       string
+	value     []byte
+	expiresAt time.Time
+}
+
+func newLRU(size int) *lru {
+	return &lru{
+		size:  size,
+		items: make(map[string]*list.Element),
+		order: list.New(),
+		now:   time.Now,
+	}
+}
+
+func (l *lru) get(key string) ([]byte, bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	elem, ok := l.items[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := elem.Value.(*lruEntry)
+	if !l.now().Before(entry.expiresAt) {
+		l.remove(elem)
+		return nil, false
+	}
+
+	l.order.MoveToFront(elem)
+	return entry.value, true
+}
+
+func (l *lru) set(key string, value []byte, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	expiresAt := l.now().Add(ttl)
+	if elem, ok := l.items[key]; ok {
+		entry := elem.Value.(*lruEntry)
+		entry.value = value
+		entry.expiresAt = expiresAt
+		l.order.MoveToFront(elem)
+		return
+	}
+
+	l.items[key] = l.order.PushFront(&lruEntry{key: key, value: value, expiresAt: expiresAt})
+	for l.order.Len() > l.size {
+		l.remove(l.order.Back())
+	}
+}
+
+func (l *lru) delete(keys ...string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for _, key := range keys {
+		if elem, ok := l.items[key]; ok {
+			l.remove(elem)
+		}
+	}
+}
+
+// deletePrefix drops every entry whose key starts with prefix. It walks the
+// whole map, namespace flushes are rare.
+func (l *lru) deletePrefix(prefix string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for key, elem := range l.items {
+		if strings.HasPrefix(key, prefix) {
+			l.remove(elem)
+		}
+	}
+}
+
+func (l *lru) clear() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.items = make(map[string]*list.Element)
+	l.order.Init()
+}
+
+func (l *lru) len() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return l.order.Len()
+}
+
+func (l *lru) remove(elem *list.Element) {
+	l.order.Remove(elem)
+	delete(l.items, elem.Value.(*lruEntry).key)
+}
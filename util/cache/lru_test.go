@@ -0,0 +1,74 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestLRU(t *testing.T) {
+	suite.Run(t, new(LRUTestSuite))
+}
+
+type LRUTestSuite struct {
+	suite.Suite
+	lru *lru
+	now time.Time
+}
+
+func (s *LRUTestSuite) SetupTest() {
+	s.now = time.Date(2024, 11, 20, 10, 0, 0, 0, time.UTC)
+	s.lru = newLRU(2)
+	s.lru.now = func() time.Time { return s.now }
+}
+
+func (s *LRUTestSuite) TestEvictsLeastRecentlyUsed() {
+	s.lru.set("a", []byte("1"), time.Minute)
+	s.lru.set("b", []byte("2"), time.Minute)
+
+	// reading a makes b the least recently used
+	_, ok := s.lru.get("a")
+	s.True(ok)
+	s.lru.set("c", []byte("3"), time.Minute)
+
+	_, ok = s.lru.get("b")
+	s.False(ok)
+	value, ok := s.lru.get("a")
+	s.True(ok)
+	s.Equal([]byte("1"), value)
+	s.Equal(2, s.lru.len())
+}
+
+func (s *LRUTestSuite) TestExpires() {
+	s.lru.set("a", []byte("1"), time.Minute)
+
+	s.now = s.now.Add(time.Minute)
+	_, ok := s.lru.get("a")
+
+	s.False(ok)
+	s.Zero(s.lru.len())
+}
+
+func (s *LRUTestSuite) TestSetReplacesValue() {
+	s.lru.set("a", []byte("1"), time.Minute)
+	s.lru.set("a", []byte("2"), time.Minute)
+
+	value, ok := s.lru.get("a")
+	s.True(ok)
+	s.Equal([]byte("2"), value)
+	s.Equal(1, s.lru.len())
+}
+
+func (s *LRUTestSuite) TestDeletePrefix() {
+	s.lru = newLRU(10)
+	s.lru.set("products:page:1", []byte("1"), time.Minute)
+	s.lru.set("products:page:2", []byte("2"), time.Minute)
+	s.lru.set("product:p1", []byte("3"), time.Minute)
+
+	s.lru.deletePrefix("products:")
+
+	s.Equal(1, s.lru.len())
+	_, ok := s.lru.get("product:p1")
+	s.True(ok)
+}
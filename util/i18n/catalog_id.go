@@ -0,0 +1,101 @@
+package i18n
+
+// idCatalog translates the response messages to Indonesian. Entries with
+// verbs take the same arguments, in the same order, as the English message.
+var idCatalog = map[string]string{
+	"success":           "Berhasil",
+	"internal_error":    "terjadi kesalahan pada server",
+	"validation_failed": "validasi permintaan gagal",
+	"unauthorized":      "tidak terautentikasi",
+	"invalid_body":      "isi permintaan tidak valid",
+	"file_required":     "berkas wajib diunggah",
+	"file_too_large":    "ukuran berkas terlalu besar",
+
+	"user_exists":         "pengguna sudah terdaftar",
+	"invalid_credentials": "username atau kata sandi salah",
+	"not_admin":           "pengguna bukan admin",
+	"not_shop_owner":      "pengguna bukan pemilik toko",
+	"not_service":         "pemanggil bukan layanan internal",
+
+	"product_not_found":       "produk tidak ditemukan",
+	"product_already_deleted": "produk sudah dihapus",
+	"product_not_deleted":     "produk belum dihapus",
+	"restore_window_expired":  "batas waktu pemulihan sudah lewat",
+	"category_not_found":      "kategori tidak ditemukan",
+	"sku_exists":              "sku sudah digunakan",
+	"publish_at_required":     "publish_at wajib diisi untuk produk terjadwal",
+	"publish_at_in_past":      "publish_at harus di masa mendatang",
+	"receipt_quantity":        "jumlah penerimaan harus lebih dari nol",
+	"warehouse_not_found":     "gudang tidak ditemukan",
+	"insufficient_stock":      "stok tidak mencukupi",
+
+	"attribute_names_not_unique": "nama atribut harus unik",
+	"enum_values_required":       "atribut enum wajib memiliki nilai",
+	"attribute_not_scalar":       "atribut %q tidak valid: harus berupa teks, angka, atau boolean",
+	"attribute_not_defined":      "atribut %q tidak valid: tidak terdaftar pada kategori",
+	"attribute_required":         "atribut %q tidak valid: wajib diisi",
+	"attribute_not_string":       "atribut %q tidak valid: harus berupa teks",
+	"attribute_not_number":       "atribut %q tidak valid: harus berupa angka",
+	"attribute_not_boolean":      "atribut %q tidak valid: harus berupa boolean",
+	"attribute_not_allowed":      "atribut %q tidak valid: harus salah satu dari %s",
+
+	"no_options":               "produk tidak memiliki opsi",
+	"option_names_not_unique":  "nama opsi harus unik",
+	"options_locked":           "opsi tidak dapat diubah selama produk memiliki varian",
+	"variant_required":         "varian wajib dipilih",
+	"variant_not_found":        "varian tidak ditemukan",
+	"variant_exists":           "varian sudah ada",
+	"variant_options_mismatch": "opsi varian tidak sesuai dengan opsi produk",
+
+	"image_not_found":   "gambar tidak ditemukan",
+	"image_order":       "id gambar harus mencantumkan setiap gambar produk tepat satu kali",
+	"unsupported_image": "jenis gambar tidak didukung",
+	"invalid_image":     "gambar tidak valid",
+	"image_too_large":   "dimensi gambar terlalu besar",
+
+	"promotion_not_found":          "promosi tidak ditemukan",
+	"promotion_overlaps":           "promosi bertabrakan dengan promosi yang sudah ada",
+	"sale_price_too_high":          "harga promo harus lebih rendah dari harga asli",
+	"flash_sale_quantity_required": "kuota flash sale wajib diisi",
+	"flash_sale_not_active":        "flash sale tidak sedang berlangsung",
+	"flash_sale_sold_out":          "flash sale sudah habis",
+
+	"reservation_not_found":  "reservasi tidak ditemukan",
+	"reservation_not_active": "reservasi sudah tidak aktif",
+	"reservation_confirmed":  "reservasi sudah dikonfirmasi",
+	"reservation_not_paid":   "reservasi belum dibayar",
+	"reservation_refunded":   "reservasi sudah dikembalikan dananya seluruhnya",
+
+	"refund_not_found":     "pengembalian dana tidak ditemukan",
+	"order_line_not_found": "produk tidak termasuk dalam pesanan",
+	"nothing_to_refund":    "semua unit pesanan sudah dikembalikan dananya",
+	"refund_exceeds_order": "jumlah pengembalian melebihi unit yang dibeli dan belum dikembalikan",
+	"refund_failed":        "penyedia pembayaran tidak dapat mengembalikan dana",
+
+	"voucher_not_found":        "voucher tidak ditemukan",
+	"voucher_code_exists":      "kode voucher sudah digunakan",
+	"voucher_not_active":       "voucher tidak aktif",
+	"voucher_not_applicable":   "voucher tidak berlaku untuk produk yang dipilih",
+	"minimum_spend":            "minimum belanja belum tercapai",
+	"discount_too_high":        "diskon persentase tidak boleh melebihi 100",
+	"usage_limit_reached":      "batas penggunaan voucher sudah tercapai",
+	"user_usage_limit_reached": "batas penggunaan voucher per pengguna sudah tercapai",
+	"order_already_redeemed":   "pesanan sudah menggunakan voucher ini",
+
+	"review_not_found": "tinjauan tidak ditemukan",
+	"review_decided":   "tinjauan sudah diputuskan",
+	"reason_required":  "alasan wajib diisi untuk menolak produk",
+
+	"unsupported_import_format": "format impor tidak didukung",
+	"unsupported_export_format": "format ekspor tidak didukung",
+	"import_not_found":          "impor tidak ditemukan",
+	"import_file_invalid":       "berkas impor tidak valid: %v",
+	"import_column_missing":     "berkas impor tidak valid: kolom %s tidak ada",
+	"empty_import":              "berkas impor tidak memiliki baris",
+
+	"cache_key_not_found":     "kunci cache tidak ditemukan",
+	"unknown_cache_namespace": "namespace cache tidak dikenal",
+	"nothing_to_purge":        "tidak ada yang perlu dihapus",
+	"cache_unavailable":       "cache tidak tersedia",
+	"cache_purge_pending":     "cache tidak tersedia, penghapusan dijalankan setelah cache kembali",
+}
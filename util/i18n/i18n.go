@@ -0,0 +1,85 @@
+// Package i18n picks the language of a response and holds the message
+// catalogs, keyed by the codes of apperror errors. English is the fallback
+// language: a message missing from a catalog keeps its English text.
+// Validation rule messages are universal-translator entries registered on
+// the validator.
+package i18n
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-playground/locales/en"
+	"github.com/go-playground/locales/id"
+	ut "github.com/go-playground/universal-translator"
+)
+
+const (
+	EN = "en"
+	ID = "id"
+
+	DEFAULT_LOCALE = EN
+)
+
+var universal = ut.New(en.New(), en.New(), id.New())
+
+// English is the source language, its messages are the ones written where
+// the errors are defined.
+var catalogs = map[string]map[string]string{
+	EN: {},
+	ID: idCatalog,
+}
+
+// Negotiate returns the supported locale the Accept-Language header ranks
+// highest, regions are ignored so id-ID counts as id.
+func Negotiate(acceptLanguage string) string {
+	type candidate struct {
+		locale string
+		q      float64
+	}
+
+	var candidates []candidate
+	for _, part := range strings.Split(acceptLanguage, ",") {
+		tag, params, _ := strings.Cut(strings.TrimSpace(part), ";")
+		locale, _, _ := strings.Cut(strings.ToLower(strings.TrimSpace(tag)), "-")
+		if _, ok := catalogs[locale]; !ok {
+			continue
+		}
+
+		q := 1.0
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			parsed, err := strconv.ParseFloat(value, 64)
+			if err != nil {
+				continue
+			}
+			q = parsed
+		}
+		if q > 0 {
+			candidates = append(candidates, candidate{locale: locale, q: q})
+		}
+	}
+
+	if len(candidates) == 0 {
+		return DEFAULT_LOCALE
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool { return candidates[i].q > candidates[j].q })
+	return candidates[0].locale
+}
+
+// Message formats the catalog entry for code with args, or returns
+// fallback when the locale has no entry.
+func Message(locale, code, fallback string, args ...any) string {
+	format, ok := catalogs[locale][code]
+	if !ok {
+		return fallback
+	}
+	return fmt.Sprintf(format, args...)
+}
+
+func translator(locale string) ut.Translator {
+	trans, _ := universal.GetTranslator(locale)
+	return trans
+}
@@ -0,0 +1,41 @@
+package i18n
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestI18n(t *testing.T) {
+	suite.Run(t, new(I18nTestSuite))
+}
+
+type I18nTestSuite struct {
+	suite.Suite
+}
+
+func (s *I18nTestSuite) TestNegotiate() {
+	cases := map[string]string{
+		"":                        EN,
+		"id":                      ID,
+		"id-ID,id;q=0.9,en;q=0.8": ID,
+		"en-US,en;q=0.9,id;q=0.8": EN,
+		"fr-FR, id;q=0.5":         ID,
+		"en;q=0.2, id;q=0.7":      ID,
+		"id;q=0, en;q=0.1":        EN,
+		"fr, de":                  EN,
+		"ID-id":                   ID,
+		"id;q=invalid, en;q=0.3":  EN,
+	}
+
+	for header, want := range cases {
+		s.Equal(want, Negotiate(header), header)
+	}
+}
+
+func (s *I18nTestSuite) TestMessage() {
+	s.Equal("stok tidak mencukupi", Message(ID, "insufficient_stock", "insufficient stock"))
+	s.Equal("insufficient stock", Message(EN, "insufficient_stock", "insufficient stock"))
+	s.Equal(`atribut "size" tidak valid: wajib diisi`, Message(ID, "attribute_required", `invalid attribute "size": is required`, "size"))
+	s.Equal("something new", Message(ID, "not_in_catalog", "something new"))
+}
@@ -0,0 +1,118 @@
+package i18n
+
+import (
+	"reflect"
+
+	ut "github.com/go-playground/universal-translator"
+	"github.com/go-playground/validator"
+)
+
+// validationMessages holds the rule messages of the tags our requests use.
+// A rule may have a -string or -items variant for lengths and counts, {0}
+// is the field and {1} the rule parameter.
+var validationMessages = map[string]map[string]string{
+	EN: {
+		"required":     "{0} is required",
+		"email":        "{0} must be a valid email address",
+		"url":          "{0} must be a valid URL",
+		"uuid":         "{0} must be a valid UUID",
+		"oneof":        "{0} must be one of [{1}]",
+		"min":          "{0} must be at least {1}",
+		"min-string":   "{0} must be at least {1} characters long",
+		"min-items":    "{0} must contain at least {1} items",
+		"max":          "{0} must be at most {1}",
+		"max-string":   "{0} must be at most {1} characters long",
+		"max-items":    "{0} must contain at most {1} items",
+		"gt":           "{0} must be greater than {1}",
+		"gt-string":    "{0} must be longer than {1} characters",
+		"gt-items":     "{0} must contain more than {1} items",
+		"gte":          "{0} must be at least {1}",
+		"gte-string":   "{0} must be at least {1} characters long",
+		"gte-items":    "{0} must contain at least {1} items",
+		"gtfield":      "{0} must be greater than {1}",
+		"__fallback__": "{0} is invalid",
+	},
+	ID: {
+		"required":     "{0} wajib diisi",
+		"email":        "{0} harus berupa alamat email yang valid",
+		"url":          "{0} harus berupa URL yang valid",
+		"uuid":         "{0} harus berupa UUID yang valid",
+		"oneof":        "{0} harus salah satu dari [{1}]",
+		"min":          "{0} minimal {1}",
+		"min-string":   "{0} minimal {1} karakter",
+		"min-items":    "{0} minimal berisi {1} item",
+		"max":          "{0} maksimal {1}",
+		"max-string":   "{0} maksimal {1} karakter",
+		"max-items":    "{0} maksimal berisi {1} item",
+		"gt":           "{0} harus lebih besar dari {1}",
+		"gt-string":    "{0} harus lebih dari {1} karakter",
+		"gt-items":     "{0} harus berisi lebih dari {1} item",
+		"gte":          "{0} minimal {1}",
+		"gte-string":   "{0} minimal {1} karakter",
+		"gte-items":    "{0} minimal berisi {1} item",
+		"gtfield":      "{0} harus lebih besar dari {1}",
+		"__fallback__": "{0} tidak valid",
+	},
+}
+
+var validationTags = []string{"required", "email", "url", "uuid", "oneof", "min", "max", "gt", "gte", "gtfield"}
+
+const fallbackKey = "__fallback__"
+
+// RegisterValidator adds the rule messages of every supported locale to v,
+// its field errors can then be passed to ValidationMessage.
+func RegisterValidator(v *validator.Validate) error {
+	for locale, messages := range validationMessages {
+		trans := translator(locale)
+		for key, text := range messages {
+			if err := trans.Add(key, text, true); err != nil {
+				return err
+			}
+		}
+
+		for _, tag := range validationTags {
+			err := v.RegisterTranslation(tag, trans, func(ut.Translator) error { return nil }, translateField)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+// ValidationMessage is the message of fe in locale. Rules without a message
+// of their own get a generic one rather than the validator's debug text.
+func ValidationMessage(locale string, fe validator.FieldError) string {
+	trans := translator(locale)
+	for _, tag := range validationTags {
+		if tag == fe.Tag() {
+			return fe.Translate(trans)
+		}
+	}
+
+	message, err := trans.T(fallbackKey, fe.Field())
+	if err != nil {
+		return fe.Field()
+	}
+	return message
+}
+
+func translateField(trans ut.Translator, fe validator.FieldError) string {
+	key := fe.Tag()
+	switch fe.Kind() {
+	case reflect.String:
+		key += "-string"
+	case reflect.Slice, reflect.Map, reflect.Array:
+		key += "-items"
+	}
+
+	message, err := trans.T(key, fe.Field(), fe.Param())
+	if err != nil {
+		message, err = trans.T(fe.Tag(), fe.Field(), fe.Param())
+	}
+	if err != nil {
+		return fe.Field()
+	}
+	return message
+}
@@ -0,0 +1,114 @@
+// Package logging builds the service's slog logger. Records logged with a
+// context carry the attributes added to that context with WithAttrs, so the
+// request ID, user and route of a request end up on every line logged
+// while serving it.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
+)
+
+type Options struct {
+	// Level is debug, info, warn or error, empty means info.
+	Level string
+	// Format is json or text, empty means json.
+	Format    string
+	AddSource bool
+}
+
+func New(w io.Writer, opts Options) (*slog.Logger, error) {
+	var level slog.Level
+	if opts.Level != "" {
+		if err := level.UnmarshalText([]byte(opts.Level)); err != nil {
+			return nil, fmt.Errorf("invalid log level %q", opts.Level)
+		}
+	}
+
+	handlerOpts := &slog.HandlerOptions{Level: level, AddSource: opts.AddSource}
+
+	var handler slog.Handler
+	switch strings.ToLower(opts.Format) {
+	case "", "json":
+		handler = slog.NewJSONHandler(w, handlerOpts)
+	case "text":
+		handler = slog.NewTextHandler(w, handlerOpts)
+	default:
+		return nil, fmt.Errorf("unknown log format %q", opts.Format)
+	}
+
+	return slog.New(contextHandler{handler}), nil
+}
+
+type (
+	attrsKey   struct{}
+	trackerKey struct{}
+)
+
+// WithAttrs returns a context whose records carry attrs on top of the ones
+// ctx already carries.
+func WithAttrs(ctx context.Context, attrs ...slog.Attr) context.Context {
+	if t, ok := ctx.Value(trackerKey{}).(*tracker); ok {
+		t.add(attrs)
+	}
+
+	parent, _ := ctx.Value(attrsKey{}).([]slog.Attr)
+	return context.WithValue(ctx, attrsKey{}, append(parent[:len(parent):len(parent)], attrs...))
+}
+
+// TrackAttrs returns a context that remembers the attributes WithAttrs adds
+// to it further down, and a function listing them. A line logged with ctx
+// once the request is served can carry what inner handlers learned, such
+// as the user.
+func TrackAttrs(ctx context.Context) (context.Context, func() []slog.Attr) {
+	t := new(tracker)
+	return context.WithValue(ctx, trackerKey{}, t), t.list
+}
+
+type tracker struct {
+	mu    sync.Mutex
+	attrs []slog.Attr
+}
+
+func (t *tracker) add(attrs []slog.Attr) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.attrs = append(t.attrs, attrs...)
+}
+
+func (t *tracker) list() []slog.Attr {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	return slices.Clone(t.attrs)
+}
+
+// Discard is for tests and tools that build layers without a logger.
+func Discard() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+type contextHandler struct {
+	slog.Handler
+}
+
+func (h contextHandler) Handle(ctx context.Context, record slog.Record) error {
+	if attrs, ok := ctx.Value(attrsKey{}).([]slog.Attr); ok {
+		record.AddAttrs(attrs...)
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h contextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return contextHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h contextHandler) WithGroup(name string) slog.Handler {
+	return contextHandler{h.Handler.WithGroup(name)}
+}
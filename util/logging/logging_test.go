@@ -0,0 +1,95 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestLogging(t *testing.T) {
+	suite.Run(t, new(LoggingTestSuite))
+}
+
+type LoggingTestSuite struct {
+	suite.Suite
+}
+
+func (s *LoggingTestSuite) decode(buf *bytes.Buffer) map[string]any {
+	var record map[string]any
+	s.Require().NoError(json.Unmarshal(buf.Bytes(), &record))
+	return record
+}
+
+func (s *LoggingTestSuite) TestNew_Level() {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Options{Level: "warn"})
+	s.Require().NoError(err)
+
+	logger.Info("skipped")
+	s.Empty(buf.String())
+
+	logger.Warn("kept")
+	s.Equal("kept", s.decode(&buf)["msg"])
+}
+
+func (s *LoggingTestSuite) TestNew_InvalidOptions() {
+	_, err := New(&bytes.Buffer{}, Options{Level: "loud"})
+	s.Error(err)
+
+	_, err = New(&bytes.Buffer{}, Options{Format: "xml"})
+	s.Error(err)
+}
+
+func (s *LoggingTestSuite) TestNew_AddSource() {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Options{AddSource: true})
+	s.Require().NoError(err)
+
+	logger.Info("with source")
+	s.Contains(s.decode(&buf), slog.SourceKey)
+}
+
+func (s *LoggingTestSuite) TestWithAttrs() {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Options{})
+	s.Require().NoError(err)
+
+	ctx := WithAttrs(context.Background(), slog.String("request_id", "req-1"))
+	first := WithAttrs(ctx, slog.String("user_id", "user-1"))
+	second := WithAttrs(ctx, slog.String("user_id", "user-2"))
+
+	logger.With("component", "test").InfoContext(first, "first")
+	record := s.decode(&buf)
+	s.Equal("req-1", record["request_id"])
+	s.Equal("user-1", record["user_id"])
+	s.Equal("test", record["component"])
+
+	buf.Reset()
+	logger.InfoContext(second, "second")
+	record = s.decode(&buf)
+	s.Equal("req-1", record["request_id"])
+	s.Equal("user-2", record["user_id"])
+
+	buf.Reset()
+	logger.Info("plain")
+	s.NotContains(s.decode(&buf), "request_id")
+}
+
+func (s *LoggingTestSuite) TestTrackAttrs() {
+	var buf bytes.Buffer
+	logger, err := New(&buf, Options{})
+	s.Require().NoError(err)
+
+	ctx, added := TrackAttrs(WithAttrs(context.Background(), slog.String("route", "GET /")))
+	WithAttrs(ctx, slog.String("user_id", "user-1"))
+	s.Equal([]slog.Attr{slog.String("user_id", "user-1")}, added())
+
+	logger.LogAttrs(ctx, slog.LevelInfo, "request served", added()...)
+	record := s.decode(&buf)
+	s.Equal("GET /", record["route"])
+	s.Equal("user-1", record["user_id"])
+}
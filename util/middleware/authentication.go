@@ -2,8 +2,12 @@ package middleware
 
 import (
 	"codebase-service/helper"
+	"codebase-service/util/apperror"
+	"codebase-service/util/logging"
 	"context"
+	"log/slog"
 	"net/http"
+	"strings"
 )
 
 type contextKey string
@@ -11,6 +15,13 @@ type contextKey string
 const (
 	userIDKey contextKey = "user_id"
 	roleKey   contextKey = "role"
+
+	roleAdmin = "admin"
+)
+
+var (
+	errUnauthorized = apperror.New(apperror.Unauthorized, "unauthorized", "Unauthorized")
+	errNotAdmin     = apperror.New(apperror.Forbidden, "not_admin", "user is not admin")
 )
 
 func SetUserID(ctx context.Context, userID string) context.Context {
@@ -34,19 +45,20 @@ func Authentication(next http.Handler) http.Handler {
 		ctx := r.Context()
 		token := r.Header.Get("Authorization")
 		if token == "" {
-			helper.HandleResponse(w, http.StatusUnauthorized, "Unauthorized", nil)
+			helper.HandleError(w, r, errUnauthorized)
 			return
 		}
 
 		token = token[len("Bearer "):]
 		payload, err := VerifyToken(token)
 		if err != nil {
-			helper.HandleResponse(w, http.StatusUnauthorized, "Unauthorized", nil)
+			helper.HandleError(w, r, errUnauthorized)
 			return
 		}
 
 		ctx = SetUserID(ctx, payload.UserID)
 		ctx = SetRole(ctx, payload.Role)
+		ctx = logging.WithAttrs(ctx, slog.String("user_id", payload.UserID))
 
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
@@ -58,11 +70,37 @@ func GetUserId(next http.Handler) http.Handler {
 		userId := r.Header.Get("X-USER-ID")
 
 		if userId == "" {
-			helper.HandleResponse(w, http.StatusUnauthorized, "Unauthorized", nil)
+			helper.HandleError(w, r, errUnauthorized)
 			return
 		}
 
 		ctx = SetUserID(ctx, userId)
+		ctx = logging.WithAttrs(ctx, slog.String("user_id", userId))
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
 }
+
+// GetUserRole reads the role forwarded by the gateway. Requests without the
+// header carry an empty role and are treated as regular users.
+func GetUserRole(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx := r.Context()
+		role := r.Header.Get("X-USER-ROLE")
+
+		ctx = SetRole(ctx, role)
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// RequireAdmin lets only admins through, for routes without a usecase to
+// check the role. It reads the role GetUserRole set.
+func RequireAdmin(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.EqualFold(GetRole(r.Context()), roleAdmin) {
+			helper.HandleError(w, r, errNotAdmin)
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
@@ -1,76 +1,81 @@
 package middleware
 
 import (
-	"encoding/json"
-	"fmt"
-	"log"
+	"codebase-service/util/logging"
+	"log/slog"
 	"net/http"
-	"net/http/httptest"
-	"reflect"
 	"time"
 )
 
-func LoggerMiddleware() func(http.Handler) http.Handler {
+// LoggerMiddleware writes an access log line per request and adds the
+// matched route to the request's log attributes. The line also carries the
+// attributes inner middleware adds, like the user, and is written for
+// aborted responses too. Responses are not buffered, so streamed downloads
+// can be logged.
+func LoggerMiddleware(logger *slog.Logger) func(http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
+			ctx := logging.WithAttrs(r.Context(), slog.String("route", r.Pattern))
+			ctx, added := logging.TrackAttrs(ctx)
 
-			recorder := httptest.NewRecorder()
-			next.ServeHTTP(recorder, r)
+			sw := &statusWriter{ResponseWriter: w}
+			defer func() {
+				attrs := []slog.Attr{
+					slog.String("method", r.Method),
+					slog.String("path", r.URL.Path),
+					slog.String("proto", r.Proto),
+					slog.Int("status", sw.Status()),
+					slog.Duration("duration", time.Since(start)),
+					slog.String("remote_addr", r.RemoteAddr),
+					slog.String("user_agent", r.UserAgent()),
+				}
+				logger.LogAttrs(ctx, slog.LevelInfo, "request served", append(attrs, added()...)...)
+			}()
 
-			for k, v := range recorder.Header() {
-				w.Header()[k] = v
-			}
-			w.WriteHeader(recorder.Code)
-			recorder.Body.WriteTo(w)
-
-			responseTime := time.Since(start).Seconds()
-			logMessage := fmt.Sprintf("%s - [%s] - \"%s %s %s\" %d %s - [%s]\n",
-				r.RemoteAddr,
-				time.Now().Format(time.RFC1123),
-				r.Method,
-				r.URL.Path,
-				r.Proto,
-				recorder.Code,
-				r.UserAgent(),
-				fmt.Sprintf("%.9fµs", responseTime),
-			)
-			log.Print(logMessage)
+			next.ServeHTTP(sw, r.WithContext(ctx))
 		})
 	}
 }
 
-func ApplyMiddleware(h http.HandlerFunc, middlewares ...func(http.Handler) http.Handler) http.HandlerFunc {
-	handler := http.Handler(h)
-	for _, middleware := range middlewares {
-		handler = middleware(handler)
+// statusWriter remembers the status a handler answered with. Unwrap lets
+// http.ResponseController reach the connection underneath.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
 	}
+	w.ResponseWriter.WriteHeader(status)
+}
 
-	return handler.ServeHTTP
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	return w.ResponseWriter.Write(p)
 }
 
-func IsSlice(v interface{}) bool {
-	return reflect.TypeOf(v).Kind() == reflect.Slice
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
 }
 
-func DebugOutput(i interface{}) {
-	var mapRes map[string]interface{}
-	switch iData := i.(type) {
-	case string:
-		log.Println(iData)
-		return
-	case []byte:
-		json.Unmarshal(iData, &mapRes)
-		s, _ := json.MarshalIndent(mapRes, "", "\t")
-		fmt.Print(string(s))
-	default:
-		switch IsSlice(iData) {
-		case true:
-			s, _ := json.MarshalIndent(i, "", "\t")
-			fmt.Print(string(s))
-		default:
-			s, _ := json.MarshalIndent(i, "", "\t")
-			fmt.Print(string(s))
-		}
+// Status is what the client got, a handler that wrote nothing answered 200.
+func (w *statusWriter) Status() int {
+	if w.status == 0 {
+		return http.StatusOK
 	}
+	return w.status
+}
+
+func ApplyMiddleware(h http.HandlerFunc, middlewares ...func(http.Handler) http.Handler) http.HandlerFunc {
+	handler := http.Handler(h)
+	for _, middleware := range middlewares {
+		handler = middleware(handler)
+	}
+
+	return handler.ServeHTTP
 }
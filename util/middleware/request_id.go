@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"codebase-service/helper"
+	"codebase-service/util/logging"
+	"log/slog"
+	"net/http"
+
+	"github.com/google/uuid"
+)
+
+// maxRequestIDLength keeps a caller supplied ID from bloating logs and
+// responses.
+const maxRequestIDLength = 128
+
+// RequestID keeps the X-Request-ID sent by the gateway, or generates one,
+// echoes it on the response and adds it to the request's log attributes.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requestID := r.Header.Get(helper.REQUEST_ID_HEADER)
+		if requestID == "" || len(requestID) > maxRequestIDLength {
+			requestID = uuid.NewString()
+		}
+
+		w.Header().Set(helper.REQUEST_ID_HEADER, requestID)
+		ctx := helper.SetRequestID(r.Context(), requestID)
+		ctx = logging.WithAttrs(ctx, slog.String("request_id", requestID))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
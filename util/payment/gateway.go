@@ -0,0 +1,95 @@
+package payment
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var _ Provider = &Gateway{}
+
+type Connection struct {
+	URL       string
+	ServerKey string
+	Client    *http.Client
+}
+
+// Gateway calls the refund endpoint of the payment gateway,
+// POST {url}/orders/{order_id}/refunds, authenticated with the server key.
+// A 2xx answer carries the gateway's refund id, anything else is a failed
+// refund and its message is returned as the error.
+type Gateway struct {
+	url       string
+	serverKey string
+	client    *http.Client
+}
+
+func NewGateway(conn Connection) *Gateway {
+	client := conn.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	return &Gateway{
+		url:       strings.TrimSuffix(conn.URL, "/"),
+		serverKey: conn.ServerKey,
+		client:    client,
+	}
+}
+
+type refundBody struct {
+	RefundKey string  `json:"refund_key"`
+	Amount    float64 `json:"amount"`
+	Reason    string  `json:"reason"`
+}
+
+type refundAnswer struct {
+	Id      string `json:"id"`
+	Message string `json:"message"`
+}
+
+func (g *Gateway) Refund(ctx context.Context, req *RefundReq) (*RefundResp, error) {
+	if g.url == "" {
+		return nil, ErrNotConfigured
+	}
+
+	body, err := json.Marshal(&refundBody{
+		RefundKey: req.RefundKey,
+		Amount:    req.Amount,
+		Reason:    req.Reason,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	httpReq, err := http.NewRequestWithContext(ctx, http.MethodPost, g.url+"/orders/"+url.PathEscape(req.OrderId)+"/refunds", bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	httpReq.Header.Set("Content-Type", "application/json")
+	httpReq.SetBasicAuth(g.serverKey, "")
+
+	resp, err := g.client.Do(httpReq)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	var answer refundAnswer
+	if err := json.NewDecoder(resp.Body).Decode(&answer); err != nil && resp.StatusCode < http.StatusMultipleChoices {
+		return nil, fmt.Errorf("cannot decode refund answer: %w", err)
+	}
+
+	if resp.StatusCode >= http.StatusMultipleChoices {
+		if answer.Message == "" {
+			answer.Message = http.StatusText(resp.StatusCode)
+		}
+		return nil, fmt.Errorf("gateway refused the refund: %s", answer.Message)
+	}
+
+	return &RefundResp{Reference: answer.Id}, nil
+}
@@ -0,0 +1,88 @@
+package payment
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestGateway(t *testing.T) {
+	suite.Run(t, new(GatewayTestSuite))
+}
+
+type GatewayTestSuite struct {
+	suite.Suite
+	status int
+	answer string
+	got    *http.Request
+	body   refundBody
+	server *httptest.Server
+}
+
+func (s *GatewayTestSuite) SetupTest() {
+	s.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.got = r
+		s.Require().NoError(json.NewDecoder(r.Body).Decode(&s.body))
+		w.WriteHeader(s.status)
+		w.Write([]byte(s.answer))
+	}))
+	s.T().Cleanup(s.server.Close)
+}
+
+func (s *GatewayTestSuite) refund() (*RefundResp, error) {
+	gateway := NewGateway(Connection{URL: s.server.URL + "/", ServerKey: "server-key"})
+	return gateway.Refund(context.Background(), &RefundReq{
+		OrderId:   "order 1",
+		RefundKey: "refund-1",
+		Amount:    150000,
+		Reason:    "damaged",
+	})
+}
+
+func (s *GatewayTestSuite) TestRefund_Success() {
+	s.status = http.StatusCreated
+	s.answer = `{"id": "gw-1"}`
+
+	resp, err := s.refund()
+
+	s.Require().NoError(err)
+	s.Equal(&RefundResp{Reference: "gw-1"}, resp)
+	s.Equal(http.MethodPost, s.got.Method)
+	s.Equal("/orders/order%201/refunds", s.got.URL.EscapedPath())
+	s.Equal(refundBody{RefundKey: "refund-1", Amount: 150000, Reason: "damaged"}, s.body)
+
+	key, _, ok := s.got.BasicAuth()
+	s.True(ok)
+	s.Equal("server-key", key)
+}
+
+func (s *GatewayTestSuite) TestRefund_Refused() {
+	s.status = http.StatusUnprocessableEntity
+	s.answer = `{"message": "amount exceeds the captured payment"}`
+
+	resp, err := s.refund()
+
+	s.EqualError(err, "gateway refused the refund: amount exceeds the captured payment")
+	s.Nil(resp)
+}
+
+func (s *GatewayTestSuite) TestRefund_RefusedWithoutMessage() {
+	s.status = http.StatusBadGateway
+	s.answer = "upstream down"
+
+	resp, err := s.refund()
+
+	s.EqualError(err, "gateway refused the refund: Bad Gateway")
+	s.Nil(resp)
+}
+
+func (s *GatewayTestSuite) TestRefund_NotConfigured() {
+	resp, err := NewGateway(Connection{}).Refund(context.Background(), &RefundReq{})
+
+	s.ErrorIs(err, ErrNotConfigured)
+	s.Nil(resp)
+}
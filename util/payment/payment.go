@@ -0,0 +1,28 @@
+package payment
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrNotConfigured is returned by providers that have no gateway to call.
+var ErrNotConfigured = errors.New("payment gateway is not configured")
+
+// Provider moves money at the payment gateway. Refunds are keyed by the
+// caller's RefundKey, so a retried call does not pay back twice.
+type Provider interface {
+	Refund(ctx context.Context, req *RefundReq) (*RefundResp, error)
+}
+
+// RefundReq.OrderId is the order the payment was made for.
+type RefundReq struct {
+	OrderId   string
+	RefundKey string
+	Amount    float64
+	Reason    string
+}
+
+// RefundResp.Reference is the gateway's id of the refund.
+type RefundResp struct {
+	Reference string
+}
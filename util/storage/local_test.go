@@ -0,0 +1,51 @@
+package storage
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestLocalStore(t *testing.T) {
+	suite.Run(t, new(LocalStoreTestSuite))
+}
+
+type LocalStoreTestSuite struct {
+	suite.Suite
+	store *LocalStore
+}
+
+func (s *LocalStoreTestSuite) SetupTest() {
+	store, err := NewLocalStore(s.T().TempDir(), "http://localhost/uploads/files")
+	s.Require().NoError(err)
+	s.store = store
+
+	_, err = s.store.Put(context.Background(), "products/p1/a.png", strings.NewReader("png"), 3, "image/png")
+	s.Require().NoError(err)
+}
+
+func (s *LocalStoreTestSuite) serve(path string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	s.store.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	return rec
+}
+
+func (s *LocalStoreTestSuite) TestHandler_File() {
+	rec := s.serve("/products/p1/a.png")
+
+	s.Equal(http.StatusOK, rec.Code)
+	s.Equal("png", rec.Body.String())
+}
+
+func (s *LocalStoreTestSuite) TestHandler_Directory() {
+	for _, path := range []string{"/", "/products/", "/products/p1/", "/products/p1"} {
+		rec := s.serve(path)
+
+		s.Equal(http.StatusNotFound, rec.Code, path)
+		s.NotContains(rec.Body.String(), "a.png", path)
+	}
+}
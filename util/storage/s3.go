@@ -0,0 +1,74 @@
+package storage
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+var _ BlobStore = &S3Store{}
+
+type S3Connection struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	UseSSL    bool
+	PublicURL string
+}
+
+// S3Store writes blobs to any S3 compatible object storage, such as AWS S3,
+// MinIO or Cloudflare R2. Objects are served from PublicURL, which usually
+// points at the bucket or a CDN in front of it.
+type S3Store struct {
+	client    *minio.Client
+	bucket    string
+	publicURL string
+}
+
+func NewS3Store(conn S3Connection) (*S3Store, error) {
+	client, err := minio.New(conn.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(conn.AccessKey, conn.SecretKey, ""),
+		Secure: conn.UseSSL,
+		Region: conn.Region,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("cannot create s3 client: %w", err)
+	}
+
+	publicURL := conn.PublicURL
+	if publicURL == "" {
+		scheme := "http"
+		if conn.UseSSL {
+			scheme = "https"
+		}
+		publicURL = fmt.Sprintf("%s://%s/%s", scheme, conn.Endpoint, conn.Bucket)
+	}
+
+	return &S3Store{
+		client:    client,
+		bucket:    conn.Bucket,
+		publicURL: strings.TrimSuffix(publicURL, "/"),
+	}, nil
+}
+
+func (s *S3Store) Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (string, error) {
+	_, err := s.client.PutObject(ctx, s.bucket, key, r, size, minio.PutObjectOptions{
+		ContentType:  contentType,
+		CacheControl: "public, max-age=31536000, immutable",
+	})
+	if err != nil {
+		return "", err
+	}
+
+	return s.publicURL + "/" + key, nil
+}
+
+func (s *S3Store) Delete(ctx context.Context, key string) error {
+	return s.client.RemoveObject(ctx, s.bucket, key, minio.RemoveObjectOptions{})
+}
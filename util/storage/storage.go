@@ -0,0 +1,12 @@
+package storage
+
+import (
+	"context"
+	"io"
+)
+
+// BlobStore keeps uploaded files and hands back the URL they are served from.
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) (string, error)
+	Delete(ctx context.Context, key string) error
+}
@@ -0,0 +1,173 @@
+// Package xlsx writes single sheet XLSX workbooks as a stream. Rows go
+// straight to the underlying writer, nothing is kept in memory, so it suits
+// exports of any size. Strings are stored inline and there are no styles.
+package xlsx
+
+import (
+	"archive/zip"
+	"bufio"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	contentTypes = xml.Header + `<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>` +
+		`<Override PartName="/xl/worksheets/sheet1.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>` +
+		`</Types>`
+
+	rootRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+
+	workbook = xml.Header + `<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships">` +
+		`<sheets><sheet name="%s" sheetId="1" r:id="rId1"/></sheets></workbook>`
+
+	workbookRels = xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet1.xml"/>` +
+		`</Relationships>`
+
+	sheetStart = xml.Header + `<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main"><sheetData>`
+	sheetEnd   = `</sheetData></worksheet>`
+
+	// maxCellLength is the longest text a cell may hold
+	maxCellLength = 32767
+)
+
+// ContentType is the media type of an XLSX file.
+const ContentType = "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet"
+
+type Writer struct {
+	zip   *zip.Writer
+	sheet *bufio.Writer
+}
+
+// NewWriter starts a workbook with one sheet called sheetName. Close must be
+// called to finish the file.
+func NewWriter(w io.Writer, sheetName string) (*Writer, error) {
+	zw := zip.NewWriter(w)
+
+	var sheetNameXML strings.Builder
+	if err := xml.EscapeText(&sheetNameXML, []byte(sheetName)); err != nil {
+		return nil, err
+	}
+
+	parts := []struct{ name, body string }{
+		{"[Content_Types].xml", contentTypes},
+		{"_rels/.rels", rootRels},
+		{"xl/workbook.xml", fmt.Sprintf(workbook, sheetNameXML.String())},
+		{"xl/_rels/workbook.xml.rels", workbookRels},
+	}
+
+	for _, part := range parts {
+		f, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+
+		if _, err := io.WriteString(f, part.body); err != nil {
+			return nil, err
+		}
+	}
+
+	f, err := zw.Create("xl/worksheets/sheet1.xml")
+	if err != nil {
+		return nil, err
+	}
+
+	sheet := bufio.NewWriter(f)
+	if _, err := sheet.WriteString(sheetStart); err != nil {
+		return nil, err
+	}
+
+	return &Writer{zip: zw, sheet: sheet}, nil
+}
+
+// WriteRow adds a row. Numbers are written as numbers, times as RFC 3339
+// text, nil as an empty cell and anything else as text.
+func (w *Writer) WriteRow(values ...interface{}) error {
+	if _, err := w.sheet.WriteString("<row>"); err != nil {
+		return err
+	}
+
+	for _, value := range values {
+		if err := w.writeCell(value); err != nil {
+			return err
+		}
+	}
+
+	_, err := w.sheet.WriteString("</row>")
+	return err
+}
+
+func (w *Writer) writeCell(value interface{}) error {
+	var number string
+
+	switch v := value.(type) {
+	case nil:
+		_, err := w.sheet.WriteString("<c/>")
+		return err
+	case int:
+		number = strconv.Itoa(v)
+	case int64:
+		number = strconv.FormatInt(v, 10)
+	case float64:
+		number = strconv.FormatFloat(v, 'f', -1, 64)
+	case time.Time:
+		return w.writeText(v.Format(time.RFC3339))
+	case string:
+		return w.writeText(v)
+	default:
+		return w.writeText(fmt.Sprint(v))
+	}
+
+	_, err := fmt.Fprintf(w.sheet, "<c><v>%s</v></c>", number)
+	return err
+}
+
+func (w *Writer) writeText(text string) error {
+	if len(text) > maxCellLength {
+		text = strings.ToValidUTF8(text[:maxCellLength], "")
+	}
+
+	if _, err := w.sheet.WriteString(`<c t="inlineStr"><is><t xml:space="preserve">`); err != nil {
+		return err
+	}
+
+	if err := xml.EscapeText(w.sheet, []byte(text)); err != nil {
+		return err
+	}
+
+	_, err := w.sheet.WriteString("</t></is></c>")
+	return err
+}
+
+// Flush writes buffered rows to the underlying writer.
+func (w *Writer) Flush() error {
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+
+	return w.zip.Flush()
+}
+
+// Close ends the sheet and the workbook. It does not close the underlying
+// writer.
+func (w *Writer) Close() error {
+	if _, err := w.sheet.WriteString(sheetEnd); err != nil {
+		return err
+	}
+
+	if err := w.sheet.Flush(); err != nil {
+		return err
+	}
+
+	return w.zip.Close()
+}
@@ -0,0 +1,138 @@
+package xlsx
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/suite"
+)
+
+func TestXLSX(t *testing.T) {
+	suite.Run(t, new(XLSXTestSuite))
+}
+
+type XLSXTestSuite struct {
+	suite.Suite
+	buf bytes.Buffer
+}
+
+func (s *XLSXTestSuite) SetupTest() {
+	s.buf.Reset()
+}
+
+type sheetXML struct {
+	Rows []struct {
+		Cells []struct {
+			Type   string `xml:"t,attr"`
+			Value  string `xml:"v"`
+			Inline string `xml:"is>t"`
+		} `xml:"c"`
+	} `xml:"sheetData>row"`
+}
+
+type workbookXML struct {
+	Sheets []struct {
+		Name string `xml:"name,attr"`
+	} `xml:"sheets>sheet"`
+}
+
+// write builds a workbook of the given rows into s.buf.
+func (s *XLSXTestSuite) write(sheetName string, rows ...[]interface{}) {
+	w, err := NewWriter(&s.buf, sheetName)
+	s.Require().NoError(err)
+
+	for _, row := range rows {
+		s.Require().NoError(w.WriteRow(row...))
+	}
+
+	s.Require().NoError(w.Close())
+}
+
+// part reads a file of the workbook back out of the zip archive.
+func (s *XLSXTestSuite) part(name string) []byte {
+	zr, err := zip.NewReader(bytes.NewReader(s.buf.Bytes()), int64(s.buf.Len()))
+	s.Require().NoError(err)
+
+	f, err := zr.Open(name)
+	s.Require().NoError(err, name)
+	defer f.Close()
+
+	data, err := io.ReadAll(f)
+	s.Require().NoError(err)
+
+	return data
+}
+
+func (s *XLSXTestSuite) sheet() *sheetXML {
+	var res sheetXML
+	s.Require().NoError(xml.Unmarshal(s.part("xl/worksheets/sheet1.xml"), &res))
+	return &res
+}
+
+func (s *XLSXTestSuite) TestRoundTrip() {
+	createdAt := time.Date(2024, 11, 20, 10, 0, 0, 0, time.UTC)
+
+	s.write("Products",
+		[]interface{}{"id", "price", "stock", "sale_price", "created_at"},
+		[]interface{}{"p1", 10000.5, int64(5), nil, createdAt},
+	)
+
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "xl/_rels/workbook.xml.rels"} {
+		s.NotEmpty(s.part(name))
+	}
+
+	var workbook workbookXML
+	s.Require().NoError(xml.Unmarshal(s.part("xl/workbook.xml"), &workbook))
+	s.Require().Len(workbook.Sheets, 1)
+	s.Equal("Products", workbook.Sheets[0].Name)
+
+	sheet := s.sheet()
+	s.Require().Len(sheet.Rows, 2)
+	s.Len(sheet.Rows[0].Cells, 5)
+	s.Equal("created_at", sheet.Rows[0].Cells[4].Inline)
+
+	cells := sheet.Rows[1].Cells
+	s.Require().Len(cells, 5)
+	s.Equal("inlineStr", cells[0].Type)
+	s.Equal("p1", cells[0].Inline)
+	s.Equal("", cells[1].Type)
+	s.Equal("10000.5", cells[1].Value)
+	s.Equal("5", cells[2].Value)
+	s.Empty(cells[3].Value)
+	s.Empty(cells[3].Inline)
+	s.Equal("2024-11-20T10:00:00Z", cells[4].Inline)
+}
+
+func (s *XLSXTestSuite) TestEscapesText() {
+	text := `<b>Kaos & "celana"</b> ]]>`
+
+	s.write(`Q&A <2024>`, []interface{}{text, "  padded  "})
+
+	var workbook workbookXML
+	s.Require().NoError(xml.Unmarshal(s.part("xl/workbook.xml"), &workbook))
+	s.Equal(`Q&A <2024>`, workbook.Sheets[0].Name)
+
+	s.NotContains(string(s.part("xl/worksheets/sheet1.xml")), "<b>")
+
+	cells := s.sheet().Rows[0].Cells
+	s.Equal(text, cells[0].Inline)
+	s.Equal("  padded  ", cells[1].Inline)
+}
+
+func (s *XLSXTestSuite) TestTruncatesLongText() {
+	// the cut falls inside the last rune, which is dropped
+	long := strings.Repeat("a", maxCellLength-1) + "é" + "tail"
+
+	s.write("Products", []interface{}{long, strings.Repeat("b", maxCellLength)})
+
+	cells := s.sheet().Rows[0].Cells
+	s.Equal(strings.Repeat("a", maxCellLength-1), cells[0].Inline)
+	s.True(utf8.ValidString(cells[0].Inline))
+	s.Len(cells[1].Inline, maxCellLength)
+}